@@ -1,41 +1,118 @@
 package application
 
 import (
+	"context"
+	"fmt"
 	"go-bestflight/application/cli"
+	"go-bestflight/application/health"
 	"go-bestflight/application/web/http"
+	"go-bestflight/domain/services/airportservice"
 	"go-bestflight/domain/services/routeservice"
+	"go-bestflight/resources/airportfile"
 	"go-bestflight/resources/cache"
 	"go-bestflight/resources/database"
 	"go-bestflight/resources/file"
-	"io"
-	"log"
+	"go-bestflight/resources/logger"
+	"go-bestflight/resources/tracing"
 	"os"
 )
 
-func configLogFile(filePath string) io.Writer {
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
-	if err != nil {
-		log.Println("could not configure log file")
-	}
+// Mode defines which interfaces the application runs.
+type Mode string
 
-	log.SetOutput(file)
+const (
+	// Interactive runs the HTTP server and the advisor, until the advisor input ends. The server is
+	// shut down when a signal arrives in the quit channel or, at the latest, when the input ends.
+	Interactive Mode = "interactive"
+	// Headless runs only the HTTP server, until a signal arrives in the quit channel.
+	Headless Mode = "server"
+	// AdvisorOnly runs only the advisor, without the HTTP server, until its input ends.
+	AdvisorOnly Mode = "advisor"
+)
 
-	return file
-}
+// Modes are the valid modes, the default one first.
+var Modes = []Mode{Interactive, Headless, AdvisorOnly}
 
-func Start(filePath string, port string, quitChan chan os.Signal) {
-	loggerWriter := configLogFile("info.log")
+// connect connects the database and the cache.
+func connect() {
 	database.Connect()
 	cache.Connect()
+	registerGauges()
+}
+
+// load syncs the routes file and loads its routes and, when given, the airports reference file.
+// The application is ready once it is done.
+func load(filePath string, airportsFilePath string) {
+	ctx, span := tracing.Start(context.Background(), "load", "path", filePath)
+	defer span.End()
+
 	file.Sync(filePath)
 
 	routesFromFile, err := file.ReadFile()
 	if err != nil {
-		log.Fatalf("could not read from file %s: %v", filePath, err)
+		logger.Fatal("could not read the routes file", "path", filePath, "error", err)
 	}
 
-	routeservice.LoadRoutes(routesFromFile)
-	http.Start(port, "release", loggerWriter)
-	http.GracefullShutdown(quitChan)
-	cli.StartAdvisor()
+	routeservice.LoadRoutes(ctx, routesFromFile)
+
+	if airportsFilePath != "" {
+		airportsFromFile, err := airportfile.ReadFile(airportsFilePath)
+		if err != nil {
+			logger.Fatal("could not read the airports file", "path", airportsFilePath, "error", err)
+		}
+
+		logger.Info("airports loaded", "path", airportsFilePath, "airports", airportservice.LoadAirports(airportsFromFile))
+	}
+
+	health.MarkLoaded()
+}
+
+// Start bootstraps the resources and the HTTP server of the mode, loads the routes and, when given,
+// the airports reference file, and then starts watching the routes file and the advisor of the mode.
+// The server answers while the routes are loaded, so it can be probed, but it refuses the other
+// requests until they are. Start returns when the mode is over, once the server is shut down
+// gracefully, with the error of its shutdown. The port is not used by the AdvisorOnly mode.
+func Start(filePath string, port string, airportsFilePath string, mode Mode, quitChan chan os.Signal) error {
+	connect()
+
+	if mode != AdvisorOnly {
+		http.Start(port, "release")
+	}
+
+	load(filePath, airportsFilePath)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go watchRoutesFile(ReloadInterval, stop)
+
+	switch mode {
+	case AdvisorOnly:
+		cli.StartAdvisor()
+
+		return nil
+	case Headless:
+		return <-http.GracefullShutdown(quitChan, nil)
+	default:
+		ended := make(chan struct{})
+		done := http.GracefullShutdown(quitChan, ended)
+		shutdown := make(chan error, 1)
+
+		go func() {
+			err := <-done
+			shutdown <- err
+
+			select {
+			case <-ended:
+			default:
+				logger.Info("press ctrl + D to exit program completely")
+				fmt.Println("\nserver shutted down. Press ctrl + D to exit program completely")
+			}
+		}()
+
+		cli.StartAdvisor()
+		close(ended)
+
+		return <-shutdown
+	}
 }
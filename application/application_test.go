@@ -0,0 +1,40 @@
+package application
+
+import (
+	"go-bestflight/application/health"
+	"go-bestflight/resources/file"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestStart(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for Start", func() {
+		g.It("should shut the HTTP server down when the advisor input ends", func() {
+			dir, _ := ioutil.TempDir("", "application")
+			defer os.RemoveAll(dir)
+
+			input, ended, _ := os.Pipe()
+			ended.Close()
+
+			stdin := os.Stdin
+			os.Stdin = input
+			defer func() { os.Stdin = stdin }()
+
+			err := Start(filepath.Join(dir, "routes.csv"), "3010", "", Interactive, make(chan os.Signal, 1))
+			defer file.Remove()
+
+			g.Assert(err).Equal(nil)
+			g.Assert(health.Readiness().Checks[4]).Equal(health.Check{Name: "server", Ready: false, Detail: "shutting down"})
+
+			_, err = http.Get("http://localhost:3010/healthz")
+			g.Assert(err != nil).IsTrue()
+		})
+	})
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"go-bestflight/domain/errors"
+	"go-bestflight/domain/services/routeservice"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// The formats of the batch results.
+const (
+	BatchCSV  = "csv"
+	BatchJSON = "json"
+)
+
+// BatchResult is the result of a line of a batch. Error is empty when the best route was found.
+type BatchResult struct {
+	Line        int    `json:"line"`
+	Boarding    string `json:"boarding"`
+	Destination string `json:"destination"`
+	Route       string `json:"route,omitempty"`
+	Cost        int    `json:"cost,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type batchJob struct {
+	index int
+	line  int
+	input string
+}
+
+type batchDone struct {
+	index  int
+	result BatchResult
+}
+
+// parsePair parses the airports of a batch line like GRU-CDG, GRU,CDG or GRU CDG,
+// optionally followed by the maximum stops like in the advisor, e.g. GRU-CDG/2.
+func parsePair(input string) (string, string, []routeservice.SearchOption, error) {
+	fields := strings.FieldsFunc(input, func(c rune) bool {
+		return c == '-' || c == ',' || unicode.IsSpace(c)
+	})
+
+	if len(fields) != 2 {
+		return "", "", nil, errors.NewInvalidParameterErr("pair")
+	}
+
+	return getSearch(strings.Join(fields, "-"))
+}
+
+func search(service *routeservice.RouteService, job batchJob) BatchResult {
+	result := BatchResult{Line: job.line}
+
+	board, dest, options, err := parsePair(job.input)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Boarding = strings.ToUpper(board)
+	result.Destination = strings.ToUpper(dest)
+
+	bestRoute, err := service.GetBestRoute(context.Background(), board, dest, options...)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Route = bestRoute.Route
+	result.Cost = bestRoute.Cost
+
+	return result
+}
+
+// batchWriter writes the results in the batch format.
+type batchWriter interface {
+	write(result BatchResult) error
+	flush() error
+}
+
+type csvBatchWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvBatchWriter) write(result BatchResult) error {
+	cost := ""
+	if result.Error == "" {
+		cost = strconv.Itoa(result.Cost)
+	}
+
+	return w.writer.Write([]string{
+		strconv.Itoa(result.Line), result.Boarding, result.Destination, result.Route, cost, result.Error,
+	})
+}
+
+func (w *csvBatchWriter) flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonBatchWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonBatchWriter) write(result BatchResult) error {
+	return w.encoder.Encode(result)
+}
+
+func (w *jsonBatchWriter) flush() error {
+	return nil
+}
+
+func newBatchWriter(output io.Writer, format string) (batchWriter, error) {
+	switch format {
+	case BatchCSV:
+		writer := csv.NewWriter(output)
+		err := writer.Write([]string{"line", "boarding", "destination", "route", "cost", "error"})
+
+		return &csvBatchWriter{writer: writer}, err
+	case BatchJSON:
+		return &jsonBatchWriter{encoder: json.NewEncoder(output)}, nil
+	default:
+		return nil, errors.NewInvalidParameterErr("format")
+	}
+}
+
+// Batch looks for the best route of every pair of airports read from the input, one pair per line,
+// and writes a result per pair to the output, as CSV with a header or as JSON lines. Blank lines and
+// lines starting with # are skipped. Up to concurrency pairs are searched at the same time, but the
+// results are written in the order of the input. Errors of a pair are written in its result, so the
+// returned error is only about reading the input or writing the output.
+func Batch(service *routeservice.RouteService, input io.Reader, output io.Writer, format string, concurrency int) error {
+	writer, err := newBatchWriter(output, format)
+	if err != nil {
+		return err
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan batchJob, concurrency)
+	done := make(chan batchDone, concurrency)
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(jobs)
+
+		scan := bufio.NewScanner(input)
+		line, index := 0, 0
+
+		for scan.Scan() {
+			line++
+			text := strings.TrimSpace(scan.Text())
+
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+
+			jobs <- batchJob{index: index, line: line, input: text}
+			index++
+		}
+
+		readErr <- scan.Err()
+	}()
+
+	var workers sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for job := range jobs {
+				done <- batchDone{index: job.index, result: search(service, job)}
+			}
+		}()
+	}
+
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	// The results arrive in any order and are held until the ones before them are written.
+	pending := map[int]BatchResult{}
+	next := 0
+
+	for d := range done {
+		pending[d.index] = d.result
+
+		for result, ok := pending[next]; ok; result, ok = pending[next] {
+			delete(pending, next)
+			next++
+
+			if err == nil {
+				err = writer.write(result)
+			}
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := <-readErr; err != nil {
+		return err
+	}
+
+	return writer.flush()
+}
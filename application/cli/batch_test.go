@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/errors"
+	"go-bestflight/domain/services/routeservice"
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/database"
+	"go-bestflight/resources/repositories/airportrepository"
+	"go-bestflight/resources/repositories/routerepository"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestBatch(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	db := database.New()
+	service := routeservice.New(routerepository.New(db, db, cache.New(), nil), airportrepository.New(db))
+	service.LoadRoutes(context.Background(), []r.Route{
+		{Boarding: "GRU", Destination: "BRC", Cost: 10},
+		{Boarding: "BRC", Destination: "SCL", Cost: 5},
+		{Boarding: "GRU", Destination: "CDG", Cost: 75},
+		{Boarding: "GRU", Destination: "SCL", Cost: 20},
+		{Boarding: "GRU", Destination: "ORL", Cost: 56},
+		{Boarding: "ORL", Destination: "CDG", Cost: 5},
+		{Boarding: "SCL", Destination: "ORL", Cost: 20},
+	})
+
+	g.Describe("Tests for Batch", func() {
+		g.It("should write a CSV result per pair, including the errors", func() {
+			input := "GRU-CDG\n\n# comment\ngru,scl\nGRU CDG/0\nCDG-GRU\nGRU\n"
+			var output bytes.Buffer
+
+			err := Batch(service, strings.NewReader(input), &output, BatchCSV, 2)
+
+			g.Assert(err).Equal(nil)
+			g.Assert(output.String()).Equal("line,boarding,destination,route,cost,error\n" +
+				"1,GRU,CDG,GRU - BRC - SCL - ORL - CDG,40,\n" +
+				"4,GRU,SCL,GRU - BRC - SCL,15,\n" +
+				"5,GRU,CDG,GRU - CDG,75,\n" +
+				"6,CDG,GRU,,," + errors.NewBestRouteNotFoundErr().Error() + "\n" +
+				"7,,,,," + errors.NewInvalidParameterErr("pair").Error() + "\n")
+		})
+
+		g.It("should write JSON lines in the order of the input with many concurrent searches", func() {
+			pairs := []string{"GRU-CDG", "GRU-SCL", "ORL-CDG", "BRC-CDG", "SCL-CDG"}
+			var input strings.Builder
+
+			for i := 0; i < 500; i++ {
+				fmt.Fprintln(&input, pairs[i%len(pairs)])
+			}
+
+			var output bytes.Buffer
+
+			err := Batch(service, strings.NewReader(input.String()), &output, BatchJSON, 16)
+			g.Assert(err).Equal(nil)
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			g.Assert(len(lines)).Equal(500)
+
+			for i, line := range lines {
+				var result BatchResult
+				json.Unmarshal([]byte(line), &result)
+
+				g.Assert(result.Line).Equal(i + 1)
+				g.Assert(result.Boarding + "-" + result.Destination).Equal(pairs[i%len(pairs)])
+				g.Assert(result.Error).Equal("")
+			}
+		})
+
+		g.It("should fail with InvalidParameterErr for an unknown format", func() {
+			var output bytes.Buffer
+
+			err := Batch(service, strings.NewReader("GRU-CDG\n"), &output, "xml", 1)
+
+			g.Assert(err).Equal(errors.NewInvalidParameterErr("format"))
+			g.Assert(output.Len()).Equal(0)
+		})
+	})
+}
@@ -2,39 +2,72 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/errors"
+	"go-bestflight/domain/services/airportservice"
 	"go-bestflight/domain/services/routeservice"
-	"log"
+	"go-bestflight/resources/logger"
+	"io"
 	"os"
-	"runtime"
+	"strconv"
 	"strings"
 )
 
-const delimiter = '\n'
+const prompt = "bestflight> "
 
-func getInput() string {
-	inputDeviceLocation := "/dev/stdin"
+// Advisor is the interactive agent that reads commands and desired routes line by line.
+type Advisor struct {
+	routes   *routeservice.RouteService
+	airports *airportservice.AirportService
+	input    *bufio.Scanner
+	output   io.Writer
+	history  []string
+	commands map[string]advisorCommand
+}
 
-	if runtime.GOOS == "windows" {
-		inputDeviceLocation = "CON"
-	}
+// advisorCommand is a command of the advisor. run returns false when the advisor must stop.
+type advisorCommand struct {
+	usage   string
+	summary string
+	run     func(a *Advisor, args []string) bool
+}
 
-	file, err := os.Open(inputDeviceLocation)
-	if err != nil {
-		log.Fatal(err)
-	}
+var advisorCommands = map[string]advisorCommand{
+	"add":      {"add GRU CDG 50", "register a route", (*Advisor).add},
+	"update":   {"update GRU CDG 60", "replace the cost of a route", (*Advisor).update},
+	"delete":   {"delete GRU CDG", "delete a route", (*Advisor).delete},
+	"list":     {"list [GRU]", "list the routes, or the routes leaving or arriving at an airport", (*Advisor).list},
+	"airports": {"airports", "list the airports of the routes", (*Advisor).listAirports},
+	"stats":    {"stats", "show the size and the costs of the network", (*Advisor).stats},
+	"alt":      {"alt GRU-CDG 3", "show up to 3 cheapest routes", (*Advisor).alternatives},
+	"export":   {"export csv|json|dot|geojson [file]", "export the routes", (*Advisor).export},
+	"history":  {"history", "show the previous lines, which can be run again with !n, or !! for the last one", (*Advisor).showHistory},
+	"help":     {"help", "show this help", (*Advisor).help},
+	"quit":     {"quit", "stop the advisor", func(*Advisor, []string) bool { return false }},
+}
 
-	reader := bufio.NewReader(file)
-	input, err := reader.ReadString(delimiter)
-	if err != nil {
-		log.Fatal(err)
+// helpOrder is the order of the commands in the help.
+var helpOrder = []string{"add", "update", "delete", "list", "airports", "stats", "alt", "export", "history", "help", "quit"}
+
+// NewAdvisor is a constructor for an Advisor over the given services, reading from input and writing to output.
+func NewAdvisor(routes *routeservice.RouteService, airports *airportservice.AirportService, input io.Reader, output io.Writer) *Advisor {
+	return &Advisor{
+		routes:   routes,
+		airports: airports,
+		input:    bufio.NewScanner(input),
+		output:   output,
+		commands: advisorCommands,
 	}
+}
 
-	input = strings.Replace(input, "\n", "", -1)
-	input = strings.Replace(input, "\r", "", -1) // for Windows
-	input = strings.Trim(input, " ")
+func (a *Advisor) printf(format string, args ...interface{}) {
+	fmt.Fprintf(a.output, format, args...)
+}
 
-	return input
+func (a *Advisor) printRoute(prefix string, route r.Route) {
+	a.printf("%s%s - %s > $%d\n", prefix, route.Boarding, route.Destination, route.Cost)
 }
 
 func getBoardingAndDestination(input string) (string, string) {
@@ -47,21 +80,399 @@ func getBoardingAndDestination(input string) (string, string) {
 	return components[0], components[1]
 }
 
-// StartAdvisor starts the agent that will be asking for desired routes by command line.
-func StartAdvisor() {
-	log.Println("starting Advisor...")
+// getSearch parses inputs like GRU-CDG, or GRU-CDG/2 for at most two stops.
+func getSearch(input string) (string, string, []routeservice.SearchOption, error) {
+	components := strings.Split(input, "/")
+
+	if len(components) > 2 {
+		return "", "", nil, errors.NewInvalidParameterErr("max stops")
+	}
+
+	board, dest := getBoardingAndDestination(components[0])
+	options := []routeservice.SearchOption{}
+
+	if len(components) == 2 {
+		maxStops, err := strconv.Atoi(components[1])
+		if err != nil {
+			return "", "", nil, errors.NewInvalidParameterErr("max stops")
+		}
+
+		options = append(options, routeservice.WithMaxStops(maxStops))
+	}
+
+	return board, dest, options, nil
+}
+
+// routeArgs parses the airports of a route given as "GRU CDG" or "GRU-CDG" and returns the remaining arguments.
+func routeArgs(args []string) (string, string, []string, bool) {
+	if len(args) > 0 && strings.Contains(args[0], "-") {
+		board, dest := getBoardingAndDestination(args[0])
+		return board, dest, args[1:], board != "" && dest != ""
+	}
+
+	if len(args) < 2 {
+		return "", "", nil, false
+	}
+
+	return args[0], args[1], args[2:], true
+}
+
+// costArgs parses a route with its cost, e.g. "GRU CDG 50".
+func costArgs(args []string) (r.Route, bool) {
+	board, dest, rest, ok := routeArgs(args)
+	if !ok || len(rest) != 1 {
+		return r.Route{}, false
+	}
+
+	cost, err := strconv.Atoi(rest[0])
+	if err != nil {
+		return r.Route{}, false
+	}
+
+	return r.Route{Boarding: strings.ToUpper(board), Destination: strings.ToUpper(dest), Cost: cost}, true
+}
+
+func (a *Advisor) usage(name string) bool {
+	a.printf("usage: %s\n", a.commands[name].usage)
+	return true
+}
+
+func (a *Advisor) add(args []string) bool {
+	route, ok := costArgs(args)
+	if !ok {
+		return a.usage("add")
+	}
+
+	addedRoute, err := a.routes.AddNewRoute(context.Background(), route)
+	if err != nil {
+		a.printf("%s\n", err.Error())
+		return true
+	}
+
+	a.printRoute("route added: ", addedRoute)
+
+	return true
+}
+
+func (a *Advisor) update(args []string) bool {
+	route, ok := costArgs(args)
+	if !ok {
+		return a.usage("update")
+	}
+
+	updatedRoute, err := a.routes.UpdateRouteCost(context.Background(), route)
+	if err != nil {
+		a.printf("%s\n", err.Error())
+		return true
+	}
+
+	a.printRoute("route updated: ", updatedRoute)
+
+	return true
+}
+
+func (a *Advisor) delete(args []string) bool {
+	board, dest, rest, ok := routeArgs(args)
+	if !ok || len(rest) > 0 {
+		return a.usage("delete")
+	}
+
+	deletedRoute, err := a.routes.DeleteRoute(context.Background(), board, dest)
+	if err != nil {
+		a.printf("%s\n", err.Error())
+		return true
+	}
+
+	a.printRoute("route deleted: ", deletedRoute)
+
+	return true
+}
+
+// allRoutes returns every route matching the query, going through all its pages.
+func (a *Advisor) allRoutes(query routeservice.RouteListQuery) ([]r.Route, error) {
+	routes := []r.Route{}
+	query.Limit = routeservice.MaxPageSize
 
 	for {
-		fmt.Print("please enter the route: ")
-		input := getInput()
-		board, dest := getBoardingAndDestination(input)
+		page, err := a.routes.ListRoutes(context.Background(), query)
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, page.Routes...)
+
+		if page.NextCursor == "" {
+			return routes, nil
+		}
+
+		query.Cursor = page.NextCursor
+	}
+}
+
+func (a *Advisor) list(args []string) bool {
+	if len(args) > 1 {
+		return a.usage("list")
+	}
+
+	queries := []routeservice.RouteListQuery{{}}
 
-		bestRoute, err := routeservice.GetBestRoute(board, dest)
+	if len(args) == 1 {
+		queries = []routeservice.RouteListQuery{{Boarding: args[0]}, {Destination: args[0]}}
+	}
+
+	count := 0
+
+	for _, query := range queries {
+		routes, err := a.allRoutes(query)
 		if err != nil {
-			fmt.Println(err.Error())
+			a.printf("%s\n", err.Error())
+			return true
+		}
+
+		for _, route := range routes {
+			a.printRoute("", route)
+		}
+
+		count += len(routes)
+	}
+
+	a.printf("%d routes\n", count)
+
+	return true
+}
+
+func (a *Advisor) listAirports(args []string) bool {
+	if len(args) > 0 {
+		return a.usage("airports")
+	}
+
+	registered := a.airports.GetAirports()
+
+	for _, airport := range registered {
+		if airport.Name == "" {
+			a.printf("%s\n", airport.Code)
 			continue
 		}
 
-		fmt.Printf("best route: %s > $%d\n", bestRoute.Route, bestRoute.Cost)
+		a.printf("%s %s (%s, %s)\n", airport.Code, airport.Name, airport.City, airport.Country)
+	}
+
+	a.printf("%d airports\n", len(registered))
+
+	return true
+}
+
+func (a *Advisor) stats(args []string) bool {
+	if len(args) > 0 {
+		return a.usage("stats")
+	}
+
+	routes, err := a.allRoutes(routeservice.RouteListQuery{SortBy: "cost"})
+	if err != nil {
+		a.printf("%s\n", err.Error())
+		return true
 	}
+
+	a.printf("routes: %d\n", len(routes))
+	a.printf("airports: %d\n", len(a.airports.GetAirports()))
+
+	if len(routes) == 0 {
+		return true
+	}
+
+	total := 0
+
+	for _, route := range routes {
+		total += route.Cost
+	}
+
+	a.printRoute("cheapest route: ", routes[0])
+	a.printRoute("most expensive route: ", routes[len(routes)-1])
+	a.printf("average cost: $%.2f\n", float64(total)/float64(len(routes)))
+
+	return true
+}
+
+func (a *Advisor) alternatives(args []string) bool {
+	if len(args) != 2 {
+		return a.usage("alt")
+	}
+
+	board, dest, options, err := getSearch(args[0])
+	if err != nil {
+		a.printf("%s\n", err.Error())
+		return true
+	}
+
+	k, err := strconv.Atoi(args[1])
+	if err != nil {
+		return a.usage("alt")
+	}
+
+	bestRoutes, err := a.routes.GetBestRoutes(context.Background(), board, dest, k, options...)
+	if err != nil {
+		a.printf("%s\n", err.Error())
+		return true
+	}
+
+	for i, bestRoute := range bestRoutes {
+		a.printf("%d. %s > $%d\n", i+1, bestRoute.Route, bestRoute.Cost)
+	}
+
+	return true
+}
+
+// export handles inputs like "export dot" or "export geojson routes.geojson",
+// writing to the output when no file is given.
+func (a *Advisor) export(args []string) bool {
+	if len(args) == 0 || len(args) > 2 {
+		return a.usage("export")
+	}
+
+	if len(args) == 1 {
+		err := a.routes.Export(context.Background(), a.output, args[0])
+		if err != nil {
+			a.printf("%s\n", err.Error())
+		}
+
+		return true
+	}
+
+	output, err := os.Create(args[1])
+	if err != nil {
+		a.printf("%s\n", err.Error())
+		return true
+	}
+	defer output.Close()
+
+	err = a.routes.Export(context.Background(), output, args[0])
+	if err != nil {
+		os.Remove(args[1])
+		a.printf("%s\n", err.Error())
+		return true
+	}
+
+	a.printf("routes exported to %s\n", args[1])
+
+	return true
+}
+
+func (a *Advisor) showHistory(args []string) bool {
+	for i, line := range a.history {
+		a.printf("%4d  %s\n", i+1, line)
+	}
+
+	return true
+}
+
+func (a *Advisor) help(args []string) bool {
+	a.printf("Enter a route like GRU-CDG, or GRU-CDG/2 for at most two stops, or one of the commands:\n")
+
+	for _, name := range helpOrder {
+		command := a.commands[name]
+		a.printf("  %-36s %s\n", command.usage, command.summary)
+	}
+
+	return true
+}
+
+func (a *Advisor) search(input string) {
+	board, dest, options, err := getSearch(input)
+	if err != nil {
+		a.printf("%s\n", err.Error())
+		return
+	}
+
+	bestRoute, err := a.routes.GetBestRoute(context.Background(), board, dest, options...)
+	if err != nil {
+		a.printf("%s\n", err.Error())
+		return
+	}
+
+	a.printf("best route: %s > $%d\n", bestRoute.Route, bestRoute.Cost)
+}
+
+// expand replaces !! by the last line of the history and !n by its nth line.
+func (a *Advisor) expand(line string) (string, bool) {
+	if !strings.HasPrefix(line, "!") {
+		return line, true
+	}
+
+	n := len(a.history)
+
+	if line != "!!" {
+		var err error
+
+		n, err = strconv.Atoi(strings.TrimPrefix(line, "!"))
+		if err != nil {
+			return "", false
+		}
+	}
+
+	if n < 1 || n > len(a.history) {
+		return "", false
+	}
+
+	return a.history[n-1], true
+}
+
+// Execute runs a line of the advisor and returns false when the advisor must stop.
+func (a *Advisor) Execute(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+
+	expanded, ok := a.expand(line)
+	if !ok {
+		a.printf("%s: event not found\n", line)
+		return true
+	}
+
+	if expanded != line {
+		a.printf("%s\n", expanded)
+	}
+
+	a.history = append(a.history, expanded)
+
+	fields := strings.Fields(expanded)
+
+	if command, ok := a.commands[strings.ToLower(fields[0])]; ok {
+		return command.run(a, fields[1:])
+	}
+
+	a.search(expanded)
+
+	return true
+}
+
+// Run reads and runs the lines of the input until it ends or the advisor is quit.
+func (a *Advisor) Run() {
+	for {
+		a.printf(prompt)
+
+		if !a.input.Scan() {
+			if err := a.input.Err(); err != nil {
+				logger.Error("could not read the advisor input", "error", err)
+			}
+
+			a.printf("\n")
+
+			return
+		}
+
+		if !a.Execute(a.input.Text()) {
+			return
+		}
+	}
+}
+
+// StartAdvisor starts the agent that will be asking for desired routes by command line, over the
+// resources shared by the application. It returns when the input ends or the advisor is quit.
+func StartAdvisor() {
+	logger.Info("starting the advisor")
+
+	NewAdvisor(routeservice.Default(), airportservice.Default(), os.Stdin, os.Stdout).Run()
+
+	logger.Info("advisor stopped")
 }
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/errors"
+	"go-bestflight/domain/services/airportservice"
+	"go-bestflight/domain/services/routeservice"
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/database"
+	"go-bestflight/resources/file"
+	"go-bestflight/resources/repositories/airportrepository"
+	"go-bestflight/resources/repositories/routerepository"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func newAdvisor(t *testing.T, input string) (*Advisor, *bytes.Buffer) {
+	dir, err := ioutil.TempDir("", "advisor")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	db := database.New()
+	routesFile, err := file.Open(filepath.Join(dir, "routes.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(routesFile.Remove)
+
+	airports := airportrepository.New(db)
+	service := routeservice.New(routerepository.New(db, db, cache.New(), routesFile), airports)
+	service.LoadRoutes(context.Background(), []r.Route{
+		{Boarding: "GRU", Destination: "BRC", Cost: 10},
+		{Boarding: "BRC", Destination: "SCL", Cost: 5},
+		{Boarding: "GRU", Destination: "CDG", Cost: 75},
+		{Boarding: "SCL", Destination: "CDG", Cost: 40},
+	})
+
+	var output bytes.Buffer
+
+	return NewAdvisor(service, airportservice.New(airports), strings.NewReader(input), &output), &output
+}
+
+func TestAdvisor(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for the Advisor", func() {
+		g.It("should search the best route and stop at the end of the input", func() {
+			advisor, output := newAdvisor(t, "GRU-CDG\nGRU-CDG/0\nCDG-GRU\n")
+
+			advisor.Run()
+
+			g.Assert(output.String()).Equal(prompt + "best route: GRU - BRC - SCL - CDG > $55\n" +
+				prompt + "best route: GRU - CDG > $75\n" +
+				prompt + errors.NewBestRouteNotFoundErr().Error() + "\n" +
+				prompt + "\n")
+		})
+
+		g.It("should add, update and delete routes", func() {
+			advisor, output := newAdvisor(t, "add cdg orl 20\nupdate CDG ORL 25\ndelete CDG-ORL\ndelete CDG ORL\n")
+
+			advisor.Run()
+
+			g.Assert(strings.Split(output.String(), prompt)[1:4]).Equal([]string{
+				"route added: CDG - ORL > $20\n",
+				"route updated: CDG - ORL > $25\n",
+				"route deleted: CDG - ORL > $25\n",
+			})
+			g.Assert(strings.Contains(output.String(), errors.NewRouteNotFoundErr().Error())).IsTrue()
+		})
+
+		g.It("should show the usage of a command given wrong arguments", func() {
+			advisor, output := newAdvisor(t, "")
+
+			advisor.Execute("add GRU ORL")
+			advisor.Execute("alt GRU-CDG many")
+
+			g.Assert(output.String()).Equal("usage: add GRU CDG 50\nusage: alt GRU-CDG 3\n")
+		})
+
+		g.It("should list the routes of an airport", func() {
+			advisor, output := newAdvisor(t, "")
+
+			advisor.Execute("list BRC")
+
+			g.Assert(output.String()).Equal("BRC - SCL > $5\nGRU - BRC > $10\n2 routes\n")
+		})
+
+		g.It("should show the airports and the stats", func() {
+			advisor, output := newAdvisor(t, "")
+
+			advisor.Execute("airports")
+			advisor.Execute("stats")
+
+			g.Assert(output.String()).Equal("BRC\nCDG\nGRU\nSCL\n4 airports\n" +
+				"routes: 4\nairports: 4\n" +
+				"cheapest route: BRC - SCL > $5\n" +
+				"most expensive route: GRU - CDG > $75\n" +
+				"average cost: $32.50\n")
+		})
+
+		g.It("should show the alternative routes", func() {
+			advisor, output := newAdvisor(t, "")
+
+			advisor.Execute("alt GRU-CDG 3")
+
+			g.Assert(output.String()).Equal("1. GRU - BRC - SCL - CDG > $55\n2. GRU - CDG > $75\n")
+		})
+
+		g.It("should run the lines of the history again", func() {
+			advisor, output := newAdvisor(t, "")
+
+			advisor.Execute("GRU-SCL")
+			advisor.Execute("list GRU")
+			output.Reset()
+
+			advisor.Execute("!1")
+			advisor.Execute("!!")
+			advisor.Execute("!9")
+			advisor.Execute("history")
+
+			g.Assert(output.String()).Equal("GRU-SCL\nbest route: GRU - BRC - SCL > $15\n" +
+				"GRU-SCL\nbest route: GRU - BRC - SCL > $15\n" +
+				"!9: event not found\n" +
+				"   1  GRU-SCL\n   2  list GRU\n   3  GRU-SCL\n   4  GRU-SCL\n   5  history\n")
+		})
+
+		g.It("should stop on quit", func() {
+			advisor, output := newAdvisor(t, "quit\nGRU-CDG\n")
+
+			advisor.Run()
+
+			g.Assert(output.String()).Equal(prompt)
+		})
+	})
+}
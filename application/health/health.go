@@ -0,0 +1,85 @@
+package health
+
+import (
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/database"
+	"go-bestflight/resources/file"
+	"sync/atomic"
+)
+
+// The statuses of a Report.
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not ready"
+)
+
+// Check is the state of a component the application needs to serve requests.
+type Check struct {
+	Name   string `json:"name"`
+	Ready  bool   `json:"ready"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report tells whether the application is ready, and why, by its checks.
+type Report struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks"`
+}
+
+var (
+	loaded       int32
+	shuttingDown int32
+)
+
+// MarkLoaded tells that the routes file was synced and read and its routes loaded.
+func MarkLoaded() {
+	atomic.StoreInt32(&loaded, 1)
+}
+
+// Loaded tells whether the routes were loaded.
+func Loaded() bool {
+	return atomic.LoadInt32(&loaded) == 1
+}
+
+// MarkShuttingDown tells that the server is shutting down, so it must get no more requests.
+func MarkShuttingDown() {
+	atomic.StoreInt32(&shuttingDown, 1)
+}
+
+// Reset must be used ONLY for tests.
+func Reset() {
+	atomic.StoreInt32(&loaded, 0)
+	atomic.StoreInt32(&shuttingDown, 0)
+}
+
+func check(name string, ready bool, notReadyDetail string) Check {
+	c := Check{Name: name, Ready: ready}
+
+	if !ready {
+		c.Detail = notReadyDetail
+	}
+
+	return c
+}
+
+// Readiness checks the resources shared by the application, the routes and the server.
+func Readiness() Report {
+	report := Report{
+		Status: StatusReady,
+		Checks: []Check{
+			check("database", database.Default() != nil, "not connected"),
+			check("cache", cache.Default() != nil, "not connected"),
+			check("file", file.Synced(), "not synced"),
+			check("routes", Loaded(), "not loaded"),
+			check("server", atomic.LoadInt32(&shuttingDown) == 0, "shutting down"),
+		},
+	}
+
+	for _, c := range report.Checks {
+		if !c.Ready {
+			report.Status = StatusNotReady
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,19 @@
+package application
+
+import (
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/metrics"
+)
+
+// registerGauges registers the gauges of the sizes of the graph and the cache shared by the application.
+func registerGauges() {
+	metrics.Default().GaugeFunc("bestflight_graph_airports", "Number of airports in the routes graph.", func() float64 {
+		return float64(cache.GetGraph().Airports())
+	})
+	metrics.Default().GaugeFunc("bestflight_graph_routes", "Number of routes in the routes graph.", func() float64 {
+		return float64(cache.GetGraph().Routes())
+	})
+	metrics.Default().GaugeFunc("bestflight_cache_routes", "Number of routes in the cache.", func() float64 {
+		return float64(cache.Default().Len())
+	})
+}
@@ -0,0 +1,50 @@
+package application
+
+import (
+	"bytes"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/metrics"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestGauges(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	registerGauges()
+
+	gauges := func() string {
+		var output bytes.Buffer
+		metrics.Write(&output)
+
+		return output.String()
+	}
+
+	g.Describe("Tests for the gauges of the graph", func() {
+		g.BeforeEach(func() {
+			cache.Connect()
+			cache.Truncate()
+		})
+
+		g.It("should count the airports left after routes are deleted and added again", func() {
+			route := r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75}
+
+			cache.AddRoute(route)
+			cache.AddRoute(r.Route{Boarding: "SCL", Destination: "ORL", Cost: 20})
+			cache.DeleteRoute(r.Route{Boarding: "SCL", Destination: "ORL", Cost: 20})
+
+			for i := 0; i < 5; i++ {
+				cache.DeleteRoute(route)
+				cache.AddRoute(route)
+			}
+
+			output := gauges()
+
+			g.Assert(strings.Contains(output, "\nbestflight_graph_airports 2\n")).IsTrue()
+			g.Assert(strings.Contains(output, "\nbestflight_graph_routes 1\n")).IsTrue()
+		})
+	})
+}
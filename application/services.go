@@ -0,0 +1,75 @@
+package application
+
+import (
+	"context"
+	"go-bestflight/domain/services/airportservice"
+	"go-bestflight/domain/services/routeservice"
+	"go-bestflight/resources/airportfile"
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/database"
+	"go-bestflight/resources/file"
+	"go-bestflight/resources/repositories/airportrepository"
+	"go-bestflight/resources/repositories/routerepository"
+)
+
+// NewRouteService returns a RouteService over resources of its own, loaded with the routes of the
+// file, for the commands that run without the server. A read-only service never changes the file or
+// its operations log, so it can be used while a server owns them, but it can not store routes: its
+// changes fail with file.ErrReadOnly and are rolled back.
+// A read-only service fails if the file does not exist, while the other one creates it.
+// An empty path gives a read-only service without routes, e.g. to validate routes.
+func NewRouteService(routesPath string, readOnly bool) (*routeservice.RouteService, error) {
+	return newRouteService(database.New(), routesPath, readOnly)
+}
+
+// NewExportService returns a read-only RouteService, like NewRouteService, that also knows the
+// coordinates of the airports of the reference file, when one is given, for the GeoJSON export.
+func NewExportService(routesPath string, airportsPath string) (*routeservice.RouteService, error) {
+	db := database.New()
+
+	if airportsPath != "" {
+		airportsFromFile, err := airportfile.ReadFile(airportsPath)
+		if err != nil {
+			return nil, err
+		}
+
+		airportservice.New(airportrepository.New(db)).LoadAirports(airportsFromFile)
+	}
+
+	return newRouteService(db, routesPath, true)
+}
+
+func newRouteService(db *database.Database, routesPath string, readOnly bool) (*routeservice.RouteService, error) {
+	mc := cache.New()
+
+	if routesPath == "" {
+		return routeservice.New(routerepository.New(db, db, mc, file.NewReadOnly(nil)), airportrepository.New(db)), nil
+	}
+
+	if readOnly {
+		routesFromFile, err := file.Read(routesPath)
+		if err != nil {
+			return nil, err
+		}
+
+		service := routeservice.New(routerepository.New(db, db, mc, file.NewReadOnly(routesFromFile)), airportrepository.New(db))
+		service.LoadRoutes(context.Background(), routesFromFile)
+
+		return service, nil
+	}
+
+	routesFile, err := file.Open(routesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	routesFromFile, err := routesFile.ReadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	service := routeservice.New(routerepository.New(db, db, mc, routesFile), airportrepository.New(db))
+	service.LoadRoutes(context.Background(), routesFromFile)
+
+	return service, nil
+}
@@ -0,0 +1,42 @@
+package application
+
+import (
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestRouteServices(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for NewRouteService", func() {
+		g.It("should refuse the changes of a read-only service and keep its routes untouched", func() {
+			service, err := NewRouteService("", true)
+			g.Assert(err).Equal(nil)
+
+			service.LoadRoutes(context.Background(), []r.Route{{Boarding: "GRU", Destination: "CDG", Cost: 75}})
+
+			_, err = service.AddNewRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "SCL", Cost: 20})
+			g.Assert(err == nil).IsFalse()
+
+			_, err = service.UpdateRouteCost(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: 10})
+			g.Assert(err == nil).IsFalse()
+
+			_, err = service.DeleteRoute(context.Background(), "GRU", "CDG")
+			g.Assert(err == nil).IsFalse()
+
+			report, err := service.ImportRoutes(context.Background(), []r.Route{{Boarding: "SCL", Destination: "ORL", Cost: 5}}, false)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(report.Rejected)).Equal(1)
+
+			best, err := service.GetBestRoute(context.Background(), "GRU", "CDG")
+
+			g.Assert(err).Equal(nil)
+			g.Assert(best).Equal(r.BestRoute{Route: "GRU - CDG", Cost: 75})
+			_, err = service.GetBestRoute(context.Background(), "GRU", "SCL")
+			g.Assert(err == nil).IsFalse()
+		})
+	})
+}
@@ -0,0 +1,61 @@
+package application
+
+import (
+	"context"
+	"go-bestflight/domain/services/routeservice"
+	"go-bestflight/resources/file"
+	"go-bestflight/resources/logger"
+	"go-bestflight/resources/tracing"
+	"time"
+)
+
+// ReloadInterval is how often the routes file is checked for changes made by someone else, e.g. a
+// data pipeline rewriting it. A changed file is reloaded one check after it stops changing.
+// Zero disables the reloading.
+var ReloadInterval = 10 * time.Second
+
+// watchRoutesFile reloads the routes whenever the routes file is rewritten, until stop is closed.
+func watchRoutesFile(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			reloadRoutesFile()
+		}
+	}
+}
+
+func reloadRoutesFile() {
+	changed, err := file.Changed()
+	if err != nil {
+		logger.Error("could not check the routes file for changes", "error", err)
+		return
+	}
+
+	if !changed {
+		return
+	}
+
+	logger.Info("routes file changed, reloading it")
+
+	ctx, span := tracing.Start(context.Background(), "reload")
+	defer span.End()
+
+	routesFromFile, err := file.ReadFile()
+	if err != nil {
+		logger.Error("could not read the changed routes file", "error", err)
+		span.RecordError(err)
+		return
+	}
+
+	_, err = routeservice.ReloadRoutes(ctx, routesFromFile)
+	span.RecordError(err)
+}
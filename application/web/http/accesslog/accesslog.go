@@ -0,0 +1,39 @@
+package accesslog
+
+import (
+	"go-bestflight/resources/logger"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware logs every request once it is served, with its status and latency, by the logger of
+// the context of the request, which has the id of the request when it comes after requesttrace.Middleware.
+// The requests that fail with a server error are logged as errors.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		log := logger.FromContext(ctx.Request.Context())
+		status := ctx.Writer.Status()
+		fields := []interface{}{
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"route", ctx.FullPath(),
+			"status", status,
+			"latency", time.Since(start),
+			"client_ip", ctx.ClientIP(),
+			"bytes", ctx.Writer.Size(),
+		}
+
+		if status >= http.StatusInternalServerError {
+			log.Error("request served", fields...)
+			return
+		}
+
+		log.Info("request served", fields...)
+	}
+}
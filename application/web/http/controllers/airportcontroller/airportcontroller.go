@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"go-bestflight/application/web/http/problem"
+	"go-bestflight/domain/services/airportservice"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAirports is a handler for API route GET /airports.
+func GetAirports(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, airportservice.GetAirports())
+}
+
+// GetAirport is a handler for API route GET /airports/:code.
+func GetAirport(ctx *gin.Context) {
+	airport, err := airportservice.GetAirport(ctx.Param("code"))
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, airport)
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-bestflight/application/web/http/problem"
+	"go-bestflight/domain/entities/airports"
+	"go-bestflight/domain/errors"
+	"go-bestflight/resources/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gin-gonic/gin"
+)
+
+// assertProblem checks that the response is the problem of the error.
+func assertProblem(g *goblin.G, resWriter *httptest.ResponseRecorder, err error) {
+	var body problem.Problem
+	json.Unmarshal(resWriter.Body.Bytes(), &body)
+
+	g.Assert(resWriter.Code).Equal(problem.Status(errors.CodeOf(err)))
+	g.Assert(resWriter.Header().Get("Content-Type")).Equal(problem.ContentType)
+	g.Assert(body.Code).Equal(errors.CodeOf(err))
+	g.Assert(body.Detail).Equal(err.Error())
+	g.Assert(body.Field).Equal(errors.FieldOf(err))
+}
+
+func TestAirportController(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	gru := airports.Airport{
+		Code:     "GRU",
+		Name:     "Guarulhos",
+		City:     "Sao Paulo",
+		Country:  "Brazil",
+		Timezone: "America/Sao_Paulo",
+	}.At(-23.43, -46.47)
+
+	g.Describe("Tests for GetAirport", func() {
+		g.BeforeEach(func() {
+			database.Connect()
+			database.Truncate()
+			database.StoreAirport("CDG")
+			database.StoreAirportsDetails([]airports.Airport{gru})
+		})
+
+		g.It("should return status code 200 and the airport with its reference data", func() {
+			jsonBytes, _ := json.Marshal(gru)
+
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request, _ = http.NewRequest("GET", "localhost:3000/airports/gru", nil)
+			ctx.Params = gin.Params{{Key: "code", Value: "gru"}}
+
+			GetAirport(ctx)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Body.String()).Equal(string(jsonBytes))
+		})
+
+		g.It("should return only the code of an airport known from routes", func() {
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request, _ = http.NewRequest("GET", "localhost:3000/airports/CDG", nil)
+			ctx.Params = gin.Params{{Key: "code", Value: "CDG"}}
+
+			GetAirport(ctx)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Body.String()).Equal(`{"code":"CDG"}`)
+		})
+
+		g.It("should return status code 404 for an unknown airport and 400 for a malformed one", func() {
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request, _ = http.NewRequest("GET", "localhost:3000/airports/SCL", nil)
+			ctx.Params = gin.Params{{Key: "code", Value: "SCL"}}
+
+			GetAirport(ctx)
+
+			assertProblem(g, resWriter, errors.NewAirportNotFoundErr())
+
+			resWriter = httptest.NewRecorder()
+			ctx, _ = gin.CreateTestContext(resWriter)
+			ctx.Request, _ = http.NewRequest("GET", "localhost:3000/airports/SC", nil)
+			ctx.Params = gin.Params{{Key: "code", Value: "SC"}}
+
+			GetAirport(ctx)
+
+			assertProblem(g, resWriter, errors.NewInvalidAirportErr("malformed").InField("code"))
+		})
+	})
+
+	g.Describe("Tests for GetAirports", func() {
+		g.It("should return status code 200 and the registered airports sorted by code", func() {
+			database.Connect()
+			database.Truncate()
+			database.StoreAirport("GRU")
+			database.StoreAirport("CDG")
+			database.StoreAirportsDetails([]airports.Airport{gru})
+
+			jsonBytes, _ := json.Marshal([]airports.Airport{{Code: "CDG"}, gru})
+
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request, _ = http.NewRequest("GET", "localhost:3000/airports", nil)
+
+			GetAirports(ctx)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Body.String()).Equal(string(jsonBytes))
+		})
+	})
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"go-bestflight/application/health"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Liveness is a handler for API route GET /healthz. It answers as long as the process serves requests.
+func Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// Readiness is a handler for API route GET /readyz, with the status 503 when any check is not ready.
+func Readiness(ctx *gin.Context) {
+	report := health.Readiness()
+
+	if report.Status != health.StatusReady {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"bytes"
+	"go-bestflight/application/web/http/problem"
+	"go-bestflight/resources/metrics"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMetrics is a handler for API route GET /metrics, in the text exposition format of Prometheus.
+func GetMetrics(ctx *gin.Context) {
+	var body bytes.Buffer
+
+	if err := metrics.Write(&body); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, metrics.ContentType, body.Bytes())
+}
@@ -1,70 +1,378 @@
 package controllers
 
 import (
+	"bytes"
+	stderrors "errors"
+	"go-bestflight/application/web/http/problem"
 	r "go-bestflight/domain/entities/routes"
 	"go-bestflight/domain/errors"
 	"go-bestflight/domain/services/routeservice"
-	"log"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RouteController serves the API routes of the routes over a RouteService.
+type RouteController struct {
+	service *routeservice.RouteService
+}
+
+// New is a constructor for a RouteController over the given service.
+func New(service *routeservice.RouteService) *RouteController {
+	return &RouteController{
+		service: service,
+	}
+}
+
+// Default returns a RouteController over the resources shared by the application.
+func Default() *RouteController {
+	return New(routeservice.Default())
+}
+
 // AddNewRoute is a handler for API route GET /route.
-func AddNewRoute(ctx *gin.Context) {
+func (c *RouteController) AddNewRoute(ctx *gin.Context) {
 	var newRoute r.Route
 
 	if err := ctx.ShouldBindJSON(&newRoute); err != nil {
-		ctx.String(http.StatusBadRequest, "Bad Request")
-
+		problem.Abort(ctx, errors.NewInvalidParameterErr("body"))
 		return
 	}
 
-	addedRoute, err := routeservice.AddNewRoute(newRoute)
+	addedRoute, err := c.service.AddNewRoute(ctx.Request.Context(), newRoute)
 	if err != nil {
-		if e, ok := err.(*errors.InvalidRouteErr); ok {
-			ctx.String(http.StatusBadRequest, e.Error())
-			return
-		}
-
 		if _, ok := err.(*errors.RouteAlreadyExistErr); ok {
 			ctx.JSON(http.StatusOK, newRoute)
 			return
 		}
 
-		log.Printf("unkown error when adding new route: %v", err)
+		problem.Abort(ctx, err)
+		return
+	}
 
-		ctx.String(http.StatusInternalServerError, "Internal Server Error")
+	ctx.JSON(http.StatusCreated, addedRoute)
+}
+
+// UpdateRoute is a handler for API route PUT /routes.
+func (c *RouteController) UpdateRoute(ctx *gin.Context) {
+	var route r.Route
 
+	if err := ctx.ShouldBindJSON(&route); err != nil {
+		problem.Abort(ctx, errors.NewInvalidParameterErr("body"))
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, addedRoute)
+	updatedRoute, err := c.service.UpdateRouteCost(ctx.Request.Context(), route)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updatedRoute)
+}
+
+// DeleteRoute is a handler for API route DELETE /routes.
+func (c *RouteController) DeleteRoute(ctx *gin.Context) {
+	boarding := ctx.Query("board")
+	destination := ctx.Query("dest")
+
+	deletedRoute, err := c.service.DeleteRoute(ctx.Request.Context(), boarding, destination)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deletedRoute)
+}
+
+func searchOptions(ctx *gin.Context) ([]routeservice.SearchOption, error) {
+	options := []routeservice.SearchOption{}
+
+	if maxStops, ok := ctx.GetQuery("max_stops"); ok {
+		n, err := strconv.Atoi(maxStops)
+		if err != nil {
+			return nil, errors.NewInvalidParameterErr("max_stops")
+		}
+
+		options = append(options, routeservice.WithMaxStops(n))
+	}
+
+	if avoid := queryList(ctx, "avoid"); len(avoid) > 0 {
+		options = append(options, routeservice.WithAvoid(avoid...))
+	}
+
+	if via := queryList(ctx, "via"); len(via) > 0 {
+		options = append(options, routeservice.WithVia(via...))
+	}
+
+	return options, nil
+}
+
+// queryList accepts both repeated and comma separated values, e.g. avoid=SCL&avoid=ORL or avoid=SCL,ORL.
+func queryList(ctx *gin.Context, key string) []string {
+	values := []string{}
+
+	for _, param := range ctx.QueryArray(key) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
 }
 
 // BestRoute is a handler for API route POST /route.
-func BestRoute(ctx *gin.Context) {
+func (c *RouteController) BestRoute(ctx *gin.Context) {
 	boarding := ctx.Query("board")
 	destination := ctx.Query("dest")
-	bestRoute, err := routeservice.GetBestRoute(boarding, destination)
 
+	options, err := searchOptions(ctx)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+
+	if alternatives, ok := ctx.GetQuery("alternatives"); ok {
+		c.bestRoutes(ctx, boarding, destination, alternatives, options)
+		return
+	}
+
+	bestRoute, err := c.service.GetBestRoute(ctx.Request.Context(), boarding, destination, options...)
+
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, bestRoute)
+}
+
+func (c *RouteController) bestRoutes(ctx *gin.Context, boarding, destination, alternatives string, options []routeservice.SearchOption) {
+	k, err := strconv.Atoi(alternatives)
+	if err != nil {
+		problem.Abort(ctx, errors.NewInvalidParameterErr("alternatives"))
+		return
+	}
+
+	bestRoutes, err := c.service.GetBestRoutes(ctx.Request.Context(), boarding, destination, k, options...)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, bestRoutes)
+}
+
+// queryInt returns the integer value of a query parameter, or zero when it is not given.
+func queryInt(ctx *gin.Context, key string) (int, error) {
+	value, ok := ctx.GetQuery(key)
+	if !ok {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
 	if err != nil {
-		if e, ok := err.(*errors.InvalidAirportErr); ok {
-			ctx.String(http.StatusBadRequest, e.Error())
+		return 0, errors.NewInvalidParameterErr(key)
+	}
+
+	return n, nil
+}
+
+// ListRoutes is a handler for API route GET /routes/all.
+func (c *RouteController) ListRoutes(ctx *gin.Context) {
+	query := routeservice.RouteListQuery{
+		Boarding:    ctx.Query("board"),
+		Destination: ctx.Query("dest"),
+		SortBy:      ctx.Query("sort"),
+		Cursor:      ctx.Query("cursor"),
+	}
+
+	params := []struct {
+		key   string
+		value *int
+	}{
+		{"min_cost", &query.MinCost},
+		{"max_cost", &query.MaxCost},
+		{"limit", &query.Limit},
+	}
+
+	for _, param := range params {
+		n, err := queryInt(ctx, param.key)
+		if err != nil {
+			problem.Abort(ctx, err)
 			return
 		}
 
-		if _, ok := err.(*errors.BestRouteNotFoundErr); ok {
-			ctx.String(http.StatusNoContent, "")
+		*param.value = n
+	}
+
+	page, err := c.service.ListRoutes(ctx.Request.Context(), query)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// maxImportSize is the maximum size in bytes of the body of an import.
+var maxImportSize int64 = 10 << 20
+
+// errImportTooLarge is returned by limitedBody once the body is larger than its limit.
+var errImportTooLarge = stderrors.New("import too large")
+
+// limitedBody reads the body of an import up to a limit and remembers whether it was larger.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	tooLarge  bool
+}
+
+func newLimitedBody(body io.ReadCloser, limit int64) *limitedBody {
+	return &limitedBody{ReadCloser: body, remaining: limit}
+}
+
+// Read reads up to the limit, and one more byte to tell whether the body goes on after it.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.tooLarge {
+		return 0, errImportTooLarge
+	}
+
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+
+	n = int(b.remaining)
+	b.remaining = 0
+	b.tooLarge = true
+
+	return n, errImportTooLarge
+}
+
+// ImportRoutes is a handler for API route POST /routes/import.
+// The body is a JSON array of routes when the content type is application/json, or a CSV in the
+// format of the routes file otherwise. With atomic=true nothing is stored if any line is rejected.
+// Nothing is stored either when the body is larger than maxImportSize, which is answered with 413.
+func (c *RouteController) ImportRoutes(ctx *gin.Context) {
+	atomic := false
+
+	if value, ok := ctx.GetQuery("atomic"); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			problem.Abort(ctx, errors.NewInvalidParameterErr("atomic"))
 			return
 		}
 
-		log.Printf("unkown error when getting best route: %v", err)
+		atomic = parsed
+	}
+
+	body := newLimitedBody(ctx.Request.Body, maxImportSize)
+	ctx.Request.Body = body
+
+	var (
+		report r.ImportReport
+		err    error
+	)
+
+	if ctx.ContentType() == gin.MIMEJSON {
+		var routes []r.Route
+
+		if err = ctx.ShouldBindJSON(&routes); err == nil {
+			report, err = c.service.ImportRoutes(ctx.Request.Context(), routes, atomic)
+		} else {
+			err = errors.NewInvalidParameterErr("body")
+		}
+	} else {
+		report, err = c.service.ImportCSV(ctx.Request.Context(), body, atomic)
+	}
+
+	if body.tooLarge {
+		problem.Abort(ctx, errors.NewImportTooLargeErr(maxImportSize))
+		return
+	}
+
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
 
-		ctx.String(http.StatusInternalServerError, "Internal Server Error")
+	if !report.Committed {
+		rejected := problem.New(ctx, errors.NewImportRejectedErr(len(report.Rejected)))
+		rejected.Report = &report
+		problem.Send(ctx, rejected)
 
 		return
 	}
 
-	ctx.JSON(http.StatusOK, bestRoute)
+	ctx.JSON(http.StatusOK, report)
+}
+
+var exportContentTypes = map[string]string{
+	routeservice.ExportCSV:     "text/csv; charset=utf-8",
+	routeservice.ExportJSON:    "application/json; charset=utf-8",
+	routeservice.ExportDOT:     "text/vnd.graphviz; charset=utf-8",
+	routeservice.ExportGeoJSON: "application/geo+json; charset=utf-8",
+}
+
+// ExportRoutes is a handler for API route GET /routes/export.
+// The format is csv (default), json, dot or geojson.
+func (c *RouteController) ExportRoutes(ctx *gin.Context) {
+	format := strings.ToLower(ctx.DefaultQuery("format", routeservice.ExportCSV))
+
+	var body bytes.Buffer
+
+	err := c.service.Export(ctx.Request.Context(), &body, format)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, exportContentTypes[format], body.Bytes())
+}
+
+// The handlers below use the resources shared by the application.
+
+// AddNewRoute is a handler for API route GET /route.
+func AddNewRoute(ctx *gin.Context) {
+	Default().AddNewRoute(ctx)
+}
+
+// UpdateRoute is a handler for API route PUT /routes.
+func UpdateRoute(ctx *gin.Context) {
+	Default().UpdateRoute(ctx)
+}
+
+// DeleteRoute is a handler for API route DELETE /routes.
+func DeleteRoute(ctx *gin.Context) {
+	Default().DeleteRoute(ctx)
+}
+
+// BestRoute is a handler for API route POST /route.
+func BestRoute(ctx *gin.Context) {
+	Default().BestRoute(ctx)
+}
+
+// ListRoutes is a handler for API route GET /routes/all.
+func ListRoutes(ctx *gin.Context) {
+	Default().ListRoutes(ctx)
+}
+
+// ImportRoutes is a handler for API route POST /routes/import.
+func ImportRoutes(ctx *gin.Context) {
+	Default().ImportRoutes(ctx)
+}
+
+// ExportRoutes is a handler for API route GET /routes/export.
+func ExportRoutes(ctx *gin.Context) {
+	Default().ExportRoutes(ctx)
 }
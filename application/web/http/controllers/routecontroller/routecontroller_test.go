@@ -2,37 +2,67 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"go-bestflight/application/web/http/problem"
 	r "go-bestflight/domain/entities/routes"
 	"go-bestflight/domain/errors"
+	"go-bestflight/domain/services/routeservice"
 	"go-bestflight/resources/cache"
 	"go-bestflight/resources/database"
 	"go-bestflight/resources/file"
+	"go-bestflight/resources/repositories/airportrepository"
 	"go-bestflight/resources/repositories/routerepository"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/franela/goblin"
 	"github.com/gin-gonic/gin"
 )
 
+// assertProblem checks that the response is the problem of the error.
+func assertProblem(g *goblin.G, resWriter *httptest.ResponseRecorder, err error) {
+	var body problem.Problem
+	json.Unmarshal(resWriter.Body.Bytes(), &body)
+
+	g.Assert(resWriter.Code).Equal(problem.Status(errors.CodeOf(err)))
+	g.Assert(resWriter.Header().Get("Content-Type")).Equal(problem.ContentType)
+	g.Assert(body.Status).Equal(resWriter.Code)
+	g.Assert(body.Code).Equal(errors.CodeOf(err))
+	g.Assert(body.Detail).Equal(err.Error())
+	g.Assert(body.Field).Equal(errors.FieldOf(err))
+}
+
 func TestController(t *testing.T) {
+	t.Parallel()
+
 	g := goblin.Goblin(t)
 
 	gin.SetMode(gin.TestMode)
 
+	var (
+		db         *database.Database
+		routesFile *file.RoutesFile
+		repo       *routerepository.RouteRepository
+		controller *RouteController
+	)
+
+	// isolate gives the test a controller over its own resources.
+	isolate := func() {
+		db = database.New()
+		routesFile, _ = file.Open("test.csv")
+		repo = routerepository.New(db, db, cache.New(), routesFile)
+		controller = New(routeservice.New(repo, airportrepository.New(db)))
+	}
+
 	g.Describe("Tests for AddNewRoute", func() {
 		g.It("should add a new route and return status code 201 and a json with the route info", func() {
-			filePath := "test.csv"
-			defer file.Remove()
-
-			file.Reset(filePath)
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
+			isolate()
+			defer routesFile.Remove()
 
 			route := r.Route{
 				Boarding:    "GRU",
@@ -46,24 +76,18 @@ func TestController(t *testing.T) {
 			ctx.Request = req
 
 			g.Assert(resWriter.Body.String()).Equal("")
-			g.Assert(routerepository.RouteExists(route.Boarding, route.Destination)).IsFalse()
+			g.Assert(repo.RouteExists(route.Boarding, route.Destination)).IsFalse()
 
-			AddNewRoute(ctx)
+			controller.AddNewRoute(ctx)
 
-			g.Assert(routerepository.RouteExists(route.Boarding, route.Destination)).IsTrue()
+			g.Assert(repo.RouteExists(route.Boarding, route.Destination)).IsTrue()
 			g.Assert(resWriter.Code).Equal(201)
 			g.Assert(resWriter.Body.String()).Equal(string(jsonBytes))
 		})
 
 		g.It("should return status code 400 for invalid data", func() {
-			filePath := "test.csv"
-			defer file.Remove()
-
-			file.Reset(filePath)
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
+			isolate()
+			defer routesFile.Remove()
 
 			invalidRoute := []int{1, 2, 3}
 			jsonBytes, _ := json.Marshal(invalidRoute)
@@ -74,21 +98,14 @@ func TestController(t *testing.T) {
 
 			g.Assert(resWriter.Body.String()).Equal("")
 
-			AddNewRoute(ctx)
+			controller.AddNewRoute(ctx)
 
-			g.Assert(resWriter.Code).Equal(400)
-			g.Assert(resWriter.Body.String()).Equal("Bad Request")
+			assertProblem(g, resWriter, errors.NewInvalidParameterErr("body"))
 		})
 
 		g.It("should return status code 400 for malformed route", func() {
-			filePath := "test.csv"
-			defer file.Remove()
-
-			file.Reset(filePath)
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
+			isolate()
+			defer routesFile.Remove()
 
 			malformedRoute := r.Route{
 				Boarding:    "",
@@ -103,21 +120,14 @@ func TestController(t *testing.T) {
 
 			g.Assert(resWriter.Body.String()).Equal("")
 
-			AddNewRoute(ctx)
+			controller.AddNewRoute(ctx)
 
-			g.Assert(resWriter.Code).Equal(400)
-			g.Assert(resWriter.Body.String()).Equal(errors.NewInvalidRouteErr().Error())
+			assertProblem(g, resWriter, errors.NewInvalidRouteErr())
 		})
 
 		g.It("should return status code 200 for a already created route", func() {
-			filePath := "test.csv"
-			defer file.Remove()
-
-			file.Reset(filePath)
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
+			isolate()
+			defer routesFile.Remove()
 
 			route := r.Route{
 				Boarding:    "GRU",
@@ -132,7 +142,7 @@ func TestController(t *testing.T) {
 
 			g.Assert(resWriter.Body.String()).Equal("")
 
-			AddNewRoute(ctx)
+			controller.AddNewRoute(ctx)
 
 			g.Assert(resWriter.Code).Equal(201)
 
@@ -144,7 +154,7 @@ func TestController(t *testing.T) {
 
 			g.Assert(resWriter2.Body.String()).Equal("")
 
-			AddNewRoute(ctx2)
+			controller.AddNewRoute(ctx2)
 
 			g.Assert(resWriter2.Code).Equal(200)
 			g.Assert(resWriter2.Body.String()).Equal(string(jsonBytes2))
@@ -207,19 +217,13 @@ func TestController(t *testing.T) {
 				ctx, _ := gin.CreateTestContext(resWriter)
 				ctx.Request = req
 
-				AddNewRoute(ctx)
+				controller.AddNewRoute(ctx)
 			}
 		}
 
 		g.It("should return status code 200 a json with best route info", func() {
-			filePath := "test.csv"
-			defer file.Remove()
-
-			file.Reset(filePath)
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
+			isolate()
+			defer routesFile.Remove()
 
 			addRoutes()
 
@@ -230,7 +234,7 @@ func TestController(t *testing.T) {
 			ctx, _ := gin.CreateTestContext(resWriter)
 			ctx.Request = req
 
-			BestRoute(ctx)
+			controller.BestRoute(ctx)
 
 			expectedBestRoute := r.BestRoute{
 				Route: "GRU - BRC - SCL - ORL - CDG",
@@ -243,14 +247,8 @@ func TestController(t *testing.T) {
 		})
 
 		g.It("should return status code 400 for a not stored airport", func() {
-			filePath := "test.csv"
-			defer file.Remove()
-
-			file.Reset(filePath)
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
+			isolate()
+			defer routesFile.Remove()
 
 			addRoutes()
 
@@ -261,21 +259,14 @@ func TestController(t *testing.T) {
 			ctx, _ := gin.CreateTestContext(resWriter)
 			ctx.Request = req
 
-			BestRoute(ctx)
+			controller.BestRoute(ctx)
 
-			g.Assert(resWriter.Code).Equal(400)
-			g.Assert(resWriter.Body.String()).Equal(errors.NewInvalidAirportErr("not registered").Error())
+			assertProblem(g, resWriter, errors.NewAirportNotRegisteredErr().InField("board"))
 		})
 
 		g.It("should return status code 400 for a malformed airport", func() {
-			filePath := "test.csv"
-			defer file.Remove()
-
-			file.Reset(filePath)
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
+			isolate()
+			defer routesFile.Remove()
 
 			addRoutes()
 
@@ -286,21 +277,14 @@ func TestController(t *testing.T) {
 			ctx, _ := gin.CreateTestContext(resWriter)
 			ctx.Request = req
 
-			BestRoute(ctx)
+			controller.BestRoute(ctx)
 
-			g.Assert(resWriter.Code).Equal(400)
-			g.Assert(resWriter.Body.String()).Equal(errors.NewInvalidAirportErr("malformed").Error())
+			assertProblem(g, resWriter, errors.NewInvalidAirportErr("malformed").InField("board"))
 		})
 
-		g.It("should return status code 204 when the best route is not found", func() {
-			filePath := "test.csv"
-			defer file.Remove()
-
-			file.Reset(filePath)
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
+		g.It("should return status code 404 when the best route is not found", func() {
+			isolate()
+			defer routesFile.Remove()
 
 			addRoutes()
 
@@ -311,9 +295,491 @@ func TestController(t *testing.T) {
 			ctx, _ := gin.CreateTestContext(resWriter)
 			ctx.Request = req
 
-			BestRoute(ctx)
+			controller.BestRoute(ctx)
+
+			assertProblem(g, resWriter, errors.NewBestRouteNotFoundErr())
+		})
+
+		g.It("should return status code 504 when the search runs out of time", func() {
+			isolate()
+			defer routesFile.Remove()
+
+			addRoutes()
+
+			deadline, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			defer cancel()
+
+			req, _ := http.NewRequestWithContext(deadline, "GET", "localhost:3000/route?board=GRU&dest=CDG", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.BestRoute(ctx)
+
+			g.Assert(resWriter.Code).Equal(http.StatusGatewayTimeout)
+			assertProblem(g, resWriter, errors.NewQueryTimeoutErr())
+		})
+	})
+
+	g.Describe("Tests for BestRoute with alternatives and constraints", func() {
+		routes := []r.Route{
+			{Boarding: "GRU", Destination: "BRC", Cost: 10},
+			{Boarding: "BRC", Destination: "SCL", Cost: 5},
+			{Boarding: "GRU", Destination: "CDG", Cost: 75},
+			{Boarding: "GRU", Destination: "SCL", Cost: 20},
+			{Boarding: "GRU", Destination: "ORL", Cost: 56},
+			{Boarding: "ORL", Destination: "CDG", Cost: 5},
+			{Boarding: "SCL", Destination: "ORL", Cost: 20},
+		}
+
+		g.BeforeEach(func() {
+			isolate()
+
+			for _, route := range routes {
+				jsonBytes, _ := json.Marshal(route)
+				req, _ := http.NewRequest("POST", "localhost:3000/route", bytes.NewReader(jsonBytes))
+				resWriter := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(resWriter)
+				ctx.Request = req
+
+				controller.AddNewRoute(ctx)
+			}
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should return status code 200 and a json list with the best routes", func() {
+			req, _ := http.NewRequest("GET", "localhost:3000/route?board=gru&dest=cdg&alternatives=2", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.BestRoute(ctx)
+
+			expectedBestRoutes := []r.BestRoute{
+				{Route: "GRU - BRC - SCL - ORL - CDG", Cost: 40},
+				{Route: "GRU - SCL - ORL - CDG", Cost: 45},
+			}
+			jsonData, _ := json.Marshal(expectedBestRoutes)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Body.String()).Equal(string(jsonData))
+		})
+
+		g.It("should return status code 400 for invalid alternatives", func() {
+			for _, alternatives := range []string{"two", "0", "-1"} {
+				req, _ := http.NewRequest("GET", fmt.Sprintf("localhost:3000/route?board=gru&dest=cdg&alternatives=%s", alternatives), nil)
+				resWriter := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(resWriter)
+				ctx.Request = req
+
+				controller.BestRoute(ctx)
+
+				assertProblem(g, resWriter, errors.NewInvalidParameterErr("alternatives"))
+			}
+		})
+
+		g.It("should return status code 200 and the best route with at most max_stops", func() {
+			req, _ := http.NewRequest("GET", "localhost:3000/route?board=gru&dest=cdg&max_stops=1", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.BestRoute(ctx)
+
+			jsonData, _ := json.Marshal(r.BestRoute{Route: "GRU - ORL - CDG", Cost: 61})
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Body.String()).Equal(string(jsonData))
+		})
+
+		g.It("should return status code 200 and the best route honouring avoid and via", func() {
+			req, _ := http.NewRequest("GET", "localhost:3000/route?board=GRU&dest=CDG&avoid=SCL&via=ORL", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.BestRoute(ctx)
+
+			jsonData, _ := json.Marshal(r.BestRoute{Route: "GRU - ORL - CDG", Cost: 61})
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Body.String()).Equal(string(jsonData))
+		})
+
+		g.It("should accept comma separated and repeated avoid lists", func() {
+			for _, query := range []string{"avoid=BRC,SCL", "avoid=BRC&avoid=SCL"} {
+				req, _ := http.NewRequest("GET", fmt.Sprintf("localhost:3000/route?board=GRU&dest=CDG&%s", query), nil)
+				resWriter := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(resWriter)
+				ctx.Request = req
+
+				controller.BestRoute(ctx)
+
+				jsonData, _ := json.Marshal(r.BestRoute{Route: "GRU - ORL - CDG", Cost: 61})
+
+				g.Assert(resWriter.Code).Equal(200)
+				g.Assert(resWriter.Body.String()).Equal(string(jsonData))
+			}
+		})
+
+		g.It("should return status code 400 for malformed avoid and via airports", func() {
+			for _, field := range []string{"avoid", "via"} {
+				req, _ := http.NewRequest("GET", fmt.Sprintf("localhost:3000/route?board=GRU&dest=CDG&%s=S1L", field), nil)
+				resWriter := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(resWriter)
+				ctx.Request = req
+
+				controller.BestRoute(ctx)
+
+				assertProblem(g, resWriter, errors.NewInvalidAirportErr("malformed").InField(field))
+			}
+		})
+
+		g.It("should return status code 400 for invalid max_stops", func() {
+			for _, maxStops := range []string{"one", "-1"} {
+				req, _ := http.NewRequest("GET", fmt.Sprintf("localhost:3000/route?board=gru&dest=cdg&max_stops=%s", maxStops), nil)
+				resWriter := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(resWriter)
+				ctx.Request = req
+
+				controller.BestRoute(ctx)
+
+				assertProblem(g, resWriter, errors.NewInvalidParameterErr("max_stops"))
+			}
+		})
+
+		g.It("should return status code 404 when no route fits max_stops", func() {
+			req, _ := http.NewRequest("GET", "localhost:3000/route?board=brc&dest=cdg&max_stops=1", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.BestRoute(ctx)
+
+			assertProblem(g, resWriter, errors.NewBestRouteNotFoundErr())
+		})
+
+		g.It("should return status code 404 when no route is found", func() {
+			req, _ := http.NewRequest("GET", "localhost:3000/route?board=scl&dest=gru&alternatives=2", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.BestRoute(ctx)
+
+			assertProblem(g, resWriter, errors.NewBestRouteNotFoundErr())
+		})
+	})
+
+	g.Describe("Tests for DeleteRoute", func() {
+		g.BeforeEach(func() {
+			isolate()
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should delete a route and return status code 200 and a json with the route info", func() {
+			route := r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75}
+			jsonBytes, _ := json.Marshal(route)
+
+			repo.StoreRoute(context.Background(), route)
+
+			req, _ := http.NewRequest("DELETE", "localhost:3000/routes?board=gru&dest=cdg", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.DeleteRoute(ctx)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Body.String()).Equal(string(jsonBytes))
+			g.Assert(repo.RouteExists(route.Boarding, route.Destination)).IsFalse()
+		})
+
+		g.It("should return status code 404 for a not stored route", func() {
+			req, _ := http.NewRequest("DELETE", "localhost:3000/routes?board=gru&dest=cdg", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.DeleteRoute(ctx)
+
+			assertProblem(g, resWriter, errors.NewRouteNotFoundErr())
+		})
+
+		g.It("should return status code 400 for a malformed airport", func() {
+			req, _ := http.NewRequest("DELETE", "localhost:3000/routes?board=gr&dest=cdg", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.DeleteRoute(ctx)
+
+			assertProblem(g, resWriter, errors.NewInvalidAirportErr("malformed").InField("board"))
+		})
+	})
+
+	g.Describe("Tests for UpdateRoute", func() {
+		g.BeforeEach(func() {
+			isolate()
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should update a route and return status code 200 and a json with the route info", func() {
+			repo.StoreRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+
+			route := r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30}
+			jsonBytes, _ := json.Marshal(route)
+
+			req, _ := http.NewRequest("PUT", "localhost:3000/routes", bytes.NewReader(jsonBytes))
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.UpdateRoute(ctx)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Body.String()).Equal(string(jsonBytes))
+		})
+
+		g.It("should return status code 404 for a not stored route", func() {
+			jsonBytes, _ := json.Marshal(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+
+			req, _ := http.NewRequest("PUT", "localhost:3000/routes", bytes.NewReader(jsonBytes))
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.UpdateRoute(ctx)
+
+			assertProblem(g, resWriter, errors.NewRouteNotFoundErr())
+		})
+
+		g.It("should return status code 400 for a malformed route", func() {
+			jsonBytes, _ := json.Marshal(r.Route{Boarding: "GR", Destination: "CDG", Cost: 30})
+
+			req, _ := http.NewRequest("PUT", "localhost:3000/routes", bytes.NewReader(jsonBytes))
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.UpdateRoute(ctx)
+
+			assertProblem(g, resWriter, errors.NewInvalidRouteErr())
+		})
+	})
+
+	g.Describe("Tests for ListRoutes", func() {
+		g.BeforeEach(func() {
+			isolate()
+			db.StoreRoute(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+			db.StoreRoute(r.Route{Boarding: "GRU", Destination: "ORL", Cost: 56})
+			db.StoreRoute(r.Route{Boarding: "ORL", Destination: "CDG", Cost: 5})
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should return status code 200 and a page with the total count", func() {
+			req, _ := http.NewRequest("GET", "localhost:3000/routes/all?board=gru&sort=cost&limit=1", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.ListRoutes(ctx)
+
+			var page r.RoutesPage
+			json.Unmarshal(resWriter.Body.Bytes(), &page)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(page.Total).Equal(2)
+			g.Assert(page.Routes).Equal([]r.Route{{Boarding: "GRU", Destination: "ORL", Cost: 56}})
+			g.Assert(page.NextCursor != "").IsTrue()
+		})
+
+		g.It("should return status code 400 for invalid parameters", func() {
+			for _, query := range []string{"min_cost=abc", "sort=name", "board=GR", "limit=-1"} {
+				req, _ := http.NewRequest("GET", "localhost:3000/routes/all?"+query, nil)
+				resWriter := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(resWriter)
+				ctx.Request = req
+
+				controller.ListRoutes(ctx)
+
+				g.Assert(resWriter.Code).Equal(400)
+			}
+		})
+	})
+
+	g.Describe("Tests for ImportRoutes", func() {
+		g.BeforeEach(func() {
+			isolate()
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should import a CSV and return status code 200 and the report", func() {
+			csv := "GRU,CDG,75\nGRU,CDG,70\nGRU,SCL\n"
+			req, _ := http.NewRequest("POST", "localhost:3000/routes/import", strings.NewReader(csv))
+			req.Header.Set("Content-Type", "text/csv")
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.ImportRoutes(ctx)
+
+			var report r.ImportReport
+			json.Unmarshal(resWriter.Body.Bytes(), &report)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(report.Committed).IsTrue()
+			g.Assert(len(report.Accepted)).Equal(1)
+			g.Assert(report.Duplicate[0].Line).Equal(2)
+			g.Assert(report.Rejected[0].Line).Equal(3)
+			g.Assert(repo.RouteExists("GRU", "CDG")).IsTrue()
+		})
+
+		g.It("should import a JSON array", func() {
+			jsonBytes, _ := json.Marshal([]r.Route{{Boarding: "gru", Destination: "cdg", Cost: 75}})
+			req, _ := http.NewRequest("POST", "localhost:3000/routes/import", bytes.NewReader(jsonBytes))
+			req.Header.Set("Content-Type", "application/json")
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.ImportRoutes(ctx)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(repo.RouteExists("GRU", "CDG")).IsTrue()
+		})
+
+		g.It("should return status code 422 and store nothing in atomic mode with rejected lines", func() {
+			csv := "GRU,CDG,75\nGRU,SCL,0\n"
+			req, _ := http.NewRequest("POST", "localhost:3000/routes/import?atomic=true", strings.NewReader(csv))
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.ImportRoutes(ctx)
+
+			var rejected problem.Problem
+			json.Unmarshal(resWriter.Body.Bytes(), &rejected)
+
+			assertProblem(g, resWriter, errors.NewImportRejectedErr(1))
+			g.Assert(rejected.Report.Committed).IsFalse()
+			g.Assert(rejected.Report.Rejected[0].Reason).Equal("invalid cost")
+			g.Assert(repo.RouteExists("GRU", "CDG")).IsFalse()
+		})
+
+		g.It("should return status code 413 and store nothing when the body is too large", func() {
+			maxImportSize = 64
+			defer func() { maxImportSize = 10 << 20 }()
+
+			jsonBytes, _ := json.Marshal([]r.Route{{Boarding: "GRU", Destination: "CDG", Cost: 75}, {Boarding: "GRU", Destination: "SCL", Cost: 5}})
+
+			for contentType, body := range map[string]string{
+				"text/csv":         "GRU,CDG,75\n" + strings.Repeat("GRU,SCL,5\n", 6),
+				"application/json": string(jsonBytes),
+			} {
+				g.Assert(int64(len(body)) > maxImportSize).IsTrue()
+
+				req, _ := http.NewRequest("POST", "localhost:3000/routes/import", strings.NewReader(body))
+				req.Header.Set("Content-Type", contentType)
+				resWriter := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(resWriter)
+				ctx.Request = req
+
+				controller.ImportRoutes(ctx)
+
+				assertProblem(g, resWriter, errors.NewImportTooLargeErr(maxImportSize))
+				g.Assert(resWriter.Code).Equal(413)
+				g.Assert(repo.RouteExists("GRU", "CDG")).IsFalse()
+			}
+		})
+
+		g.It("should import a body as large as the limit", func() {
+			body := "GRU,CDG,75\n"
+			maxImportSize = int64(len(body))
+			defer func() { maxImportSize = 10 << 20 }()
+
+			req, _ := http.NewRequest("POST", "localhost:3000/routes/import", strings.NewReader(body))
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.ImportRoutes(ctx)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(repo.RouteExists("GRU", "CDG")).IsTrue()
+		})
+
+		g.It("should return status code 400 for an invalid atomic parameter", func() {
+			req, _ := http.NewRequest("POST", "localhost:3000/routes/import?atomic=maybe", strings.NewReader(""))
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.ImportRoutes(ctx)
+
+			assertProblem(g, resWriter, errors.NewInvalidParameterErr("atomic"))
+		})
+	})
+
+	g.Describe("Tests for ExportRoutes", func() {
+		g.BeforeEach(func() {
+			isolate()
+			db.StoreRoute(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should return status code 200 and the routes in the format asked", func() {
+			req, _ := http.NewRequest("GET", "localhost:3000/routes/export?format=dot", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.ExportRoutes(ctx)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Header().Get("Content-Type")).Equal("text/vnd.graphviz; charset=utf-8")
+			g.Assert(resWriter.Body.String()).Equal("digraph routes {\n\t\"GRU\" -> \"CDG\" [label=\"75\"];\n}\n")
+		})
+
+		g.It("should export a CSV by default", func() {
+			req, _ := http.NewRequest("GET", "localhost:3000/routes/export", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.ExportRoutes(ctx)
+
+			g.Assert(resWriter.Code).Equal(200)
+			g.Assert(resWriter.Body.String()).Equal("GRU,CDG,75\n")
+		})
+
+		g.It("should return status code 400 for an unknown format", func() {
+			req, _ := http.NewRequest("GET", "localhost:3000/routes/export?format=xml", nil)
+			resWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resWriter)
+			ctx.Request = req
+
+			controller.ExportRoutes(ctx)
 
-			g.Assert(resWriter.Code).Equal(204)
+			assertProblem(g, resWriter, errors.NewInvalidParameterErr("format"))
 		})
 	})
 }
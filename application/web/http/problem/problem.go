@@ -0,0 +1,102 @@
+package problem
+
+import (
+	"go-bestflight/application/web/http/requestid"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/errors"
+	"go-bestflight/resources/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of the error responses.
+const ContentType = "application/problem+json"
+
+// Problem is the body of the error responses: the problem details of RFC 7807 extended with the code
+// of the error, the field of the request that caused it and the id of the request.
+// Report is only given by the imports that were rejected.
+type Problem struct {
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Status    int             `json:"status"`
+	Detail    string          `json:"detail"`
+	Instance  string          `json:"instance,omitempty"`
+	Code      errors.Code     `json:"code"`
+	Field     string          `json:"field,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Report    *r.ImportReport `json:"report,omitempty"`
+}
+
+// StatusClientClosedRequest is the status of the requests whose client disconnected before being
+// answered, as logged by nginx. Nobody reads the response, but the log and the metrics tell them apart.
+const StatusClientClosedRequest = 499
+
+var statuses = map[errors.Code]int{
+	errors.CodeRouteInvalid:         http.StatusBadRequest,
+	errors.CodeRouteAlreadyExists:   http.StatusConflict,
+	errors.CodeRouteNotFound:        http.StatusNotFound,
+	errors.CodeRouteUnreachable:     http.StatusNotFound,
+	errors.CodeAirportInvalid:       http.StatusBadRequest,
+	errors.CodeAirportNotRegistered: http.StatusBadRequest,
+	errors.CodeAirportNotFound:      http.StatusNotFound,
+	errors.CodeParameterInvalid:     http.StatusBadRequest,
+	errors.CodeImportRejected:       http.StatusUnprocessableEntity,
+	errors.CodeImportTooLarge:       http.StatusRequestEntityTooLarge,
+	errors.CodeNotReady:             http.StatusServiceUnavailable,
+	errors.CodeQueryTimeout:         http.StatusGatewayTimeout,
+	errors.CodeQueryCanceled:        StatusClientClosedRequest,
+}
+
+// Status returns the HTTP status of an error code.
+func Status(code errors.Code) int {
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+// title returns the title of the problems with a status.
+func title(status int) string {
+	if status == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+
+	return http.StatusText(status)
+}
+
+// New returns the problem of an error raised by a request. The message of errors without a code is
+// logged rather than sent, since it may tell about the internals of the application.
+func New(ctx *gin.Context, err error) Problem {
+	code := errors.CodeOf(err)
+	status := Status(code)
+	detail := err.Error()
+
+	if code == errors.CodeInternal {
+		logger.FromContext(ctx.Request.Context()).Error("unknown error", "method", ctx.Request.Method, "path", ctx.Request.URL.Path, "error", err)
+		detail = "internal server error"
+	}
+
+	return Problem{
+		Type:      "about:blank",
+		Title:     title(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  ctx.Request.URL.Path,
+		Code:      code,
+		Field:     errors.FieldOf(err),
+		RequestID: requestid.Get(ctx),
+	}
+}
+
+// Send responds with a problem and stops the handling of the request.
+func Send(ctx *gin.Context, p Problem) {
+	ctx.Header("Content-Type", ContentType)
+	ctx.AbortWithStatusJSON(p.Status, p)
+}
+
+// Abort responds with the problem of an error and stops the handling of the request.
+func Abort(ctx *gin.Context, err error) {
+	Send(ctx, New(ctx, err))
+}
@@ -0,0 +1,46 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the request and response header holding the id of the request.
+const Header = "X-Request-ID"
+
+const key = "requestID"
+
+// validID matches the ids accepted from the clients, so they can be logged safely.
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+func newID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// Middleware gives every request an id, sent back in the X-Request-ID header. The id sent by the
+// client in the same header is kept, e.g. the one given by a proxy, unless it is longer than 128
+// characters or has characters other than letters, digits, '.', '_', ':' and '-'.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(Header)
+		if !validID.MatchString(id) {
+			id = newID()
+		}
+
+		ctx.Set(key, id)
+		ctx.Header(Header, id)
+
+		ctx.Next()
+	}
+}
+
+// Get returns the id of the request, or an empty string when it went through no Middleware.
+func Get(ctx *gin.Context) string {
+	return ctx.GetString(key)
+}
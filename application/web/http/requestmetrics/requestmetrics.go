@@ -0,0 +1,33 @@
+package requestmetrics
+
+import (
+	"go-bestflight/resources/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatched is the route of the requests that match no endpoint, so unknown paths do not
+// create a series each.
+const unmatched = "unmatched"
+
+// Middleware counts the requests and observes their durations by route, method and status.
+// The route is the path of the endpoint, e.g. /airports/:code, rather than the path of the request.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatched
+		}
+
+		status := strconv.Itoa(ctx.Writer.Status())
+
+		metrics.HTTPRequests.Inc(route, ctx.Request.Method, status)
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, ctx.Request.Method, status)
+	}
+}
@@ -0,0 +1,53 @@
+package requesttrace
+
+import (
+	"errors"
+	"go-bestflight/application/web/http/requestid"
+	"go-bestflight/resources/logger"
+	"go-bestflight/resources/tracing"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware starts a span for every request, continuing the trace of the traceparent header when
+// the client sends a valid one, and sends the span back in the traceparent header of the response.
+// The context of the request holds the span, so the spans of the services are its children, and a
+// logger with the request_id and trace_id fields, so every entry about the request can be found.
+// It must come after requestid.Middleware.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		parent := ctx.Request.Context()
+
+		if remote, err := tracing.ParseTraceparent(ctx.GetHeader(tracing.Header)); err == nil {
+			parent = tracing.ContextWithRemote(parent, remote)
+		}
+
+		id := requestid.Get(ctx)
+		spanCtx, span := tracing.Start(parent, ctx.Request.Method,
+			"http.method", ctx.Request.Method,
+			"http.target", ctx.Request.URL.RequestURI(),
+			"request_id", id)
+		defer span.End()
+
+		spanCtx = logger.NewContext(spanCtx, logger.With("request_id", id, "trace_id", span.Context().TraceID))
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+		ctx.Header(tracing.Header, span.Context().Traceparent())
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+
+		if route := ctx.FullPath(); route != "" {
+			span.SetName(ctx.Request.Method + " " + route)
+			span.SetAttributes("http.route", route)
+		}
+
+		span.SetAttributes("http.status_code", status)
+
+		if status >= http.StatusInternalServerError {
+			span.RecordError(errors.New(http.StatusText(status)))
+		}
+	}
+}
@@ -1,13 +1,26 @@
 package routes
 
 import (
-	controllers "go-bestflight/application/web/http/controllers/routecontroller"
+	airportcontroller "go-bestflight/application/web/http/controllers/airportcontroller"
+	healthcontroller "go-bestflight/application/web/http/controllers/healthcontroller"
+	metricscontroller "go-bestflight/application/web/http/controllers/metricscontroller"
+	routecontroller "go-bestflight/application/web/http/controllers/routecontroller"
 
 	"github.com/gin-gonic/gin"
 )
 
 // InscribeRoutes ...
 func InscribeRoutes(server *gin.Engine) {
-	server.POST("/routes", controllers.AddNewRoute)
-	server.GET("/routes", controllers.BestRoute)
+	server.POST("/routes", routecontroller.AddNewRoute)
+	server.GET("/routes", routecontroller.BestRoute)
+	server.GET("/routes/all", routecontroller.ListRoutes)
+	server.GET("/routes/export", routecontroller.ExportRoutes)
+	server.POST("/routes/import", routecontroller.ImportRoutes)
+	server.PUT("/routes", routecontroller.UpdateRoute)
+	server.DELETE("/routes", routecontroller.DeleteRoute)
+	server.GET("/airports", airportcontroller.GetAirports)
+	server.GET("/airports/:code", airportcontroller.GetAirport)
+	server.GET("/metrics", metricscontroller.GetMetrics)
+	server.GET("/healthz", healthcontroller.Liveness)
+	server.GET("/readyz", healthcontroller.Readiness)
 }
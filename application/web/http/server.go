@@ -3,9 +3,15 @@ package http
 import (
 	"context"
 	"fmt"
+	"go-bestflight/application/health"
+	"go-bestflight/application/web/http/accesslog"
+	"go-bestflight/application/web/http/problem"
+	"go-bestflight/application/web/http/requestid"
+	"go-bestflight/application/web/http/requestmetrics"
+	"go-bestflight/application/web/http/requesttrace"
 	"go-bestflight/application/web/http/routes"
-	"io"
-	"log"
+	"go-bestflight/domain/errors"
+	"go-bestflight/resources/logger"
 	"net/http"
 	"os"
 	"time"
@@ -15,18 +21,28 @@ import (
 
 var (
 	server *http.Server
+	// shutdownTimeout is how long Shutdown waits for the requests being served.
+	shutdownTimeout = 5 * time.Second
 )
 
+// probes are the paths served while the routes are being loaded.
+var probes = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// waitForRoutes refuses the requests made while the routes are being loaded, except the probes.
+func waitForRoutes(ctx *gin.Context) {
+	if !health.Loaded() && !probes[ctx.Request.URL.Path] {
+		problem.Abort(ctx, errors.NewNotReadyErr())
+		return
+	}
+
+	ctx.Next()
+}
+
 // Start the http server.
-func Start(port string, mode string, loggerWriter io.Writer) {
+func Start(port string, mode string) {
 	gin.SetMode(mode)
 	router := gin.New()
-
-	if loggerWriter == nil {
-		router.Use(gin.Logger())
-	} else {
-		router.Use(gin.LoggerWithWriter(loggerWriter))
-	}
+	router.Use(requestid.Middleware(), requesttrace.Middleware(), accesslog.Middleware(), requestmetrics.Middleware(), waitForRoutes)
 
 	routes.InscribeRoutes(router)
 
@@ -38,36 +54,54 @@ func Start(port string, mode string, loggerWriter io.Writer) {
 
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("could not start the HTTP server: %v", err)
+			logger.Fatal("could not start the HTTP server", "port", port, "error", err)
 		}
 	}()
 
-	message := fmt.Sprintf("HTTP server running on port %s...", port)
-	fmt.Println(message)
-	log.Println(message)
+	fmt.Printf("HTTP server running on port %s...\n", port)
+	logger.Info("HTTP server running", "port", port)
 }
 
-// GracefullShutdown allows gracefull shutdown.
-func GracefullShutdown(quitChan chan os.Signal) {
-	go func() {
-		log.Println("gracefull shutdown enabled")
+// Shutdown stops the server, waiting up to 5 seconds for the requests being served.
+func Shutdown() error {
+	logger.Info("shutting the HTTP server down")
 
-		oscall := <-quitChan
+	health.MarkShuttingDown()
 
-		log.Printf("system call:%+v", oscall)
-		log.Println("shutting server down...")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	server.SetKeepAlivesEnabled(false)
 
-		server.SetKeepAlivesEnabled(false)
+	return server.Shutdown(ctx)
+}
+
+// GracefullShutdown allows gracefull shutdown: the server is shut down when a signal arrives
+// in quitChan or when stop is closed, whichever comes first. The returned channel receives the
+// error of the shutdown, nil when every request was served in time, and is closed once it is done.
+func GracefullShutdown(quitChan chan os.Signal, stop <-chan struct{}) <-chan error {
+	done := make(chan error, 1)
+
+	go func() {
+		defer close(done)
 
-		if err := server.Shutdown(ctx); err != nil {
-			log.Fatal("erro when shuttingdown the server:", err)
+		logger.Info("gracefull shutdown enabled")
+
+		select {
+		case oscall := <-quitChan:
+			logger.Info("signal received", "signal", oscall)
+		case <-stop:
+		}
+
+		err := Shutdown()
+		if err != nil {
+			logger.Error("could not shut the HTTP server down", "error", err)
+		} else {
+			logger.Info("HTTP server shut down")
 		}
 
-		log.Println("finished")
-		log.Println("press ctrl + D to exit program completely")
-		fmt.Println("\nserver shutted down. Press ctrl + D to exit program completely")
+		done <- err
 	}()
+
+	return done
 }
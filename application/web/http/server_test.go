@@ -2,15 +2,26 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"go-bestflight/application/health"
+	"go-bestflight/application/web/http/problem"
+	"go-bestflight/application/web/http/requestid"
 	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/errors"
 	"go-bestflight/resources/cache"
 	"go-bestflight/resources/database"
 	"go-bestflight/resources/file"
+	"go-bestflight/resources/metrics"
 	"go-bestflight/resources/repositories/routerepository"
+	"go-bestflight/resources/tracing"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,12 +29,96 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// spans keeps the exported spans, which are ended by the server after the response may be received.
+type spans struct {
+	data []tracing.SpanData
+	sync.Mutex
+}
+
+func (s *spans) Export(span tracing.SpanData) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.data = append(s.data, span)
+}
+
+func (s *spans) Close() error {
+	return nil
+}
+
+// wait returns the first span with the given name, waiting up to a second for it.
+func (s *spans) wait(name string) tracing.SpanData {
+	for i := 0; i < 100; i++ {
+		s.Lock()
+		for _, span := range s.data {
+			if span.Name == name {
+				s.Unlock()
+				return span
+			}
+		}
+		s.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return tracing.SpanData{}
+}
+
 func TestComponents(t *testing.T) {
 	g := goblin.Goblin(t)
 
-	Start("3000", gin.TestMode, nil)
+	health.Reset()
+	Start("3000", gin.TestMode)
 	time.Sleep(2 * time.Second)
 
+	g.Describe("Tests for the probes", func() {
+		readiness := func() (int, health.Report) {
+			resp, err := http.Get("http://localhost:3000/readyz")
+			g.Assert(err).Equal(nil)
+			defer resp.Body.Close()
+
+			var report health.Report
+			json.NewDecoder(resp.Body).Decode(&report)
+
+			return resp.StatusCode, report
+		}
+
+		g.It("should be alive and not ready, refusing other requests, until the routes are loaded", func() {
+			resp, err := http.Get("http://localhost:3000/healthz")
+			g.Assert(err).Equal(nil)
+			resp.Body.Close()
+
+			g.Assert(resp.StatusCode).Equal(200)
+
+			status, report := readiness()
+
+			g.Assert(status).Equal(503)
+			g.Assert(report.Status).Equal(health.StatusNotReady)
+			g.Assert(report.Checks[3]).Equal(health.Check{Name: "routes", Ready: false, Detail: "not loaded"})
+
+			resp, err = http.Get("http://localhost:3000/airports")
+			g.Assert(err).Equal(nil)
+			resp.Body.Close()
+
+			g.Assert(resp.StatusCode).Equal(503)
+		})
+
+		g.It("should be ready once the resources are connected and the routes are loaded", func() {
+			file.Reset("test.csv")
+			database.Connect()
+			cache.Connect()
+			health.MarkLoaded()
+
+			status, report := readiness()
+
+			g.Assert(status).Equal(200)
+			g.Assert(report.Status).Equal(health.StatusReady)
+			g.Assert(len(report.Checks)).Equal(5)
+
+			file.Remove()
+		})
+	})
+
 	g.Describe("Tests for the adding of new routes", func() {
 		g.BeforeEach(func() {
 			filePath := "test.csv"
@@ -47,7 +142,8 @@ func TestComponents(t *testing.T) {
 			}
 			jsonBytes, _ := json.Marshal(route)
 
-			resp, _ := http.Post("http://localhost:3000/routes", "application/json", bytes.NewReader(jsonBytes))
+			resp, err := http.Post("http://localhost:3000/routes", "application/json", bytes.NewReader(jsonBytes))
+			g.Assert(err).Equal(nil)
 			defer resp.Body.Close()
 
 			body, _ := ioutil.ReadAll(resp.Body)
@@ -68,13 +164,36 @@ func TestComponents(t *testing.T) {
 
 			http.Post("http://localhost:3000/routes", "application/json", bytes.NewReader(jsonBytes))
 
-			resp, _ := http.Post("http://localhost:3000/routes", "application/json", bytes.NewReader(jsonBytes))
+			resp, err := http.Post("http://localhost:3000/routes", "application/json", bytes.NewReader(jsonBytes))
+			g.Assert(err).Equal(nil)
+
+			defer resp.Body.Close()
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			g.Assert(resp.StatusCode).Equal(200)
+			g.Assert(jsonBytes).Equal(body)
+		})
+
+		g.It("should return status code 200 when deleting a stored route", func() {
+			route := r.Route{
+				Boarding:    "GRU",
+				Destination: "CDG",
+				Cost:        75,
+			}
+			jsonBytes, _ := json.Marshal(route)
 
+			http.Post("http://localhost:3000/routes", "application/json", bytes.NewReader(jsonBytes))
+
+			req, _ := http.NewRequest(http.MethodDelete, "http://localhost:3000/routes?board=GRU&dest=CDG", nil)
+			resp, err := http.DefaultClient.Do(req)
+			g.Assert(err).Equal(nil)
 			defer resp.Body.Close()
+
 			body, _ := ioutil.ReadAll(resp.Body)
 
 			g.Assert(resp.StatusCode).Equal(200)
 			g.Assert(jsonBytes).Equal(body)
+			g.Assert(routerepository.RouteExists(route.Boarding, route.Destination)).IsFalse()
 		})
 
 		g.It("should return status code 400 for a malformed route", func() {
@@ -173,7 +292,26 @@ func TestComponents(t *testing.T) {
 			}
 			jsonData, _ := json.Marshal(expectedBestRoute)
 
-			resp, _ := http.Get(fmt.Sprintf("http://localhost:3000/routes?board=%s&dest=%s", boarding, destination))
+			resp, err := http.Get(fmt.Sprintf("http://localhost:3000/routes?board=%s&dest=%s", boarding, destination))
+			g.Assert(err).Equal(nil)
+			defer resp.Body.Close()
+
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			g.Assert(resp.StatusCode).Equal(200)
+			g.Assert(jsonData).Equal(body)
+		})
+
+		g.It("should return status code 200 and a json list when asking for alternatives", func() {
+			expectedBestRoutes := []r.BestRoute{
+				{Route: "GRU - BRC - SCL - ORL - CDG", Cost: 40},
+				{Route: "GRU - SCL - ORL - CDG", Cost: 45},
+				{Route: "GRU - ORL - CDG", Cost: 61},
+			}
+			jsonData, _ := json.Marshal(expectedBestRoutes)
+
+			resp, err := http.Get("http://localhost:3000/routes?board=GRU&dest=CDG&alternatives=3")
+			g.Assert(err).Equal(nil)
 			defer resp.Body.Close()
 
 			body, _ := ioutil.ReadAll(resp.Body)
@@ -191,13 +329,118 @@ func TestComponents(t *testing.T) {
 			g.Assert(resp.StatusCode).Equal(400)
 		})
 
-		g.It("should return status code 204 when the best route could not be found", func() {
+		g.It("should return status code 404 and a problem when the best route could not be found", func() {
 			boarding := "SCL"
 			destination := "GRU"
 
-			resp, _ := http.Get(fmt.Sprintf("http://localhost:3000/routes?board=%s&dest=%s", boarding, destination))
+			resp, err := http.Get(fmt.Sprintf("http://localhost:3000/routes?board=%s&dest=%s", boarding, destination))
+			g.Assert(err).Equal(nil)
+			defer resp.Body.Close()
+
+			var body problem.Problem
+			json.NewDecoder(resp.Body).Decode(&body)
+
+			g.Assert(resp.StatusCode).Equal(404)
+			g.Assert(resp.Header.Get("Content-Type")).Equal(problem.ContentType)
+			g.Assert(body.Code).Equal(errors.CodeRouteUnreachable)
+			g.Assert(body.Instance).Equal("/routes")
+			g.Assert(body.RequestID == "").IsFalse()
+			g.Assert(body.RequestID).Equal(resp.Header.Get(requestid.Header))
+		})
+
+		g.It("should keep the request id and continue the trace of the client", func() {
+			exported := &spans{}
+			tracing.SetDefault(tracing.New(exported))
+			defer tracing.SetDefault(tracing.New(nil))
+
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost:3000/routes?board=GRU&dest=CDG", nil)
+			req.Header.Set(requestid.Header, "client-id-1")
+			req.Header.Set(tracing.Header, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+			resp, err := http.DefaultClient.Do(req)
+			g.Assert(err).Equal(nil)
+			resp.Body.Close()
+
+			g.Assert(resp.Header.Get(requestid.Header)).Equal("client-id-1")
+
+			traceparent, err := tracing.ParseTraceparent(resp.Header.Get(tracing.Header))
+			g.Assert(err).Equal(nil)
+			g.Assert(traceparent.TraceID.String()).Equal("4bf92f3577b34da6a3ce929d0e0e4736")
+
+			request := exported.wait("GET /routes")
+			search := exported.wait("search")
+
+			g.Assert(request.SpanID).Equal(traceparent.SpanID.String())
+			g.Assert(request.ParentSpanID).Equal("00f067aa0ba902b7")
+			g.Assert(request.Attributes["request_id"]).Equal("client-id-1")
+			g.Assert(request.Attributes["http.status_code"]).Equal(200)
+			g.Assert(search.TraceID).Equal(request.TraceID)
+			g.Assert(search.ParentSpanID).Equal(request.SpanID)
+			g.Assert(search.Attributes["algorithm"]).Equal("dijkstra")
+		})
+	})
+
+	g.Describe("Tests for metrics", func() {
+		g.It("should expose the requests by route, method and status", func() {
+			http.Get("http://localhost:3000/airports/XYZ")
+
+			resp, err := http.Get("http://localhost:3000/metrics")
+			g.Assert(err).Equal(nil)
+			defer resp.Body.Close()
+
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			g.Assert(resp.StatusCode).Equal(200)
+			g.Assert(resp.Header.Get("Content-Type")).Equal(metrics.ContentType)
+			g.Assert(strings.Contains(string(body),
+				`bestflight_http_requests_total{route="/airports/:code",method="GET",status="404"} 1`)).IsTrue()
+			g.Assert(strings.Contains(string(body), "# TYPE bestflight_search_duration_seconds histogram")).IsTrue()
+		})
+	})
+
+	g.Describe("Tests for Shutdown", func() {
+		g.It("should make the application not ready", func() {
+			g.Assert(Shutdown()).Equal(nil)
+
+			report := health.Readiness()
+
+			g.Assert(report.Status).Equal(health.StatusNotReady)
+			g.Assert(report.Checks[4]).Equal(health.Check{Name: "server", Ready: false, Detail: "shutting down"})
+		})
+	})
+
+	g.Describe("Tests for GracefullShutdown", func() {
+		g.It("should shut the server down when stopped", func() {
+			Start("3001", gin.TestMode)
+			time.Sleep(100 * time.Millisecond)
+
+			stop := make(chan struct{})
+			close(stop)
+
+			g.Assert(<-GracefullShutdown(nil, stop)).Equal(nil)
+
+			_, err := http.Get("http://localhost:3001/healthz")
+			g.Assert(err != nil).IsTrue()
+		})
+
+		g.It("should return the error of a shutdown that times out instead of exiting", func() {
+			Start("3002", gin.TestMode)
+			time.Sleep(100 * time.Millisecond)
+
+			// A connection without a request keeps the server from shutting down for 5 seconds.
+			conn, err := net.Dial("tcp", "localhost:3002")
+			g.Assert(err).Equal(nil)
+			defer conn.Close()
+
+			time.Sleep(100 * time.Millisecond)
+
+			shutdownTimeout = 10 * time.Millisecond
+			defer func() { shutdownTimeout = 5 * time.Second }()
+
+			quitChan := make(chan os.Signal, 1)
+			quitChan <- os.Interrupt
 
-			g.Assert(resp.StatusCode).Equal(204)
+			g.Assert(<-GracefullShutdown(quitChan, nil)).Equal(context.DeadlineExceeded)
 		})
 	})
 }
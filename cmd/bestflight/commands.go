@@ -0,0 +1,537 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go-bestflight/application"
+	"go-bestflight/application/cli"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/services/routeservice"
+	"go-bestflight/resources/logger"
+	"go-bestflight/resources/tracing"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// required reports the required flags that are missing.
+func required(fs *flag.FlagSet, stderr io.Writer, names ...string) bool {
+	missing := []string{}
+
+	for _, name := range names {
+		if fs.Lookup(name).Value.String() == "" {
+			missing = append(missing, "--"+name)
+		}
+	}
+
+	if len(missing) > 0 {
+		fmt.Fprintf(stderr, "missing required flags: %s\n\n", strings.Join(missing, ", "))
+		fs.Usage()
+		return false
+	}
+
+	return true
+}
+
+func wrongArguments(fs *flag.FlagSet, stderr io.Writer) int {
+	fmt.Fprintf(stderr, "wrong number of arguments\n\n")
+	fs.Usage()
+
+	return exitUsage
+}
+
+// list splits a comma separated flag value, e.g. "SCL,ORL".
+func list(value string) []string {
+	values := []string{}
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// quiet discards the log of the commands that run without the server, which print what matters.
+func quiet() {
+	logger.SetDefault(logger.Discard())
+}
+
+// spanExporter returns the exporter of the spans of serve, to a file or to a collector, or nil when
+// they are not exported.
+func spanExporter(output, collector string) (tracing.Exporter, error) {
+	switch {
+	case output != "":
+		exporter, err := tracing.OpenFileExporter(output)
+		if err != nil {
+			return nil, err
+		}
+
+		return exporter, nil
+	case collector != "":
+		return tracing.NewCollectorExporter(collector), nil
+	default:
+		return nil, nil
+	}
+}
+
+func formats() []string {
+	names := []string{}
+
+	for _, format := range logger.Formats {
+		names = append(names, string(format))
+	}
+
+	return names
+}
+
+func modes() []string {
+	names := []string{}
+
+	for _, mode := range application.Modes {
+		names = append(names, string(mode))
+	}
+
+	return names
+}
+
+var serveCommand = command{
+	name:    "serve",
+	summary: "Load the routes and start the HTTP server and the interactive advisor.",
+	details: "With --mode server only the HTTP server runs, until SIGINT or SIGTERM, and with --mode advisor only the advisor runs.",
+	setup: func(fs *flag.FlagSet) func(args []string, stdout, stderr io.Writer) int {
+		routesPath := fs.String("routes", "", "routes file, created if it does not exist (required)")
+		port := fs.String("port", "", "port of the HTTP server (required unless the mode is advisor)")
+		airportsPath := fs.String("airports", "", "airports reference file in the OpenFlights airports.dat format")
+		reloadInterval := fs.Duration("reload-interval", application.ReloadInterval, "how often the routes file is checked for changes, 0 to never check")
+		queryTimeout := fs.Duration("query-timeout", routeservice.QueryTimeout, "budget of every search, 0 for no limit")
+		mode := fs.String("mode", string(application.Interactive), "what to run: "+strings.Join(modes(), ", "))
+		logLevel := fs.String("log-level", logger.LevelInfo.String(), "least severe entries logged: debug, info, warn, error")
+		logFormat := fs.String("log-format", string(logger.Logfmt), "format of the log entries: "+strings.Join(formats(), ", "))
+		logOutput := fs.String("log-output", "info.log", "where the log is written: stdout, stderr or a file, created if it does not exist")
+		logMaxSize := fs.Int("log-max-size", 10, "size in megabytes a log file reaches before it is rotated, 0 to never rotate it")
+		logBackups := fs.Int("log-backups", 3, "number of rotated log files kept")
+		traceOutput := fs.String("trace-output", "", "file the spans are appended to, as JSON lines")
+		traceCollector := fs.String("trace-collector", "", "URL the spans are posted to in batches, as JSON")
+
+		return func(args []string, stdout, stderr io.Writer) int {
+			if len(args) > 0 {
+				return wrongArguments(fs, stderr)
+			}
+
+			runMode := application.Mode(*mode)
+			valid := false
+
+			for _, m := range application.Modes {
+				valid = valid || m == runMode
+			}
+
+			if !valid {
+				fmt.Fprintf(stderr, "invalid mode %q, it must be one of: %s\n", *mode, strings.Join(modes(), ", "))
+				return exitUsage
+			}
+
+			requiredFlags := []string{"routes", "port"}
+			if runMode == application.AdvisorOnly {
+				requiredFlags = requiredFlags[:1]
+			}
+
+			if !required(fs, stderr, requiredFlags...) {
+				return exitUsage
+			}
+
+			if *traceOutput != "" && *traceCollector != "" {
+				fmt.Fprintln(stderr, "--trace-output and --trace-collector can not be used together")
+				return exitUsage
+			}
+
+			level, err := logger.ParseLevel(*logLevel)
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitUsage
+			}
+
+			format, err := logger.ParseFormat(*logFormat)
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitUsage
+			}
+
+			log, err := logger.Open(logger.Config{
+				Level:   level,
+				Format:  format,
+				Output:  *logOutput,
+				MaxSize: int64(*logMaxSize) << 20,
+				Backups: *logBackups,
+			})
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitFailure
+			}
+			defer log.Close()
+
+			logger.SetDefault(log)
+
+			exporter, err := spanExporter(*traceOutput, *traceCollector)
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitFailure
+			}
+
+			tracer := tracing.New(exporter)
+			defer tracer.Close()
+
+			tracing.SetDefault(tracer)
+
+			application.ReloadInterval = *reloadInterval
+			routeservice.QueryTimeout = *queryTimeout
+
+			// The advisor alone is stopped by the signals like any other program.
+			var quitChan chan os.Signal
+
+			if runMode != application.AdvisorOnly {
+				quitChan = make(chan os.Signal, 1)
+				signal.Notify(quitChan, os.Interrupt, syscall.SIGTERM)
+			}
+
+			err = application.Start(*routesPath, *port, *airportsPath, runMode, quitChan)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not shut the HTTP server down: %v\n", err)
+				return exitFailure
+			}
+
+			return exitOK
+		}
+	},
+}
+
+var queryCommand = command{
+	name:    "query",
+	args:    "BOARDING DESTINATION",
+	summary: "Print the best route between two airports, without changing the routes file.",
+	setup: func(fs *flag.FlagSet) func(args []string, stdout, stderr io.Writer) int {
+		routesPath := fs.String("routes", "", "routes file (required)")
+		maxStops := fs.Int("max-stops", -1, "maximum number of intermediate airports, -1 for no limit")
+		alternatives := fs.Int("alternatives", 0, fmt.Sprintf("number of cheapest routes to print, up to %d", routeservice.MaxAlternatives))
+		via := fs.String("via", "", "comma separated airports to pass through, in order")
+		avoid := fs.String("avoid", "", "comma separated airports to avoid")
+		queryTimeout := fs.Duration("query-timeout", routeservice.QueryTimeout, "budget of every search, 0 for no limit")
+
+		return func(args []string, stdout, stderr io.Writer) int {
+			if len(args) != 2 {
+				return wrongArguments(fs, stderr)
+			}
+
+			if !required(fs, stderr, "routes") {
+				return exitUsage
+			}
+
+			quiet()
+
+			routeservice.QueryTimeout = *queryTimeout
+
+			service, err := application.NewRouteService(*routesPath, true)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not read the routes file: %v\n", err)
+				return exitFailure
+			}
+
+			options := []routeservice.SearchOption{}
+
+			if *maxStops >= 0 {
+				options = append(options, routeservice.WithMaxStops(*maxStops))
+			}
+
+			if airports := list(*via); len(airports) > 0 {
+				options = append(options, routeservice.WithVia(airports...))
+			}
+
+			if airports := list(*avoid); len(airports) > 0 {
+				options = append(options, routeservice.WithAvoid(airports...))
+			}
+
+			bestRoutes := []r.BestRoute{}
+
+			if *alternatives > 0 {
+				bestRoutes, err = service.GetBestRoutes(context.Background(), args[0], args[1], *alternatives, options...)
+			} else {
+				var bestRoute r.BestRoute
+
+				bestRoute, err = service.GetBestRoute(context.Background(), args[0], args[1], options...)
+				bestRoutes = append(bestRoutes, bestRoute)
+			}
+
+			if err != nil {
+				fmt.Fprintln(stderr, err.Error())
+				return exitFailure
+			}
+
+			for _, bestRoute := range bestRoutes {
+				fmt.Fprintf(stdout, "best route: %s > $%d\n", bestRoute.Route, bestRoute.Cost)
+			}
+
+			return exitOK
+		}
+	},
+}
+
+var batchCommand = command{
+	name:    "batch",
+	args:    "[FILE]",
+	summary: "Print the best route of every pair of airports of a file, or of the standard input.",
+	details: "The pairs are one per line, like GRU-CDG, GRU,CDG or GRU CDG, optionally with the maximum stops, e.g. GRU-CDG/2.\n" +
+		"A result is printed per pair, in the order of the input, with the error of the pair when there is one.",
+	setup: func(fs *flag.FlagSet) func(args []string, stdout, stderr io.Writer) int {
+		routesPath := fs.String("routes", "", "routes file (required)")
+		format := fs.String("format", cli.BatchCSV, "format of the results: csv, with a header, or json, a JSON object per line")
+		concurrency := fs.Int("concurrency", runtime.NumCPU(), "number of pairs searched at the same time")
+		queryTimeout := fs.Duration("query-timeout", routeservice.QueryTimeout, "budget of every search, 0 for no limit")
+
+		return func(args []string, stdout, stderr io.Writer) int {
+			if len(args) > 1 {
+				return wrongArguments(fs, stderr)
+			}
+
+			if !required(fs, stderr, "routes") {
+				return exitUsage
+			}
+
+			if *format != cli.BatchCSV && *format != cli.BatchJSON {
+				fmt.Fprintf(stderr, "invalid format %q, it must be csv or json\n", *format)
+				return exitUsage
+			}
+
+			quiet()
+
+			routeservice.QueryTimeout = *queryTimeout
+
+			input, err := openInput(args)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not open the input: %v\n", err)
+				return exitFailure
+			}
+			defer input.Close()
+
+			service, err := application.NewRouteService(*routesPath, true)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not read the routes file: %v\n", err)
+				return exitFailure
+			}
+
+			err = cli.Batch(service, input, stdout, *format, *concurrency)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not run the batch: %v\n", err)
+				return exitFailure
+			}
+
+			return exitOK
+		}
+	},
+}
+
+var exportCommand = command{
+	name:    "export",
+	args:    "[FILE]",
+	summary: "Export the routes of the routes file to a file, or to the standard output.",
+	details: "The formats are those of the GET /routes/export endpoint. The routes file is never changed.",
+	setup: func(fs *flag.FlagSet) func(args []string, stdout, stderr io.Writer) int {
+		routesPath := fs.String("routes", "", "routes file (required)")
+		format := fs.String("format", routeservice.ExportCSV, "format of the export: csv, json, dot or geojson")
+		airportsPath := fs.String("airports", "", "airports reference file in the OpenFlights airports.dat format, with the coordinates of the geojson format")
+
+		return func(args []string, stdout, stderr io.Writer) int {
+			if len(args) > 1 {
+				return wrongArguments(fs, stderr)
+			}
+
+			if !required(fs, stderr, "routes") {
+				return exitUsage
+			}
+
+			if !isExportFormat(*format) {
+				fmt.Fprintf(stderr, "invalid format %q, it must be one of %s\n", *format, strings.Join(exportFormats, ", "))
+				return exitUsage
+			}
+
+			quiet()
+
+			service, err := application.NewExportService(*routesPath, *airportsPath)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not read the routes or airports file: %v\n", err)
+				return exitFailure
+			}
+
+			if len(args) == 0 || args[0] == "-" {
+				err = service.Export(context.Background(), stdout, *format)
+				if err != nil {
+					fmt.Fprintf(stderr, "could not export the routes: %v\n", err)
+					return exitFailure
+				}
+
+				return exitOK
+			}
+
+			output, err := os.Create(args[0])
+			if err != nil {
+				fmt.Fprintf(stderr, "could not create the output: %v\n", err)
+				return exitFailure
+			}
+			defer output.Close()
+
+			err = service.Export(context.Background(), output, *format)
+			if err != nil {
+				os.Remove(args[0])
+				fmt.Fprintf(stderr, "could not export the routes: %v\n", err)
+				return exitFailure
+			}
+
+			return exitOK
+		}
+	},
+}
+
+var exportFormats = []string{routeservice.ExportCSV, routeservice.ExportJSON, routeservice.ExportDOT, routeservice.ExportGeoJSON}
+
+func isExportFormat(format string) bool {
+	for _, name := range exportFormats {
+		if strings.ToLower(format) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// openInput opens the file of the arguments, or the standard input when there is none or it is "-".
+func openInput(args []string) (io.ReadCloser, error) {
+	if len(args) == 0 || args[0] == "-" {
+		return ioutil.NopCloser(os.Stdin), nil
+	}
+
+	return os.Open(args[0])
+}
+
+// printReport prints an import report as JSON, or the lines that were not accepted and a summary.
+func printReport(stdout io.Writer, report r.ImportReport, asJSON bool) {
+	if asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "    ")
+		encoder.Encode(report)
+
+		return
+	}
+
+	for _, line := range report.Duplicate {
+		fmt.Fprintf(stdout, "line %d: duplicate: %s\n", line.Line, line.Reason)
+	}
+
+	for _, line := range report.Rejected {
+		fmt.Fprintf(stdout, "line %d: rejected: %s\n", line.Line, line.Reason)
+	}
+
+	fmt.Fprintf(stdout, "%d accepted, %d duplicate, %d rejected\n", len(report.Accepted), len(report.Duplicate), len(report.Rejected))
+}
+
+var importCommand = command{
+	name:    "import",
+	args:    "[FILE]",
+	summary: "Import the routes of a CSV, or of the standard input, into the routes file.",
+	details: "Do not use it on the file of a running server, use its POST /routes/import endpoint instead.\n" +
+		"Exits with 1 when there are rejected lines.",
+	setup: func(fs *flag.FlagSet) func(args []string, stdout, stderr io.Writer) int {
+		routesPath := fs.String("routes", "", "routes file to import into, created if it does not exist (required)")
+		atomic := fs.Bool("atomic", false, "import nothing if any line is rejected")
+		asJSON := fs.Bool("json", false, "print the report as JSON")
+
+		return func(args []string, stdout, stderr io.Writer) int {
+			if len(args) > 1 {
+				return wrongArguments(fs, stderr)
+			}
+
+			if !required(fs, stderr, "routes") {
+				return exitUsage
+			}
+
+			quiet()
+
+			input, err := openInput(args)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not open the input: %v\n", err)
+				return exitFailure
+			}
+			defer input.Close()
+
+			service, err := application.NewRouteService(*routesPath, false)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not open the routes file: %v\n", err)
+				return exitFailure
+			}
+
+			report, err := service.ImportCSV(context.Background(), input, *atomic)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not import the routes: %v\n", err)
+				return exitFailure
+			}
+
+			printReport(stdout, report, *asJSON)
+
+			if !report.Committed {
+				fmt.Fprintln(stderr, "nothing was imported because there are rejected lines")
+			}
+
+			if !report.Committed || len(report.Rejected) > 0 {
+				return exitFailure
+			}
+
+			return exitOK
+		}
+	},
+}
+
+var validateCommand = command{
+	name:    "validate",
+	args:    "FILE",
+	summary: "Check the lines of a routes file without loading them.",
+	details: "Use \"-\" as FILE to read the standard input. Exits with 1 when there are duplicate or rejected lines.",
+	setup: func(fs *flag.FlagSet) func(args []string, stdout, stderr io.Writer) int {
+		asJSON := fs.Bool("json", false, "print the report as JSON")
+
+		return func(args []string, stdout, stderr io.Writer) int {
+			if len(args) != 1 {
+				return wrongArguments(fs, stderr)
+			}
+
+			quiet()
+
+			input, err := openInput(args)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not open the input: %v\n", err)
+				return exitFailure
+			}
+			defer input.Close()
+
+			service, _ := application.NewRouteService("", true)
+
+			report, err := service.ValidateCSV(context.Background(), input)
+			if err != nil {
+				fmt.Fprintf(stderr, "could not read the input: %v\n", err)
+				return exitFailure
+			}
+
+			printReport(stdout, report, *asJSON)
+
+			if len(report.Duplicate) > 0 || len(report.Rejected) > 0 {
+				return exitFailure
+			}
+
+			return exitOK
+		}
+	},
+}
@@ -1,17 +1,203 @@
 package main
 
 import (
-	"go-bestflight/application"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-	"os/signal"
+	"strings"
 )
 
-func main() {
-	filePath := os.Args[1]
-	port := os.Args[2]
+// The exit codes of the commands.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// envPrefix prefixes the environment variables that can be used instead of the flags,
+// e.g. BESTFLIGHT_ROUTES for --routes.
+const envPrefix = "BESTFLIGHT_"
+
+// command is a subcommand of bestflight, with a one line summary and optional details for its help.
+// setup defines its flags and returns the function that runs
+// it with the parsed flags and the arguments, returning the exit code.
+type command struct {
+	name    string
+	args    string
+	summary string
+	details string
+	setup   func(fs *flag.FlagSet) func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = []command{serveCommand, queryCommand, batchCommand, importCommand, validateCommand, exportCommand}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: bestflight <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'bestflight <command> --help' for the flags of a command.")
+	fmt.Fprintln(w, "Every flag can also be set by an environment variable, e.g. BESTFLIGHT_ROUTES for --routes.")
+	fmt.Fprintln(w, "The exit code is 0 on success, 1 when the command fails and 2 when it is used wrongly.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "The legacy form 'bestflight routes.csv port [airports.dat]' is the same as 'bestflight serve'.")
+}
+
+// envName returns the environment variable of a flag, e.g. BESTFLIGHT_MAX_STOPS for --max-stops.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets the flags that were not given in the command line from their environment variables.
+func applyEnv(fs *flag.FlagSet) error {
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var err error
+
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || given[f.Name] || err != nil {
+			return
+		}
+
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %v", value, envName(f.Name), setErr)
+		}
+	})
+
+	return err
+}
+
+// parse parses the flags, which can come before, between or after the arguments, and returns the arguments.
+// The errors are reported by the flag set itself.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			break
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	return positional, nil
+}
+
+func commandUsage(fs *flag.FlagSet, c command) func() {
+	return func() {
+		w := fs.Output()
+
+		fmt.Fprintf(w, "Usage: %s\n\n%s\n", strings.TrimSpace("bestflight "+c.name+" [flags] "+c.args), c.summary)
 
-	quitChan := make(chan os.Signal, 1)
-	signal.Notify(quitChan, os.Interrupt)
+		if c.details != "" {
+			fmt.Fprintln(w, c.details)
+		}
 
-	application.Start(filePath, port, quitChan)
+		fmt.Fprintf(w, "\nFlags:\n")
+
+		fs.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, "  --%s (%s)\n\t%s", f.Name, envName(f.Name), f.Usage)
+
+			if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+				fmt.Fprintf(w, " (default %s)", f.DefValue)
+			}
+
+			fmt.Fprintln(w)
+		})
+	}
+}
+
+// isLegacy tells whether the arguments are in the form 'bestflight routes.csv port [airports.dat]'.
+func isLegacy(args []string) bool {
+	if len(args) < 2 || len(args) > 3 {
+		return false
+	}
+
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return false
+		}
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if isLegacy(args) {
+		legacy := []string{"--routes", args[0], "--port", args[1]}
+
+		if len(args) == 3 {
+			legacy = append(legacy, "--airports", args[2])
+		}
+
+		args = append([]string{serveCommand.name}, legacy...)
+	}
+
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return exitOK
+	}
+
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		fs.Usage = commandUsage(fs, c)
+		runCommand := c.setup(fs)
+
+		positional, err := parse(fs, args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+
+		if err != nil {
+			return exitUsage
+		}
+
+		if err := applyEnv(fs); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+
+		return runCommand(positional, stdout, stderr)
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+	usage(stderr)
+
+	return exitUsage
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestCommandLine(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	routesPath := "test.csv"
+
+	execute := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+
+		code := run(args, &stdout, &stderr)
+
+		return code, stdout.String(), stderr.String()
+	}
+
+	g.Describe("Tests for the command line", func() {
+		g.BeforeEach(func() {
+			ioutil.WriteFile(routesPath, []byte("GRU,BRC,10\nBRC,SCL,5\nGRU,CDG,75\nGRU,SCL,20\nGRU,ORL,56\nORL,CDG,5\nSCL,ORL,20\n"), 0664)
+		})
+
+		g.AfterEach(func() {
+			os.Remove(routesPath)
+			os.Remove(routesPath + ".wal")
+		})
+
+		g.Describe("Tests for run", func() {
+			g.It("should print the usage and exit with 2 without a command", func() {
+				code, _, stderr := execute()
+
+				g.Assert(code).Equal(exitUsage)
+				g.Assert(strings.HasPrefix(stderr, "Usage: bestflight")).IsTrue()
+			})
+
+			g.It("should print the usage and exit with 0 for help", func() {
+				code, stdout, _ := execute("--help")
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(strings.Contains(stdout, "validate")).IsTrue()
+
+				code, _, stderr := execute("query", "--help")
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(strings.Contains(stderr, "--max-stops (BESTFLIGHT_MAX_STOPS)")).IsTrue()
+			})
+
+			g.It("should exit with 2 for an unknown command, flag or missing argument", func() {
+				code, _, _ := execute("fly")
+				g.Assert(code).Equal(exitUsage)
+
+				code, _, _ = execute("query", "--routes", routesPath, "--fast", "GRU", "CDG")
+				g.Assert(code).Equal(exitUsage)
+
+				code, _, _ = execute("query", "--routes", routesPath, "GRU")
+				g.Assert(code).Equal(exitUsage)
+
+				code, _, stderr := execute("query", "GRU", "CDG")
+				g.Assert(code).Equal(exitUsage)
+				g.Assert(strings.HasPrefix(stderr, "missing required flags: --routes")).IsTrue()
+			})
+		})
+
+		g.Describe("Tests for serve", func() {
+			g.It("should exit with 2 for an invalid mode", func() {
+				code, _, stderr := execute("serve", "--routes", routesPath, "--port", "5000", "--mode", "daemon")
+
+				g.Assert(code).Equal(exitUsage)
+				g.Assert(strings.HasPrefix(stderr, `invalid mode "daemon"`)).IsTrue()
+			})
+
+			g.It("should require the port unless the mode is advisor", func() {
+				code, _, stderr := execute("serve", "--routes", routesPath)
+
+				g.Assert(code).Equal(exitUsage)
+				g.Assert(strings.HasPrefix(stderr, "missing required flags: --port")).IsTrue()
+
+				code, _, stderr = execute("serve", "--mode", "advisor")
+
+				g.Assert(code).Equal(exitUsage)
+				g.Assert(strings.HasPrefix(stderr, "missing required flags: --routes\n")).IsTrue()
+			})
+
+			g.It("should exit with 2 for an invalid log level or format and 1 for a log file it cannot open", func() {
+				code, _, stderr := execute("serve", "--routes", routesPath, "--port", "5000", "--log-level", "verbose")
+
+				g.Assert(code).Equal(exitUsage)
+				g.Assert(strings.HasPrefix(stderr, `invalid log level "verbose"`)).IsTrue()
+
+				code, _, stderr = execute("serve", "--routes", routesPath, "--port", "5000", "--log-format", "xml")
+
+				g.Assert(code).Equal(exitUsage)
+				g.Assert(strings.HasPrefix(stderr, `invalid log format "xml"`)).IsTrue()
+
+				code, _, stderr = execute("serve", "--routes", routesPath, "--port", "5000", "--log-output", "missing/info.log")
+
+				g.Assert(code).Equal(exitFailure)
+				g.Assert(strings.HasPrefix(stderr, "could not open the log file")).IsTrue()
+			})
+
+			g.It("should exit with 2 when the spans are both written and posted", func() {
+				code, _, stderr := execute("serve", "--routes", routesPath, "--port", "5000",
+					"--trace-output", "traces.json", "--trace-collector", "http://localhost:4318/spans")
+
+				g.Assert(code).Equal(exitUsage)
+				g.Assert(stderr).Equal("--trace-output and --trace-collector can not be used together\n")
+			})
+		})
+
+		g.Describe("Tests for isLegacy", func() {
+			g.It("should recognize the positional form of serve", func() {
+				g.Assert(isLegacy([]string{"routes.csv", "5000"})).IsTrue()
+				g.Assert(isLegacy([]string{"routes.csv", "5000", "airports.dat"})).IsTrue()
+
+				g.Assert(isLegacy([]string{"routes.csv"})).IsFalse()
+				g.Assert(isLegacy([]string{"validate", "routes.csv"})).IsFalse()
+				g.Assert(isLegacy([]string{"query", "GRU", "CDG"})).IsFalse()
+				g.Assert(isLegacy([]string{"serve", "--routes", "routes.csv"})).IsFalse()
+			})
+		})
+
+		g.Describe("Tests for query", func() {
+			g.It("should print the best route with flags after the arguments", func() {
+				code, stdout, _ := execute("query", "GRU", "CDG", "--routes", routesPath)
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(stdout).Equal("best route: GRU - BRC - SCL - ORL - CDG > $40\n")
+			})
+
+			g.It("should take the flags from the environment unless given", func() {
+				os.Setenv("BESTFLIGHT_ROUTES", routesPath)
+				os.Setenv("BESTFLIGHT_MAX_STOPS", "0")
+				defer os.Unsetenv("BESTFLIGHT_ROUTES")
+				defer os.Unsetenv("BESTFLIGHT_MAX_STOPS")
+
+				code, stdout, _ := execute("query", "GRU", "CDG")
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(stdout).Equal("best route: GRU - CDG > $75\n")
+
+				code, stdout, _ = execute("query", "--max-stops", "1", "GRU", "CDG")
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(stdout).Equal("best route: GRU - ORL - CDG > $61\n")
+			})
+
+			g.It("should exit with 1 when there is no route and leave the file untouched", func() {
+				code, _, stderr := execute("query", "--routes", routesPath, "CDG", "GRU")
+
+				_, err := os.Stat(routesPath + ".wal")
+
+				g.Assert(code).Equal(exitFailure)
+				g.Assert(stderr != "").IsTrue()
+				g.Assert(os.IsNotExist(err)).IsTrue()
+			})
+
+			g.It("should exit with 1 when the routes file does not exist", func() {
+				code, _, _ := execute("query", "--routes", "missing.csv", "GRU", "CDG")
+
+				_, err := os.Stat("missing.csv")
+
+				g.Assert(code).Equal(exitFailure)
+				g.Assert(os.IsNotExist(err)).IsTrue()
+			})
+		})
+
+		g.Describe("Tests for batch", func() {
+			g.It("should print a JSON line per pair of the file", func() {
+				inputPath := "pairs.txt"
+				ioutil.WriteFile(inputPath, []byte("GRU-CDG\nCDG-XYZ\n"), 0664)
+				defer os.Remove(inputPath)
+
+				code, stdout, _ := execute("batch", "--routes", routesPath, "--format", "json", inputPath)
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(stdout).Equal(`{"line":1,"boarding":"GRU","destination":"CDG","route":"GRU - BRC - SCL - ORL - CDG","cost":40}` + "\n" +
+					`{"line":2,"boarding":"CDG","destination":"XYZ","error":"invalid airport: not registered"}` + "\n")
+			})
+
+			g.It("should exit with 2 for an unknown format", func() {
+				code, _, _ := execute("batch", "--routes", routesPath, "--format", "xml")
+
+				g.Assert(code).Equal(exitUsage)
+			})
+		})
+
+		g.Describe("Tests for import and validate", func() {
+			g.It("should import the accepted lines of a file", func() {
+				inputPath := "import.csv"
+				ioutil.WriteFile(inputPath, []byte("GRU,XYZ,3\nGRU,CDG,70\n"), 0664)
+				defer os.Remove(inputPath)
+
+				code, stdout, _ := execute("import", "--routes", routesPath, inputPath)
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(stdout).Equal("line 2: duplicate: route already created\n1 accepted, 1 duplicate, 0 rejected\n")
+
+				code, stdout, _ = execute("query", "--routes", routesPath, "GRU", "XYZ")
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(stdout).Equal("best route: GRU - XYZ > $3\n")
+			})
+
+			g.It("should exit with 1 and import nothing in atomic mode with rejected lines", func() {
+				inputPath := "import.csv"
+				ioutil.WriteFile(inputPath, []byte("GRU,XYZ,3\nGRU,CDG\n"), 0664)
+				defer os.Remove(inputPath)
+
+				code, _, _ := execute("import", "--atomic", "--routes", routesPath, inputPath)
+				g.Assert(code).Equal(exitFailure)
+
+				code, _, _ = execute("query", "--routes", routesPath, "GRU", "XYZ")
+				g.Assert(code).Equal(exitFailure)
+			})
+
+			g.It("should validate a file without loading it", func() {
+				code, stdout, _ := execute("validate", routesPath)
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(stdout).Equal("7 accepted, 0 duplicate, 0 rejected\n")
+
+				ioutil.WriteFile(routesPath, []byte("GRU,BRC,10\nGRU,BRC,12\nGRU,CDG,0\n"), 0664)
+
+				code, stdout, _ = execute("validate", routesPath)
+
+				g.Assert(code).Equal(exitFailure)
+				g.Assert(stdout).Equal("line 2: duplicate: duplicate of line 1\nline 3: rejected: invalid cost\n1 accepted, 1 duplicate, 1 rejected\n")
+			})
+		})
+
+		g.Describe("Tests for export", func() {
+			g.It("should print the sorted routes in the format", func() {
+				code, stdout, _ := execute("export", "--routes", routesPath, "--format", "dot")
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(strings.HasPrefix(stdout, "digraph routes {\n\t\"BRC\" -> \"SCL\" [label=\"5\"];\n")).IsTrue()
+			})
+
+			g.It("should write the routes to a file and leave the routes file untouched", func() {
+				outputPath := "export.csv"
+				defer os.Remove(outputPath)
+
+				code, stdout, _ := execute("export", "--routes", routesPath, outputPath)
+
+				g.Assert(code).Equal(exitOK)
+				g.Assert(stdout).Equal("")
+
+				content, _ := ioutil.ReadFile(outputPath)
+				g.Assert(string(content)).Equal("BRC,SCL,5\nGRU,BRC,10\nGRU,CDG,75\nGRU,ORL,56\nGRU,SCL,20\nORL,CDG,5\nSCL,ORL,20\n")
+
+				_, err := os.Stat(routesPath + ".wal")
+				g.Assert(os.IsNotExist(err)).IsTrue()
+			})
+
+			g.It("should exit with 2 for an unknown format", func() {
+				code, _, stderr := execute("export", "--routes", routesPath, "--format", "xml")
+
+				g.Assert(code).Equal(exitUsage)
+				g.Assert(strings.HasPrefix(stderr, `invalid format "xml"`)).IsTrue()
+			})
+		})
+	})
+}
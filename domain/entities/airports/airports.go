@@ -0,0 +1,27 @@
+package airports
+
+// Airport holds the reference data of an airport identified by its IATA code.
+// Airports that are only known from routes have nothing but the code. The coordinates are nil
+// when they are not known, as 0 is a valid latitude and longitude.
+type Airport struct {
+	Code      string   `json:"code"`
+	Name      string   `json:"name,omitempty"`
+	City      string   `json:"city,omitempty"`
+	Country   string   `json:"country,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	Timezone  string   `json:"timezone,omitempty"`
+}
+
+// At returns the airport located at the given coordinates.
+func (a Airport) At(latitude, longitude float64) Airport {
+	a.Latitude = &latitude
+	a.Longitude = &longitude
+
+	return a
+}
+
+// HasCoordinates tells whether the location of the airport is known.
+func (a Airport) HasCoordinates() bool {
+	return a.Latitude != nil && a.Longitude != nil
+}
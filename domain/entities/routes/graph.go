@@ -0,0 +1,218 @@
+package routes
+
+// Edge is a connection to the airport with index To in a Graph.
+type Edge struct {
+	To   int
+	Cost int
+}
+
+// Graph is an immutable and integer-indexed snapshot of the routes, ready for searches.
+// Changes never modify a Graph, they return a new one sharing with the previous
+// snapshot everything that did not change, so it can be read without locks.
+type Graph struct {
+	airports  []string
+	indexes   map[string]int
+	adjacency [][]Edge
+	free      []int // indexes of the removed airports, reused by the next airports added
+}
+
+// NewGraph is a constructor for an empty Graph.
+func NewGraph() *Graph {
+	return &Graph{
+		airports:  []string{},
+		indexes:   make(map[string]int),
+		adjacency: [][]Edge{},
+	}
+}
+
+// Size returns the number of indexes of the graph, which is the size of the slices indexed by airport.
+// It includes the indexes of removed airports that were not reused yet.
+func (g *Graph) Size() int {
+	return len(g.airports)
+}
+
+// Airports returns the number of airports in the graph.
+func (g *Graph) Airports() int {
+	return len(g.indexes)
+}
+
+// Routes returns the number of routes in the graph.
+func (g *Graph) Routes() int {
+	routes := 0
+
+	for _, edges := range g.adjacency {
+		routes += len(edges)
+	}
+
+	return routes
+}
+
+// Index returns the index of an airport and whether it is part of the graph.
+func (g *Graph) Index(airport string) (int, bool) {
+	index, ok := g.indexes[airport]
+
+	return index, ok
+}
+
+// Airport returns the airport with the given index.
+func (g *Graph) Airport(index int) string {
+	return g.airports[index]
+}
+
+// Edges returns the connections leaving the airport with the given index.
+// The returned slice is shared with other snapshots and must not be modified.
+func (g *Graph) Edges(index int) []Edge {
+	return g.adjacency[index]
+}
+
+// WithRoute returns a new Graph with the route added, or with its cost replaced when it exists.
+func (g *Graph) WithRoute(route Route) *Graph {
+	return g.WithRoutes([]Route{route})
+}
+
+// WithRoutes returns a new Graph with all the routes added or replaced at once.
+func (g *Graph) WithRoutes(routes []Route) *Graph {
+	b := newGraphBuilder(g)
+
+	for _, route := range routes {
+		b.setEdge(b.index(route.Boarding), b.index(route.Destination), route.Cost)
+	}
+
+	return b.graph
+}
+
+// WithoutRoute returns a new Graph without the connection from the route boarding to its destination.
+func (g *Graph) WithoutRoute(route Route) *Graph {
+	from, okFrom := g.indexes[route.Boarding]
+	to, okTo := g.indexes[route.Destination]
+
+	if !okFrom || !okTo {
+		return g
+	}
+
+	b := newGraphBuilder(g)
+	b.removeEdge(from, to)
+
+	return b.graph
+}
+
+// WithoutAirport returns a new Graph where the airport is no longer indexed.
+// Its index is left without connections, so the indexes of the other airports do not
+// change, until it is reused by the next airport added.
+func (g *Graph) WithoutAirport(airport string) *Graph {
+	index, ok := g.indexes[airport]
+	if !ok {
+		return g
+	}
+
+	b := newGraphBuilder(g)
+	b.copyIndexes()
+	delete(b.graph.indexes, airport)
+	b.graph.airports[index] = ""
+	b.graph.adjacency[index] = []Edge{}
+	b.graph.free = append(b.graph.free, index)
+
+	for from := range b.graph.adjacency {
+		b.removeEdge(from, index)
+	}
+
+	return b.graph
+}
+
+// graphBuilder copies the parts of a Graph only when they are about to change.
+type graphBuilder struct {
+	graph       *Graph
+	ownAirports bool
+	ownRows     map[int]bool
+}
+
+func newGraphBuilder(g *Graph) *graphBuilder {
+	return &graphBuilder{
+		graph: &Graph{
+			airports:  g.airports,
+			indexes:   g.indexes,
+			adjacency: append(make([][]Edge, 0, len(g.adjacency)), g.adjacency...),
+			free:      g.free,
+		},
+		ownRows: make(map[int]bool),
+	}
+}
+
+func (b *graphBuilder) index(airport string) int {
+	if index, ok := b.graph.indexes[airport]; ok {
+		return index
+	}
+
+	b.copyIndexes()
+
+	if free := len(b.graph.free); free > 0 {
+		index := b.graph.free[free-1]
+		b.graph.free = b.graph.free[:free-1]
+		b.graph.indexes[airport] = index
+		b.graph.airports[index] = airport
+		b.graph.adjacency[index] = []Edge{}
+		b.ownRows[index] = true
+
+		return index
+	}
+
+	index := len(b.graph.airports)
+	b.graph.indexes[airport] = index
+	b.graph.airports = append(b.graph.airports, airport)
+	b.graph.adjacency = append(b.graph.adjacency, []Edge{})
+	b.ownRows[index] = true
+
+	return index
+}
+
+func (b *graphBuilder) copyIndexes() {
+	if b.ownAirports {
+		return
+	}
+
+	indexes := make(map[string]int, len(b.graph.indexes)+1)
+
+	for a, i := range b.graph.indexes {
+		indexes[a] = i
+	}
+
+	b.graph.indexes = indexes
+	b.graph.airports = append(make([]string, 0, len(b.graph.airports)+1), b.graph.airports...)
+	b.graph.free = append([]int{}, b.graph.free...)
+	b.ownAirports = true
+}
+
+func (b *graphBuilder) row(from int) []Edge {
+	if !b.ownRows[from] {
+		b.graph.adjacency[from] = append([]Edge{}, b.graph.adjacency[from]...)
+		b.ownRows[from] = true
+	}
+
+	return b.graph.adjacency[from]
+}
+
+func (b *graphBuilder) setEdge(from, to, cost int) {
+	edges := b.row(from)
+
+	for i := range edges {
+		if edges[i].To == to {
+			edges[i].Cost = cost
+			return
+		}
+	}
+
+	b.graph.adjacency[from] = append(edges, Edge{To: to, Cost: cost})
+}
+
+func (b *graphBuilder) removeEdge(from, to int) {
+	for i, e := range b.graph.adjacency[from] {
+		if e.To != to {
+			continue
+		}
+
+		edges := b.row(from)
+		b.graph.adjacency[from] = append(edges[:i], edges[i+1:]...)
+
+		return
+	}
+}
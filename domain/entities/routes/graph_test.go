@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestGraph(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for WithRoutes", func() {
+		g.It("should index every airport and its connections", func() {
+			graph := NewGraph().WithRoutes([]Route{
+				{Boarding: "GRU", Destination: "BRC", Cost: 10},
+				{Boarding: "BRC", Destination: "SCL", Cost: 5},
+				{Boarding: "GRU", Destination: "SCL", Cost: 20},
+			})
+
+			gru, okGRU := graph.Index("GRU")
+			brc, okBRC := graph.Index("BRC")
+			scl, okSCL := graph.Index("SCL")
+			_, okCDG := graph.Index("CDG")
+
+			g.Assert(graph.Size()).Equal(3)
+			g.Assert(okGRU && okBRC && okSCL).IsTrue()
+			g.Assert(okCDG).IsFalse()
+			g.Assert(graph.Airport(gru)).Equal("GRU")
+			g.Assert(graph.Edges(gru)).Equal([]Edge{{To: brc, Cost: 10}, {To: scl, Cost: 20}})
+			g.Assert(graph.Edges(brc)).Equal([]Edge{{To: scl, Cost: 5}})
+			g.Assert(graph.Edges(scl)).Equal([]Edge{})
+		})
+
+		g.It("should replace the cost of an existing connection", func() {
+			graph := NewGraph().WithRoute(Route{Boarding: "GRU", Destination: "BRC", Cost: 10})
+			updated := graph.WithRoute(Route{Boarding: "GRU", Destination: "BRC", Cost: 8})
+			gru, _ := updated.Index("GRU")
+			brc, _ := updated.Index("BRC")
+
+			g.Assert(updated.Edges(gru)).Equal([]Edge{{To: brc, Cost: 8}})
+		})
+	})
+
+	g.Describe("Tests for snapshots", func() {
+		g.It("should never change a previous snapshot", func() {
+			first := NewGraph().WithRoute(Route{Boarding: "GRU", Destination: "BRC", Cost: 10})
+			second := first.WithRoutes([]Route{
+				{Boarding: "GRU", Destination: "BRC", Cost: 8},
+				{Boarding: "GRU", Destination: "CDG", Cost: 75},
+			})
+			gru, _ := first.Index("GRU")
+			brc, _ := first.Index("BRC")
+			_, ok := first.Index("CDG")
+
+			g.Assert(ok).IsFalse()
+			g.Assert(first.Size()).Equal(2)
+			g.Assert(first.Edges(gru)).Equal([]Edge{{To: brc, Cost: 10}})
+			g.Assert(second.Size()).Equal(3)
+			g.Assert(len(second.Edges(gru))).Equal(2)
+		})
+	})
+
+	g.Describe("Tests for WithoutRoute and WithoutAirport", func() {
+		g.It("should remove a connection keeping the previous snapshot", func() {
+			graph := NewGraph().WithRoutes([]Route{
+				{Boarding: "GRU", Destination: "BRC", Cost: 10},
+				{Boarding: "GRU", Destination: "SCL", Cost: 20},
+			})
+			updated := graph.WithoutRoute(Route{Boarding: "GRU", Destination: "BRC"})
+			gru, _ := graph.Index("GRU")
+			scl, _ := graph.Index("SCL")
+
+			g.Assert(len(graph.Edges(gru))).Equal(2)
+			g.Assert(updated.Edges(gru)).Equal([]Edge{{To: scl, Cost: 20}})
+		})
+
+		g.It("should remove an airport and every connection to it without changing other indexes", func() {
+			graph := NewGraph().WithRoutes([]Route{
+				{Boarding: "GRU", Destination: "BRC", Cost: 10},
+				{Boarding: "BRC", Destination: "SCL", Cost: 5},
+				{Boarding: "GRU", Destination: "SCL", Cost: 20},
+			})
+			updated := graph.WithoutAirport("BRC")
+			gru, _ := updated.Index("GRU")
+			scl, _ := updated.Index("SCL")
+			_, ok := updated.Index("BRC")
+			_, okBefore := graph.Index("BRC")
+
+			g.Assert(ok).IsFalse()
+			g.Assert(okBefore).IsTrue()
+			g.Assert(updated.Size()).Equal(graph.Size())
+			g.Assert(updated.Airport(scl)).Equal("SCL")
+			g.Assert(updated.Edges(gru)).Equal([]Edge{{To: scl, Cost: 20}})
+		})
+
+		g.It("should reuse the indexes of removed airports without changing previous snapshots", func() {
+			graph := NewGraph().WithRoute(Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+
+			for i := 0; i < 5; i++ {
+				without := graph.WithoutAirport("GRU").WithoutAirport("CDG")
+				graph = without.WithRoute(Route{Boarding: "GRU", Destination: "CDG", Cost: 75 + i})
+
+				_, ok := without.Index("GRU")
+				g.Assert(ok).IsFalse()
+			}
+
+			gru, _ := graph.Index("GRU")
+			cdg, _ := graph.Index("CDG")
+
+			g.Assert(graph.Size()).Equal(2)
+			g.Assert(graph.Airports()).Equal(2)
+			g.Assert(graph.Airport(gru)).Equal("GRU")
+			g.Assert(graph.Edges(gru)).Equal([]Edge{{To: cdg, Cost: 79}})
+			g.Assert(graph.Edges(cdg)).Equal([]Edge{})
+		})
+	})
+}
@@ -13,6 +13,43 @@ type BestRoute struct {
 	Cost  int    `json:"cost"`
 }
 
+// RoutesPage is a page of a routes listing. NextCursor is empty on the last page.
+type RoutesPage struct {
+	Routes     []Route `json:"routes"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// ImportLine is a numbered line of an import. Route is nil when the line could not be read,
+// and Reason tells why a line was not accepted.
+type ImportLine struct {
+	Line   int    `json:"line"`
+	Route  *Route `json:"route,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ImportReport tells what happened to every line of an import. Committed is false when
+// nothing was stored because an all-or-nothing import had rejected lines.
+type ImportReport struct {
+	Committed bool         `json:"committed"`
+	Accepted  []ImportLine `json:"accepted"`
+	Duplicate []ImportLine `json:"duplicate"`
+	Rejected  []ImportLine `json:"rejected"`
+}
+
+// RouteChanges are the differences between two versions of the routes, e.g. when the routes file is reloaded.
+// Updated routes have their new cost.
+type RouteChanges struct {
+	Added   []Route `json:"added"`
+	Updated []Route `json:"updated"`
+	Removed []Route `json:"removed"`
+}
+
+// Empty tells whether there is no change at all.
+func (c RouteChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Updated) == 0 && len(c.Removed) == 0
+}
+
 // Connection ...
 type Connection struct {
 	Airport string
@@ -0,0 +1,52 @@
+package errors
+
+// Code identifies a kind of error. Unlike the messages, the codes do not change, so clients can rely on them.
+type Code string
+
+// The codes of the errors.
+const (
+	CodeRouteInvalid         Code = "ROUTE_INVALID"
+	CodeRouteAlreadyExists   Code = "ROUTE_ALREADY_EXISTS"
+	CodeRouteNotFound        Code = "ROUTE_NOT_FOUND"
+	CodeRouteUnreachable     Code = "ROUTE_UNREACHABLE"
+	CodeAirportInvalid       Code = "AIRPORT_INVALID"
+	CodeAirportNotRegistered Code = "AIRPORT_NOT_REGISTERED"
+	CodeAirportNotFound      Code = "AIRPORT_NOT_FOUND"
+	CodeParameterInvalid     Code = "PARAMETER_INVALID"
+	CodeImportRejected       Code = "IMPORT_REJECTED"
+	CodeImportTooLarge       Code = "IMPORT_TOO_LARGE"
+	CodeNotReady             Code = "NOT_READY"
+	CodeQueryTimeout         Code = "QUERY_TIMEOUT"
+	CodeQueryCanceled        Code = "QUERY_CANCELED"
+	CodeInternal             Code = "INTERNAL_ERROR"
+)
+
+// Coded is implemented by the errors that have a code.
+type Coded interface {
+	error
+	Code() Code
+}
+
+// Fielded is implemented by the errors caused by a single field of a request.
+type Fielded interface {
+	error
+	Field() string
+}
+
+// CodeOf returns the code of an error, or INTERNAL_ERROR when it has none.
+func CodeOf(err error) Code {
+	if coded, ok := err.(Coded); ok {
+		return coded.Code()
+	}
+
+	return CodeInternal
+}
+
+// FieldOf returns the field that caused an error, or an empty string when it is not known.
+func FieldOf(err error) string {
+	if fielded, ok := err.(Fielded); ok {
+		return fielded.Field()
+	}
+
+	return ""
+}
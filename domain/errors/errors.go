@@ -11,6 +11,10 @@ func (e *InvalidRouteErr) Error() string {
 	return e.message
 }
 
+func (e *InvalidRouteErr) Code() Code {
+	return CodeRouteInvalid
+}
+
 func NewInvalidRouteErr() *InvalidRouteErr {
 	return &InvalidRouteErr{
 		message: "invalid route format",
@@ -25,6 +29,10 @@ func (e *RouteAlreadyExistErr) Error() string {
 	return e.message
 }
 
+func (e *RouteAlreadyExistErr) Code() Code {
+	return CodeRouteAlreadyExists
+}
+
 func NewRouteAlreadyExistErr() *RouteAlreadyExistErr {
 	return &RouteAlreadyExistErr{
 		message: "route already created",
@@ -33,18 +41,50 @@ func NewRouteAlreadyExistErr() *RouteAlreadyExistErr {
 
 type InvalidAirportErr struct {
 	message string
+	code    Code
+	field   string
 }
 
 func (e *InvalidAirportErr) Error() string {
 	return e.message
 }
 
-func NewInvalidAirportErr(cause string) *InvalidAirportErr {
+// Code is AIRPORT_NOT_REGISTERED for airports that are not part of any route and AIRPORT_INVALID otherwise.
+func (e *InvalidAirportErr) Code() Code {
+	return e.code
+}
+
+// Field is the parameter holding the airport, when it is known.
+func (e *InvalidAirportErr) Field() string {
+	return e.field
+}
+
+// InField returns a copy of the error telling the parameter holding the airport.
+func (e *InvalidAirportErr) InField(field string) *InvalidAirportErr {
+	err := *e
+	err.field = field
+
+	return &err
+}
+
+func newInvalidAirportErr(cause string, code Code) *InvalidAirportErr {
 	return &InvalidAirportErr{
 		message: fmt.Sprintf("invalid airport: %s", cause),
+		code:    code,
 	}
 }
 
+// NewInvalidAirportErr is a constructor for InvalidAirportErr with the code AIRPORT_INVALID.
+func NewInvalidAirportErr(cause string) *InvalidAirportErr {
+	return newInvalidAirportErr(cause, CodeAirportInvalid)
+}
+
+// NewAirportNotRegisteredErr is a constructor for InvalidAirportErr with the code AIRPORT_NOT_REGISTERED,
+// for airports that are not part of any route.
+func NewAirportNotRegisteredErr() *InvalidAirportErr {
+	return newInvalidAirportErr("not registered", CodeAirportNotRegistered)
+}
+
 // RouteNotFoundErr is an error for when routes are not found when searched.
 type RouteNotFoundErr struct {
 	message string
@@ -54,6 +94,10 @@ func (e *RouteNotFoundErr) Error() string {
 	return e.message
 }
 
+func (e *RouteNotFoundErr) Code() Code {
+	return CodeRouteNotFound
+}
+
 // NewRouteNotFoundErr is aconstructor for RouteNotFoundErr.
 func NewRouteNotFoundErr() *RouteNotFoundErr {
 	return &RouteNotFoundErr{
@@ -70,9 +114,160 @@ func (e *BestRouteNotFoundErr) Error() string {
 	return e.message
 }
 
+func (e *BestRouteNotFoundErr) Code() Code {
+	return CodeRouteUnreachable
+}
+
 // NewBestRouteNotFoundErr is a constructor for BestRouteNotFoundErr.
 func NewBestRouteNotFoundErr() *BestRouteNotFoundErr {
 	return &BestRouteNotFoundErr{
 		message: "best route not found",
 	}
 }
+
+// InvalidParameterErr represents a malformed or out of range search parameter.
+type InvalidParameterErr struct {
+	message   string
+	parameter string
+}
+
+func (e *InvalidParameterErr) Error() string {
+	return e.message
+}
+
+func (e *InvalidParameterErr) Code() Code {
+	return CodeParameterInvalid
+}
+
+// Field is the invalid parameter.
+func (e *InvalidParameterErr) Field() string {
+	return e.parameter
+}
+
+// NewInvalidParameterErr is a constructor for InvalidParameterErr.
+func NewInvalidParameterErr(parameter string) *InvalidParameterErr {
+	return &InvalidParameterErr{
+		message:   fmt.Sprintf("invalid parameter: %s", parameter),
+		parameter: parameter,
+	}
+}
+
+// AirportNotFoundErr represents an airport that is neither registered nor in the reference data.
+type AirportNotFoundErr struct {
+	message string
+}
+
+func (e *AirportNotFoundErr) Error() string {
+	return e.message
+}
+
+func (e *AirportNotFoundErr) Code() Code {
+	return CodeAirportNotFound
+}
+
+// NewAirportNotFoundErr is a constructor for AirportNotFoundErr.
+func NewAirportNotFoundErr() *AirportNotFoundErr {
+	return &AirportNotFoundErr{
+		message: "airport not found",
+	}
+}
+
+// ImportRejectedErr represents an all-or-nothing import that was not stored because of rejected lines.
+type ImportRejectedErr struct {
+	message string
+}
+
+func (e *ImportRejectedErr) Error() string {
+	return e.message
+}
+
+func (e *ImportRejectedErr) Code() Code {
+	return CodeImportRejected
+}
+
+// NewImportRejectedErr is a constructor for ImportRejectedErr.
+func NewImportRejectedErr(rejected int) *ImportRejectedErr {
+	return &ImportRejectedErr{
+		message: fmt.Sprintf("import rejected: %d invalid lines", rejected),
+	}
+}
+
+// ImportTooLargeErr represents an import whose body is larger than the limit, of which nothing was stored.
+type ImportTooLargeErr struct {
+	message string
+}
+
+func (e *ImportTooLargeErr) Error() string {
+	return e.message
+}
+
+func (e *ImportTooLargeErr) Code() Code {
+	return CodeImportTooLarge
+}
+
+// NewImportTooLargeErr is a constructor for ImportTooLargeErr.
+func NewImportTooLargeErr(limit int64) *ImportTooLargeErr {
+	return &ImportTooLargeErr{
+		message: fmt.Sprintf("import too large: the body is larger than %d bytes", limit),
+	}
+}
+
+// NotReadyErr represents a request made before the application is ready to serve it.
+type NotReadyErr struct {
+	message string
+}
+
+func (e *NotReadyErr) Error() string {
+	return e.message
+}
+
+func (e *NotReadyErr) Code() Code {
+	return CodeNotReady
+}
+
+// NewNotReadyErr is a constructor for NotReadyErr.
+func NewNotReadyErr() *NotReadyErr {
+	return &NotReadyErr{
+		message: "not ready: the routes are being loaded",
+	}
+}
+
+// QueryTimeoutErr represents a search that did not finish within its budget.
+type QueryTimeoutErr struct {
+	message string
+}
+
+func (e *QueryTimeoutErr) Error() string {
+	return e.message
+}
+
+func (e *QueryTimeoutErr) Code() Code {
+	return CodeQueryTimeout
+}
+
+// NewQueryTimeoutErr is a constructor for QueryTimeoutErr.
+func NewQueryTimeoutErr() *QueryTimeoutErr {
+	return &QueryTimeoutErr{
+		message: "query timeout: the search took longer than its budget",
+	}
+}
+
+// QueryCanceledErr represents a search stopped because its caller gave up, e.g. a client that disconnected.
+type QueryCanceledErr struct {
+	message string
+}
+
+func (e *QueryCanceledErr) Error() string {
+	return e.message
+}
+
+func (e *QueryCanceledErr) Code() Code {
+	return CodeQueryCanceled
+}
+
+// NewQueryCanceledErr is a constructor for QueryCanceledErr.
+func NewQueryCanceledErr() *QueryCanceledErr {
+	return &QueryCanceledErr{
+		message: "query canceled",
+	}
+}
@@ -0,0 +1,48 @@
+package ports
+
+import (
+	"go-bestflight/domain/entities/airports"
+	r "go-bestflight/domain/entities/routes"
+)
+
+// RouteStore is the persistent storage of the routes, e.g. the database.
+type RouteStore interface {
+	StoreRoute(route r.Route) r.Route
+	StoreRoutes(routes []r.Route)
+	DeleteRoute(route r.Route)
+	GetRouteCost(boarding, destination string) (int, error)
+	GetAllRoutes() []r.Route
+	HasConnection(boarding string) bool
+	IsAirportInUse(airport string) bool
+}
+
+// AirportStore is the persistent storage of the registered airports and of their reference data.
+type AirportStore interface {
+	StoreAirport(airport string) string
+	GetAirport(airport string) bool
+	DeleteAirport(airport string)
+	GetAllAirports() []string
+	StoreAirportsDetails(details []airports.Airport)
+	GetAirportDetails(code string) (airports.Airport, bool)
+}
+
+// RouteGraphCache keeps the routes ready to be searched, including the routes graph snapshot.
+type RouteGraphCache interface {
+	AddRoute(route r.Route) r.Route
+	AddRoutes(routes []r.Route)
+	UpdateRoute(route r.Route) r.Route
+	DeleteRoute(route r.Route)
+	ApplyChanges(upserted, removed []r.Route)
+	GetAllRoutes() r.Routes
+	GetGraph() *r.Graph
+}
+
+// RouteFile is the source file of the routes, where every change must be persisted.
+type RouteFile interface {
+	Write(route r.Route) error
+	Update(route r.Route) error
+	Delete(route r.Route) error
+	WriteAll(routes []r.Route) error
+	DeleteAll(routes []r.Route) error
+	ReadFile() ([]r.Route, error)
+}
@@ -0,0 +1,80 @@
+package airportservice
+
+import (
+	"go-bestflight/domain/entities/airports"
+	e "go-bestflight/domain/errors"
+	validation "go-bestflight/domain/services/validationservice"
+	"go-bestflight/resources/logger"
+	"go-bestflight/resources/repositories/airportrepository"
+	"strings"
+)
+
+// AirportService holds the business rules for the airports and their reference data.
+type AirportService struct {
+	airports *airportrepository.AirportRepository
+}
+
+// New is a constructor for an AirportService over the given repository.
+func New(airports *airportrepository.AirportRepository) *AirportService {
+	return &AirportService{
+		airports: airports,
+	}
+}
+
+// Default returns an AirportService over the resources shared by the application.
+func Default() *AirportService {
+	return New(airportrepository.Default())
+}
+
+// LoadAirports stores the reference data of the airports with a valid code and returns how many were stored.
+func (s *AirportService) LoadAirports(details []airports.Airport) int {
+	valid := make([]airports.Airport, 0, len(details))
+
+	for _, airport := range details {
+		airport.Code = strings.ToUpper(airport.Code)
+
+		if !validation.IsValidAirport(airport.Code) {
+			logger.Warn("invalid airport code", "code", airport.Code)
+			continue
+		}
+
+		valid = append(valid, airport)
+	}
+
+	s.airports.StoreAirportsDetails(valid)
+
+	return len(valid)
+}
+
+// GetAirport returns an airport with its reference data.
+func (s *AirportService) GetAirport(code string) (airports.Airport, error) {
+	code = strings.ToUpper(code)
+
+	if !validation.IsValidAirport(code) {
+		return airports.Airport{}, e.NewInvalidAirportErr("malformed").InField("code")
+	}
+
+	return s.airports.GetAirport(code)
+}
+
+// GetAirports returns every airport registered by routes, sorted by code.
+func (s *AirportService) GetAirports() []airports.Airport {
+	return s.airports.GetRegisteredAirports()
+}
+
+// The functions below use the resources shared by the application.
+
+// LoadAirports stores the reference data of the airports with a valid code and returns how many were stored.
+func LoadAirports(details []airports.Airport) int {
+	return Default().LoadAirports(details)
+}
+
+// GetAirport returns an airport with its reference data.
+func GetAirport(code string) (airports.Airport, error) {
+	return Default().GetAirport(code)
+}
+
+// GetAirports returns every airport registered by routes, sorted by code.
+func GetAirports() []airports.Airport {
+	return Default().GetAirports()
+}
@@ -0,0 +1,125 @@
+package routeservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-bestflight/domain/entities/airports"
+	r "go-bestflight/domain/entities/routes"
+	e "go-bestflight/domain/errors"
+	"go-bestflight/resources/file"
+	"go-bestflight/resources/tracing"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The formats the routes can be exported to.
+const (
+	ExportCSV     = "csv"
+	ExportJSON    = "json"
+	ExportDOT     = "dot"
+	ExportGeoJSON = "geojson"
+)
+
+type geometry struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+type feature struct {
+	Type       string   `json:"type"`
+	Geometry   geometry `json:"geometry"`
+	Properties r.Route  `json:"properties"`
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+// Export writes every stored route, sorted by boarding and destination, in the given format:
+// a CSV in the format of the routes file, a JSON array of routes, a Graphviz digraph with the
+// costs as edge labels or a GeoJSON FeatureCollection of LineStrings. Routes between airports
+// without known coordinates are left out of the GeoJSON.
+// It fails with InvalidParameterErr for an unknown format, before writing anything.
+func (s *RouteService) Export(ctx context.Context, writer io.Writer, format string) error {
+	_, span := tracing.Start(ctx, "export", "format", format)
+	defer span.End()
+
+	routes := s.routes.GetAllRoutes()
+	sort.Slice(routes, func(i, j int) bool { return compareAirports(routes[i], routes[j]) < 0 })
+
+	switch strings.ToLower(format) {
+	case ExportCSV:
+		return file.WriteRoutes(writer, routes)
+	case ExportJSON:
+		return json.NewEncoder(writer).Encode(routes)
+	case ExportDOT:
+		return writeDOT(writer, routes)
+	case ExportGeoJSON:
+		return json.NewEncoder(writer).Encode(s.featureCollection(routes))
+	default:
+		return e.NewInvalidParameterErr("format")
+	}
+}
+
+func writeDOT(writer io.Writer, routes []r.Route) error {
+	var builder strings.Builder
+
+	builder.WriteString("digraph routes {\n")
+
+	for _, route := range routes {
+		fmt.Fprintf(&builder, "\t%q -> %q [label=\"%d\"];\n", route.Boarding, route.Destination, route.Cost)
+	}
+
+	builder.WriteString("}\n")
+
+	_, err := io.WriteString(writer, builder.String())
+
+	return err
+}
+
+func (s *RouteService) featureCollection(routes []r.Route) featureCollection {
+	collection := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	known := make(map[string]airports.Airport)
+
+	coordinates := func(code string) ([2]float64, bool) {
+		airport, ok := known[code]
+		if !ok {
+			airport, _ = s.airports.GetAirport(code)
+			known[code] = airport
+		}
+
+		if !airport.HasCoordinates() {
+			return [2]float64{}, false
+		}
+
+		return [2]float64{*airport.Longitude, *airport.Latitude}, true
+	}
+
+	for _, route := range routes {
+		from, ok := coordinates(route.Boarding)
+		if !ok {
+			continue
+		}
+
+		to, ok := coordinates(route.Destination)
+		if !ok {
+			continue
+		}
+
+		collection.Features = append(collection.Features, feature{
+			Type:       "Feature",
+			Geometry:   geometry{Type: "LineString", Coordinates: [][2]float64{from, to}},
+			Properties: route,
+		})
+	}
+
+	return collection
+}
+
+// Export writes every route stored in the shared database in the given format.
+func Export(ctx context.Context, writer io.Writer, format string) error {
+	return Default().Export(ctx, writer, format)
+}
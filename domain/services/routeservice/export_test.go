@@ -0,0 +1,115 @@
+package routeservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-bestflight/domain/entities/airports"
+	r "go-bestflight/domain/entities/routes"
+	e "go-bestflight/domain/errors"
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/database"
+	"go-bestflight/resources/file"
+	"go-bestflight/resources/repositories/airportrepository"
+	"go-bestflight/resources/repositories/routerepository"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestExport(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	db := database.New()
+	db.StoreRoutes([]r.Route{
+		{Boarding: "GRU", Destination: "CDG", Cost: 75},
+		{Boarding: "BRC", Destination: "GRU", Cost: 10},
+		{Boarding: "GRU", Destination: "BRC", Cost: 10},
+	})
+	db.StoreAirportsDetails([]airports.Airport{
+		airports.Airport{Code: "GRU"}.At(-23.43, -46.47),
+		airports.Airport{Code: "CDG"}.At(49.01, 2.55),
+	})
+
+	service := New(routerepository.New(db, db, cache.New(), nil), airportrepository.New(db))
+
+	g.Describe("Tests for Export", func() {
+		g.It("should export a CSV that can be read as a routes file", func() {
+			var out bytes.Buffer
+
+			g.Assert(service.Export(context.Background(), &out, "csv")).Equal(nil)
+			g.Assert(out.String()).Equal("BRC,GRU,10\nGRU,BRC,10\nGRU,CDG,75\n")
+
+			lines, _ := file.ParseRoutes(&out)
+			g.Assert(len(lines)).Equal(3)
+			g.Assert(*lines[2].Route).Equal(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+		})
+
+		g.It("should export a JSON array of routes", func() {
+			var out bytes.Buffer
+			var routes []r.Route
+
+			g.Assert(service.Export(context.Background(), &out, "JSON")).Equal(nil)
+
+			json.Unmarshal(out.Bytes(), &routes)
+			g.Assert(routes[0]).Equal(r.Route{Boarding: "BRC", Destination: "GRU", Cost: 10})
+			g.Assert(len(routes)).Equal(3)
+		})
+
+		g.It("should export a Graphviz digraph with the costs as labels", func() {
+			var out bytes.Buffer
+
+			g.Assert(service.Export(context.Background(), &out, "dot")).Equal(nil)
+			g.Assert(out.String()).Equal("digraph routes {\n" +
+				"\t\"BRC\" -> \"GRU\" [label=\"10\"];\n" +
+				"\t\"GRU\" -> \"BRC\" [label=\"10\"];\n" +
+				"\t\"GRU\" -> \"CDG\" [label=\"75\"];\n" +
+				"}\n")
+		})
+
+		g.It("should export only the routes with known coordinates to GeoJSON", func() {
+			var out bytes.Buffer
+			var collection featureCollection
+
+			g.Assert(service.Export(context.Background(), &out, "geojson")).Equal(nil)
+
+			json.Unmarshal(out.Bytes(), &collection)
+			g.Assert(collection.Type).Equal("FeatureCollection")
+			g.Assert(len(collection.Features)).Equal(1)
+			g.Assert(collection.Features[0].Geometry).Equal(geometry{
+				Type:        "LineString",
+				Coordinates: [][2]float64{{-46.47, -23.43}, {2.55, 49.01}},
+			})
+			g.Assert(collection.Features[0].Properties.Cost).Equal(75)
+		})
+
+		g.It("should export the routes of airports at 0 degrees", func() {
+			db := database.New()
+			db.StoreRoutes([]r.Route{{Boarding: "LOS", Destination: "ACC", Cost: 30}})
+			db.StoreAirportsDetails([]airports.Airport{
+				airports.Airport{Code: "LOS"}.At(6.58, 3.32),
+				airports.Airport{Code: "ACC"}.At(5.6, 0),
+			})
+
+			service := New(routerepository.New(db, db, cache.New(), nil), airportrepository.New(db))
+
+			var (
+				out        bytes.Buffer
+				collection featureCollection
+			)
+
+			g.Assert(service.Export(context.Background(), &out, "geojson")).Equal(nil)
+
+			json.Unmarshal(out.Bytes(), &collection)
+			g.Assert(len(collection.Features)).Equal(1)
+			g.Assert(collection.Features[0].Geometry.Coordinates).Equal([][2]float64{{3.32, 6.58}, {0, 5.6}})
+		})
+
+		g.It("should fail with InvalidParameterErr for an unknown format without writing", func() {
+			var out bytes.Buffer
+
+			g.Assert(service.Export(context.Background(), &out, "xml")).Equal(e.NewInvalidParameterErr("format"))
+			g.Assert(out.Len()).Equal(0)
+		})
+	})
+}
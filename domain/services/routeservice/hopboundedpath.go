@@ -0,0 +1,95 @@
+package routeservice
+
+import "context"
+
+func reconstructHopBoundedRoute(start, end int, previous [][]int) []int {
+	route := []int{end}
+	node := end
+
+	for hops := len(previous) - 1; node != start && hops > 0; hops-- {
+		if previous[hops][node] == -1 {
+			continue
+		}
+
+		node = previous[hops][node]
+		route = append(route, node)
+	}
+
+	return reverseRoute(route)
+}
+
+// hopBoundedLayers relaxes the edges one layer at a time, as in the Bellman-Ford algorithm,
+// so the layer h holds the best distances using up to h connections. The layers stop
+// as soon as one of them brings no improvement, or with the error of ctx once it is canceled.
+func hopBoundedLayers(ctx context.Context, args dijkstraArgs) ([][]int, [][]int, error) {
+	distances := make([][]int, 1, args.maxEdges+1)
+	previous := make([][]int, 1, args.maxEdges+1)
+
+	distances[0], previous[0] = newDistances(len(args.dist))
+	distances[0][args.start] = 0
+	relaxed := 0
+
+	for hops := 1; hops <= args.maxEdges; hops++ {
+		last := distances[hops-1]
+		current := append([]int{}, last...)
+		_, prev := newDistances(len(args.dist))
+		improved := false
+
+		for node, distance := range last {
+			if distance == maxInt || args.removedNodes[node] && node != args.start {
+				continue
+			}
+
+			if err := checkContext(ctx, relaxed); err != nil {
+				return nil, nil, err
+			}
+
+			relaxed++
+			args.expand()
+
+			for _, destination := range args.g.Edges(node) {
+				destinationNode := destination.To
+
+				if args.removedNodes[destinationNode] ||
+					args.removedEdges[edge{from: node, to: destinationNode}] {
+					continue
+				}
+
+				newDistance := distance + destination.Cost
+
+				if newDistance >= current[destinationNode] {
+					continue
+				}
+
+				current[destinationNode] = newDistance
+				prev[destinationNode] = node
+				improved = true
+			}
+		}
+
+		if !improved {
+			break
+		}
+
+		distances = append(distances, current)
+		previous = append(previous, prev)
+	}
+
+	return distances, previous, nil
+}
+
+// HopBoundedSTP finds the shortest path using at most args.maxEdges connections.
+func HopBoundedSTP(ctx context.Context, args dijkstraArgs) ([]int, int, error) {
+	distances, previous, err := hopBoundedLayers(ctx, args)
+	if err != nil {
+		return []int{}, -1, err
+	}
+
+	cost := distances[len(distances)-1][args.end]
+
+	if cost == maxInt {
+		return []int{}, -1, nil
+	}
+
+	return reconstructHopBoundedRoute(args.start, args.end, previous), cost, nil
+}
@@ -0,0 +1,68 @@
+package routeservice
+
+import (
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestHopBoundedPath(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	graph := r.NewGraph().WithRoutes(testRoutes)
+
+	newArgs := func(start, end string, maxEdges int) dijkstraArgs {
+		args := newTestArgs(graph, start, end)
+		args.maxEdges = maxEdges
+
+		return args
+	}
+
+	g.Describe("Tests for HopBoundedSTP", func() {
+		g.It("should retrieve the shortest path for every limit of connections", func() {
+			expected := []struct {
+				route string
+				cost  int
+			}{
+				{route: "GRU - CDG", cost: 75},
+				{route: "GRU - ORL - CDG", cost: 61},
+				{route: "GRU - SCL - ORL - CDG", cost: 45},
+				{route: "GRU - BRC - SCL - ORL - CDG", cost: 40},
+				{route: "GRU - BRC - SCL - ORL - CDG", cost: 40},
+			}
+
+			for i, e := range expected {
+				bestRoute, cost, _ := HopBoundedSTP(context.Background(), newArgs("GRU", "CDG", i+1))
+
+				g.Assert(convertRouteToNamed(bestRoute, graph)).Equal(e.route)
+				g.Assert(cost).Equal(e.cost)
+			}
+		})
+
+		g.It("should retrieve cost equal -1 when no path fits the limit", func() {
+			bestRoute, cost, _ := HopBoundedSTP(context.Background(), newArgs("BRC", "CDG", 2))
+
+			g.Assert(bestRoute).Equal([]int{})
+			g.Assert(cost).Equal(-1)
+		})
+
+		g.It("should retrieve cost equal -1 for unreachable connection", func() {
+			bestRoute, cost, _ := HopBoundedSTP(context.Background(), newArgs("CDG", "GRU", 4))
+
+			g.Assert(bestRoute).Equal([]int{})
+			g.Assert(cost).Equal(-1)
+		})
+
+		g.It("should retrieve the k shortest paths within the limit with YenKSP", func() {
+			paths, _ := YenKSP(context.Background(), newArgs("GRU", "CDG", 2), 3)
+
+			g.Assert(len(paths)).Equal(2)
+			g.Assert(convertRouteToNamed(paths[0].nodes, graph)).Equal("GRU - ORL - CDG")
+			g.Assert(paths[0].cost).Equal(61)
+			g.Assert(convertRouteToNamed(paths[1].nodes, graph)).Equal("GRU - CDG")
+			g.Assert(paths[1].cost).Equal(75)
+		})
+	})
+}
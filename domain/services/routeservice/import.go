@@ -0,0 +1,204 @@
+package routeservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	r "go-bestflight/domain/entities/routes"
+	e "go-bestflight/domain/errors"
+	validation "go-bestflight/domain/services/validationservice"
+	"go-bestflight/resources/file"
+	"go-bestflight/resources/tracing"
+	"io"
+	"sort"
+	"strings"
+)
+
+// invalidRouteReason tells which part of a route makes it invalid.
+func invalidRouteReason(route r.Route) string {
+	if !validation.IsValidAirport(route.Boarding) {
+		return "invalid boarding airport"
+	}
+
+	if !validation.IsValidAirport(route.Destination) {
+		return "invalid destination airport"
+	}
+
+	return "invalid cost"
+}
+
+// classify sorts the lines of an import into accepted, duplicate and rejected ones.
+// A route is a duplicate when it is already stored or when an earlier line has the same airports.
+func (s *RouteService) classify(lines []r.ImportLine) r.ImportReport {
+	report := r.ImportReport{
+		Accepted:  []r.ImportLine{},
+		Duplicate: []r.ImportLine{},
+		Rejected:  []r.ImportLine{},
+	}
+	firstLine := make(map[string]int)
+
+	for _, line := range lines {
+		if line.Route == nil {
+			report.Rejected = append(report.Rejected, line)
+			continue
+		}
+
+		route := r.Route{
+			Boarding:    strings.ToUpper(line.Route.Boarding),
+			Destination: strings.ToUpper(line.Route.Destination),
+			Cost:        line.Route.Cost,
+		}
+		line.Route = &route
+		key := route.Boarding + "-" + route.Destination
+
+		if !validation.IsValidRoute(route) {
+			line.Reason = invalidRouteReason(route)
+			report.Rejected = append(report.Rejected, line)
+			continue
+		}
+
+		if first, ok := firstLine[key]; ok {
+			line.Reason = fmt.Sprintf("duplicate of line %d", first)
+			report.Duplicate = append(report.Duplicate, line)
+			continue
+		}
+
+		if s.routes.RouteExists(route.Boarding, route.Destination) {
+			line.Reason = "route already created"
+			report.Duplicate = append(report.Duplicate, line)
+			continue
+		}
+
+		firstLine[key] = line.Line
+		report.Accepted = append(report.Accepted, line)
+	}
+
+	return report
+}
+
+func numberedLines(routes []r.Route) []r.ImportLine {
+	lines := make([]r.ImportLine, len(routes))
+
+	for i := range routes {
+		route := routes[i]
+		lines[i] = r.ImportLine{Line: i + 1, Route: &route}
+	}
+
+	return lines
+}
+
+func acceptedRoutes(report r.ImportReport) []r.Route {
+	routes := make([]r.Route, len(report.Accepted))
+
+	for i, line := range report.Accepted {
+		routes[i] = *line.Route
+	}
+
+	return routes
+}
+
+// importLines stores the accepted lines. In all-or-nothing mode nothing is stored when there is
+// any rejected line, and the accepted routes are stored at once. Otherwise the accepted routes are
+// stored at once as well, but the ones stored by someone else in the meantime are reported as
+// duplicates instead of failing the import, and all of them are reported as rejected when they
+// can not be stored.
+func (s *RouteService) importLines(ctx context.Context, lines []r.ImportLine, atomic bool) (r.ImportReport, error) {
+	report := s.classify(lines)
+
+	if atomic {
+		if len(report.Rejected) > 0 {
+			return report, nil
+		}
+
+		err := s.routes.StoreRoutes(ctx, acceptedRoutes(report))
+		if err != nil {
+			return report, errors.New("could not create resource")
+		}
+
+		report.Committed = true
+
+		return report, nil
+	}
+
+	skipped, err := s.routes.StoreNewRoutes(ctx, acceptedRoutes(report))
+	if err != nil {
+		for _, line := range report.Accepted {
+			line.Reason = "could not create resource"
+			report.Rejected = append(report.Rejected, line)
+		}
+
+		sort.Slice(report.Rejected, func(i, j int) bool { return report.Rejected[i].Line < report.Rejected[j].Line })
+
+		report.Accepted = []r.ImportLine{}
+	}
+
+	if len(skipped) > 0 {
+		isSkipped := make(map[string]bool, len(skipped))
+
+		for _, route := range skipped {
+			isSkipped[route.Boarding+"-"+route.Destination] = true
+		}
+
+		accepted := []r.ImportLine{}
+
+		for _, line := range report.Accepted {
+			if isSkipped[line.Route.Boarding+"-"+line.Route.Destination] {
+				line.Reason = "route already created"
+				report.Duplicate = append(report.Duplicate, line)
+				continue
+			}
+
+			accepted = append(accepted, line)
+		}
+
+		sort.Slice(report.Duplicate, func(i, j int) bool { return report.Duplicate[i].Line < report.Duplicate[j].Line })
+
+		report.Accepted = accepted
+	}
+
+	report.Committed = true
+
+	return report, nil
+}
+
+// ImportRoutes stores a batch of routes, numbered from 1 in the given order, and reports what
+// happened to each of them. See importLines for the all-or-nothing mode.
+func (s *RouteService) ImportRoutes(ctx context.Context, routes []r.Route, atomic bool) (r.ImportReport, error) {
+	return s.importLines(ctx, numberedLines(routes), atomic)
+}
+
+// ImportCSV stores the routes read from a CSV in the format of the routes file and reports
+// what happened to each of its lines. See importLines for the all-or-nothing mode.
+// It fails with InvalidParameterErr when the CSV can not be read, e.g. when it is too large.
+func (s *RouteService) ImportCSV(ctx context.Context, reader io.Reader, atomic bool) (r.ImportReport, error) {
+	lines, err := file.ParseRoutes(reader)
+	if err != nil {
+		return r.ImportReport{}, e.NewInvalidParameterErr("body")
+	}
+
+	return s.importLines(ctx, lines, atomic)
+}
+
+// ValidateCSV reports what importing a CSV in the format of the routes file would do, without storing anything.
+// It fails with InvalidParameterErr when the CSV can not be read.
+func (s *RouteService) ValidateCSV(ctx context.Context, reader io.Reader) (r.ImportReport, error) {
+	_, span := tracing.Start(ctx, "validate")
+	defer span.End()
+
+	lines, err := file.ParseRoutes(reader)
+	if err != nil {
+		return r.ImportReport{}, e.NewInvalidParameterErr("body")
+	}
+
+	return s.classify(lines), nil
+}
+
+// ImportRoutes stores a batch of routes in the shared resources and reports what happened to each of them.
+func ImportRoutes(ctx context.Context, routes []r.Route, atomic bool) (r.ImportReport, error) {
+	return Default().ImportRoutes(ctx, routes, atomic)
+}
+
+// ImportCSV stores the routes read from a CSV in the shared resources and reports what happened to each line.
+func ImportCSV(ctx context.Context, reader io.Reader, atomic bool) (r.ImportReport, error) {
+	return Default().ImportCSV(ctx, reader, atomic)
+}
@@ -0,0 +1,106 @@
+package routeservice
+
+import (
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestImportRoutes(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	var service *RouteService
+
+	g.Describe("Tests for ImportCSV", func() {
+		g.BeforeEach(func() {
+			service = newIsolatedService(t, "import.csv")
+			service.AddNewRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+		})
+
+		g.It("should report accepted, duplicate and rejected lines with their reasons", func() {
+			csv := "gru,brc,10\nGRU,CDG,70\nBRC,SCL,5\nGRU,BRC,12\nGR,SCL,5\nGRU,ORL,0\nGRU;ORL;5\n"
+
+			report, err := service.ImportCSV(context.Background(), strings.NewReader(csv), false)
+
+			g.Assert(err).Equal(nil)
+			g.Assert(report.Committed).IsTrue()
+			g.Assert(report.Accepted).Equal([]r.ImportLine{
+				{Line: 1, Route: &r.Route{Boarding: "GRU", Destination: "BRC", Cost: 10}},
+				{Line: 3, Route: &r.Route{Boarding: "BRC", Destination: "SCL", Cost: 5}},
+			})
+			g.Assert(report.Duplicate).Equal([]r.ImportLine{
+				{Line: 2, Route: &r.Route{Boarding: "GRU", Destination: "CDG", Cost: 70}, Reason: "route already created"},
+				{Line: 4, Route: &r.Route{Boarding: "GRU", Destination: "BRC", Cost: 12}, Reason: "duplicate of line 1"},
+			})
+			g.Assert(report.Rejected).Equal([]r.ImportLine{
+				{Line: 5, Route: &r.Route{Boarding: "GR", Destination: "SCL", Cost: 5}, Reason: "invalid boarding airport"},
+				{Line: 6, Route: &r.Route{Boarding: "GRU", Destination: "ORL", Cost: 0}, Reason: "invalid cost"},
+				{Line: 7, Reason: "invalid line format"},
+			})
+
+			best, _ := service.GetBestRoute(context.Background(), "GRU", "SCL")
+			g.Assert(best.Cost).Equal(15)
+		})
+
+		g.It("should store nothing in all-or-nothing mode when a line is rejected", func() {
+			csv := "GRU,BRC,10\nBRC,SCL,0\n"
+
+			report, err := service.ImportCSV(context.Background(), strings.NewReader(csv), true)
+
+			g.Assert(err).Equal(nil)
+			g.Assert(report.Committed).IsFalse()
+			g.Assert(len(report.Accepted)).Equal(1)
+			g.Assert(service.routes.RouteExists("GRU", "BRC")).IsFalse()
+		})
+
+		g.It("should store every accepted route in all-or-nothing mode", func() {
+			csv := "GRU,BRC,10\nBRC,SCL,5\nGRU,CDG,70\n"
+
+			report, err := service.ImportCSV(context.Background(), strings.NewReader(csv), true)
+
+			g.Assert(err).Equal(nil)
+			g.Assert(report.Committed).IsTrue()
+			g.Assert(len(report.Duplicate)).Equal(1)
+			g.Assert(service.routes.RouteExists("GRU", "BRC")).IsTrue()
+			g.Assert(service.routes.RouteExists("BRC", "SCL")).IsTrue()
+		})
+	})
+
+	g.Describe("Tests for ImportRoutes", func() {
+		g.BeforeEach(func() {
+			service = newIsolatedService(t, "import.csv")
+		})
+
+		g.It("should number the routes from 1", func() {
+			report, _ := service.ImportRoutes(context.Background(), []r.Route{
+				{Boarding: "GRU", Destination: "CDG", Cost: 75},
+				{Boarding: "GRU", Destination: "CDG", Cost: 70},
+			}, false)
+
+			g.Assert(report.Accepted[0].Line).Equal(1)
+			g.Assert(report.Duplicate[0].Reason).Equal("duplicate of line 1")
+		})
+	})
+
+	g.Describe("Tests for LoadRoutes report", func() {
+		g.BeforeEach(func() {
+			service = newIsolatedService(t, "import.csv")
+		})
+
+		g.It("should report the lines that were not loaded", func() {
+			report := service.LoadRoutes(context.Background(), []r.Route{
+				{Boarding: "GRU", Destination: "CDG", Cost: 75},
+				{Boarding: "GRU", Destination: "CDG", Cost: 70},
+				{Boarding: "GRU", Destination: "SCL", Cost: 0},
+			})
+
+			g.Assert(report.Committed).IsTrue()
+			g.Assert(len(report.Accepted)).Equal(1)
+			g.Assert(report.Duplicate[0].Line).Equal(2)
+			g.Assert(report.Rejected[0].Line).Equal(3)
+		})
+	})
+}
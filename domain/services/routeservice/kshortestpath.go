@@ -0,0 +1,206 @@
+package routeservice
+
+import (
+	"context"
+	"fmt"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/errors"
+	"go-bestflight/resources/tracing"
+	"sort"
+	"time"
+)
+
+type path struct {
+	nodes []int
+	cost  int
+}
+
+func pathKey(nodes []int) string {
+	return fmt.Sprint(nodes)
+}
+
+func hasPrefix(nodes, prefix []int) bool {
+	if len(nodes) < len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if nodes[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func edgeCost(from, to int, g *r.Graph) int {
+	for _, destination := range g.Edges(from) {
+		if destination.To == to {
+			return destination.Cost
+		}
+	}
+
+	return -1
+}
+
+func pathCost(nodes []int, g *r.Graph) int {
+	cost := 0
+
+	for i := 0; i < len(nodes)-1; i++ {
+		cost += edgeCost(nodes[i], nodes[i+1], g)
+	}
+
+	return cost
+}
+
+// YenKSP implements the Yen's algorithm for finding the k shortest loopless paths.
+// Every spur path is searched over the same graph, removing the edges already used
+// by the found paths sharing the same root and the nodes of the root. It stops with
+// the error of ctx once ctx is canceled.
+func YenKSP(ctx context.Context, args dijkstraArgs, k int) ([]path, error) {
+	firstRoute, firstCost, err := shortestPath(ctx, args)
+	if err != nil {
+		return []path{}, err
+	}
+
+	if firstCost == maxInt || firstCost == -1 {
+		return []path{}, nil
+	}
+
+	found := []path{{nodes: firstRoute, cost: firstCost}}
+	candidates := []path{}
+	seen := map[string]bool{pathKey(firstRoute): true}
+
+	for len(found) < k {
+		lastPath := found[len(found)-1].nodes
+
+		for i := 0; i < len(lastPath)-1; i++ {
+			spurNode := lastPath[i]
+			rootPath := lastPath[:i+1]
+			maxEdges := 0
+
+			if args.maxEdges > 0 {
+				maxEdges = args.maxEdges - i
+
+				if maxEdges < 1 {
+					break
+				}
+			}
+
+			removedEdges := make(map[edge]bool)
+			removedNodes := make(map[int]bool)
+
+			for e := range args.removedEdges {
+				removedEdges[e] = true
+			}
+
+			for node := range args.removedNodes {
+				removedNodes[node] = true
+			}
+
+			for _, p := range found {
+				if len(p.nodes) > i+1 && hasPrefix(p.nodes, rootPath) {
+					removedEdges[edge{from: p.nodes[i], to: p.nodes[i+1]}] = true
+				}
+			}
+
+			for _, node := range rootPath[:i] {
+				removedNodes[node] = true
+			}
+
+			dist, prev := newDistances(len(args.dist))
+			spurRoute, spurCost, err := shortestPath(ctx, dijkstraArgs{
+				start:        spurNode,
+				end:          args.end,
+				dist:         dist,
+				prev:         prev,
+				g:            args.g,
+				removedNodes: removedNodes,
+				removedEdges: removedEdges,
+				maxEdges:     maxEdges,
+				expanded:     args.expanded,
+			})
+			if err != nil {
+				return []path{}, err
+			}
+
+			if spurCost == maxInt || spurCost == -1 {
+				continue
+			}
+
+			totalRoute := append(append([]int{}, rootPath[:i]...), spurRoute...)
+			key := pathKey(totalRoute)
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			candidates = append(candidates, path{
+				nodes: totalRoute,
+				cost:  pathCost(rootPath, args.g) + spurCost,
+			})
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].cost == candidates[j].cost {
+				return len(candidates[i].nodes) < len(candidates[j].nodes)
+			}
+
+			return candidates[i].cost < candidates[j].cost
+		})
+
+		found = append(found, candidates[0])
+		candidates = candidates[1:]
+	}
+
+	return found, nil
+}
+
+func findBestRoutes(ctx context.Context, g *r.Graph, boarding, destination string, k int, opts searchOptions) ([]r.BestRoute, error) {
+	ctx, span := tracing.Start(ctx, "search", "board", boarding, "dest", destination, "alternatives", k)
+	defer span.End()
+
+	start, okStart := g.Index(boarding)
+	end, okEnd := g.Index(destination)
+
+	if !okStart || !okEnd {
+		return []r.BestRoute{}, errors.NewBestRouteNotFoundErr()
+	}
+
+	args := newDijkstraArgs(g, start, end)
+
+	if err := opts.apply(&args); err != nil {
+		return []r.BestRoute{}, err
+	}
+
+	searchStart := time.Now()
+	paths, err := YenKSP(ctx, args, k)
+	observeSearch(ctx, yenAlgorithm, args, searchStart)
+
+	if err != nil {
+		err = searchErr(err)
+		span.RecordError(err)
+
+		return []r.BestRoute{}, err
+	}
+
+	if len(paths) == 0 {
+		return []r.BestRoute{}, errors.NewBestRouteNotFoundErr()
+	}
+
+	bestRoutes := make([]r.BestRoute, len(paths))
+
+	for i, p := range paths {
+		bestRoutes[i] = r.BestRoute{
+			Route: convertRouteToNamed(p.nodes, g),
+			Cost:  p.cost,
+		}
+	}
+
+	return bestRoutes, nil
+}
@@ -0,0 +1,79 @@
+package routeservice
+
+import (
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	e "go-bestflight/domain/errors"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+)
+
+func TestKShortestPath(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	graph := r.NewGraph().WithRoutes(testRoutes)
+
+	g.Describe("Tests for pathCost", func() {
+		g.It("should sum the cost of every connection of a path", func() {
+			nodes := []int{
+				indexOf(graph, "GRU"),
+				indexOf(graph, "SCL"),
+				indexOf(graph, "ORL"),
+			}
+
+			g.Assert(pathCost(nodes, graph)).Equal(40)
+			g.Assert(pathCost(nodes[:1], graph)).Equal(0)
+		})
+	})
+
+	g.Describe("Tests for YenKSP", func() {
+		g.It("should retrieve the k shortest paths in cost order", func() {
+			paths, _ := YenKSP(context.Background(), newTestArgs(graph, "GRU", "CDG"), 3)
+
+			g.Assert(len(paths)).Equal(3)
+			g.Assert(convertRouteToNamed(paths[0].nodes, graph)).Equal("GRU - BRC - SCL - ORL - CDG")
+			g.Assert(paths[0].cost).Equal(40)
+			g.Assert(convertRouteToNamed(paths[1].nodes, graph)).Equal("GRU - SCL - ORL - CDG")
+			g.Assert(paths[1].cost).Equal(45)
+			g.Assert(convertRouteToNamed(paths[2].nodes, graph)).Equal("GRU - ORL - CDG")
+			g.Assert(paths[2].cost).Equal(61)
+		})
+
+		g.It("should retrieve only the existing paths when k is greater than them", func() {
+			paths, _ := YenKSP(context.Background(), newTestArgs(graph, "GRU", "CDG"), 10)
+
+			g.Assert(len(paths)).Equal(4)
+			g.Assert(convertRouteToNamed(paths[3].nodes, graph)).Equal("GRU - CDG")
+			g.Assert(paths[3].cost).Equal(75)
+		})
+
+		g.It("should retrieve no paths for an unreachable connection", func() {
+			paths, _ := YenKSP(context.Background(), newTestArgs(graph, "CDG", "GRU"), 3)
+
+			g.Assert(len(paths)).Equal(0)
+		})
+
+		g.It("should stop with the error of a canceled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			paths, err := YenKSP(ctx, newTestArgs(graph, "GRU", "CDG"), 3)
+
+			g.Assert(err).Equal(context.Canceled)
+			g.Assert(len(paths)).Equal(0)
+		})
+	})
+
+	g.Describe("Tests for findBestRoutes", func() {
+		g.It("should return QueryTimeoutErr once the deadline passed", func() {
+			ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			defer cancel()
+
+			_, err := findBestRoutes(ctx, graph, "GRU", "CDG", 3, searchOptions{})
+
+			g.Assert(err).Equal(e.NewQueryTimeoutErr())
+		})
+	})
+}
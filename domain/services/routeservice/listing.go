@@ -0,0 +1,205 @@
+package routeservice
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	r "go-bestflight/domain/entities/routes"
+	e "go-bestflight/domain/errors"
+	validation "go-bestflight/domain/services/validationservice"
+	"go-bestflight/resources/tracing"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageSize is the number of routes of a page when no limit is given.
+	DefaultPageSize = 50
+	// MaxPageSize is the maximum number of routes of a page.
+	MaxPageSize = 500
+)
+
+// RouteListQuery filters, sorts and paginates a routes listing.
+// Empty airports and zero costs do not filter. SortBy is "boarding", "destination" or "cost",
+// optionally prefixed by "-" for descending order, and Cursor is the NextCursor of the previous page.
+type RouteListQuery struct {
+	Boarding    string
+	Destination string
+	MinCost     int
+	MaxCost     int
+	SortBy      string
+	Cursor      string
+	Limit       int
+}
+
+type routeLess func(a, b r.Route) bool
+
+func compareAirports(a, b r.Route) int {
+	if a.Boarding != b.Boarding {
+		return strings.Compare(a.Boarding, b.Boarding)
+	}
+
+	return strings.Compare(a.Destination, b.Destination)
+}
+
+// sortOrder returns a total order over the routes, so a route can be used as a cursor.
+// Ties are broken by boarding and destination, which are unique together.
+func sortOrder(sortBy string) (routeLess, error) {
+	descending := strings.HasPrefix(sortBy, "-")
+	field := strings.TrimPrefix(sortBy, "-")
+
+	var compare func(a, b r.Route) int
+
+	switch field {
+	case "", "boarding":
+		compare = compareAirports
+	case "destination":
+		compare = func(a, b r.Route) int {
+			if a.Destination != b.Destination {
+				return strings.Compare(a.Destination, b.Destination)
+			}
+
+			return strings.Compare(a.Boarding, b.Boarding)
+		}
+	case "cost":
+		compare = func(a, b r.Route) int {
+			if a.Cost != b.Cost {
+				return a.Cost - b.Cost
+			}
+
+			return compareAirports(a, b)
+		}
+	default:
+		return nil, e.NewInvalidParameterErr("sort")
+	}
+
+	if descending {
+		return func(a, b r.Route) bool { return compare(a, b) > 0 }, nil
+	}
+
+	return func(a, b r.Route) bool { return compare(a, b) < 0 }, nil
+}
+
+func encodeCursor(route r.Route) string {
+	key := fmt.Sprintf("%s,%s,%d", route.Boarding, route.Destination, route.Cost)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func decodeCursor(cursor string) (r.Route, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return r.Route{}, e.NewInvalidParameterErr("cursor")
+	}
+
+	components := strings.Split(string(key), ",")
+	if len(components) != 3 {
+		return r.Route{}, e.NewInvalidParameterErr("cursor")
+	}
+
+	cost, err := strconv.Atoi(components[2])
+	if err != nil {
+		return r.Route{}, e.NewInvalidParameterErr("cursor")
+	}
+
+	return r.Route{Boarding: components[0], Destination: components[1], Cost: cost}, nil
+}
+
+func (query RouteListQuery) normalize() (RouteListQuery, error) {
+	query.Boarding = strings.ToUpper(query.Boarding)
+	query.Destination = strings.ToUpper(query.Destination)
+
+	if query.Boarding != "" && !validation.IsValidAirport(query.Boarding) {
+		return query, e.NewInvalidAirportErr("malformed").InField("board")
+	}
+
+	if query.Destination != "" && !validation.IsValidAirport(query.Destination) {
+		return query, e.NewInvalidAirportErr("malformed").InField("dest")
+	}
+
+	if query.MinCost < 0 {
+		return query, e.NewInvalidParameterErr("min_cost")
+	}
+
+	if query.MaxCost < 0 || (query.MaxCost > 0 && query.MaxCost < query.MinCost) {
+		return query, e.NewInvalidParameterErr("max_cost")
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return query, e.NewInvalidParameterErr("limit")
+	}
+
+	return query, nil
+}
+
+func (query RouteListQuery) matches(route r.Route) bool {
+	return (query.Boarding == "" || route.Boarding == query.Boarding) &&
+		(query.Destination == "" || route.Destination == query.Destination) &&
+		route.Cost >= query.MinCost &&
+		(query.MaxCost == 0 || route.Cost <= query.MaxCost)
+}
+
+// ListRoutes returns a page of the stored routes matching the query and how many routes match it.
+// The pagination is keyset based: a page starts right after the route encoded in the cursor, so
+// routes added or removed meanwhile never make a route be skipped or repeated.
+func (s *RouteService) ListRoutes(ctx context.Context, query RouteListQuery) (r.RoutesPage, error) {
+	_, span := tracing.Start(ctx, "list")
+	defer span.End()
+
+	query, err := query.normalize()
+	if err != nil {
+		return r.RoutesPage{}, err
+	}
+
+	less, err := sortOrder(query.SortBy)
+	if err != nil {
+		return r.RoutesPage{}, err
+	}
+
+	matching := []r.Route{}
+
+	for _, route := range s.routes.GetAllRoutes() {
+		if query.matches(route) {
+			matching = append(matching, route)
+		}
+	}
+
+	sort.Slice(matching, func(i, j int) bool { return less(matching[i], matching[j]) })
+
+	start := 0
+
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return r.RoutesPage{}, err
+		}
+
+		start = sort.Search(len(matching), func(i int) bool { return less(after, matching[i]) })
+	}
+
+	end := start + query.Limit
+	if end > len(matching) {
+		end = len(matching)
+	}
+
+	page := r.RoutesPage{
+		Routes: matching[start:end],
+		Total:  len(matching),
+	}
+
+	if end < len(matching) {
+		page.NextCursor = encodeCursor(matching[end-1])
+	}
+
+	return page, nil
+}
+
+// ListRoutes returns a page of the routes stored in the shared database matching the query.
+func ListRoutes(ctx context.Context, query RouteListQuery) (r.RoutesPage, error) {
+	return Default().ListRoutes(ctx, query)
+}
@@ -0,0 +1,118 @@
+package routeservice
+
+import (
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	e "go-bestflight/domain/errors"
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/database"
+	"go-bestflight/resources/repositories/airportrepository"
+	"go-bestflight/resources/repositories/routerepository"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestListRoutes(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	db := database.New()
+	db.StoreRoutes(testRoutes)
+
+	service := New(routerepository.New(db, db, cache.New(), nil), airportrepository.New(db))
+
+	g.Describe("Tests for ListRoutes", func() {
+		g.It("should list every route sorted by boarding and destination by default", func() {
+			page, err := service.ListRoutes(context.Background(), RouteListQuery{})
+
+			g.Assert(err).Equal(nil)
+			g.Assert(page.Total).Equal(7)
+			g.Assert(page.NextCursor).Equal("")
+			g.Assert(page.Routes[0]).Equal(r.Route{Boarding: "BRC", Destination: "SCL", Cost: 5})
+			g.Assert(page.Routes[6]).Equal(r.Route{Boarding: "SCL", Destination: "ORL", Cost: 20})
+		})
+
+		g.It("should filter by boarding, destination and cost range", func() {
+			page, _ := service.ListRoutes(context.Background(), RouteListQuery{Boarding: "gru", MinCost: 20, MaxCost: 60})
+
+			g.Assert(page.Total).Equal(2)
+			g.Assert(page.Routes).Equal([]r.Route{
+				{Boarding: "GRU", Destination: "ORL", Cost: 56},
+				{Boarding: "GRU", Destination: "SCL", Cost: 20},
+			})
+
+			page, _ = service.ListRoutes(context.Background(), RouteListQuery{Destination: "CDG"})
+
+			g.Assert(page.Total).Equal(2)
+		})
+
+		g.It("should sort by cost in descending order breaking ties by airports", func() {
+			page, _ := service.ListRoutes(context.Background(), RouteListQuery{SortBy: "-cost", Limit: 3})
+
+			g.Assert(page.Routes).Equal([]r.Route{
+				{Boarding: "GRU", Destination: "CDG", Cost: 75},
+				{Boarding: "GRU", Destination: "ORL", Cost: 56},
+				{Boarding: "SCL", Destination: "ORL", Cost: 20},
+			})
+		})
+
+		g.It("should go through every page with the cursor", func() {
+			visited := []r.Route{}
+			query := RouteListQuery{SortBy: "cost", Limit: 3}
+			pages := 0
+
+			for {
+				page, err := service.ListRoutes(context.Background(), query)
+				g.Assert(err).Equal(nil)
+				g.Assert(page.Total).Equal(7)
+
+				visited = append(visited, page.Routes...)
+				pages++
+
+				if page.NextCursor == "" {
+					break
+				}
+
+				query.Cursor = page.NextCursor
+			}
+
+			g.Assert(pages).Equal(3)
+			g.Assert(len(visited)).Equal(7)
+			g.Assert(visited[0].Cost).Equal(5)
+			g.Assert(visited[6].Cost).Equal(75)
+		})
+
+		g.It("should keep going from the cursor when routes change between pages", func() {
+			other := database.New()
+			other.StoreRoutes(testRoutes)
+			otherService := New(routerepository.New(other, other, cache.New(), nil), airportrepository.New(other))
+
+			page, _ := otherService.ListRoutes(context.Background(), RouteListQuery{Limit: 2})
+
+			other.DeleteRoute(page.Routes[1])
+			other.StoreRoute(r.Route{Boarding: "AAA", Destination: "BBB", Cost: 1})
+
+			next, _ := otherService.ListRoutes(context.Background(), RouteListQuery{Limit: 2, Cursor: page.NextCursor})
+
+			g.Assert(next.Routes[0]).Equal(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+			g.Assert(next.Total).Equal(7)
+		})
+
+		g.It("should return errors for invalid parameters", func() {
+			_, err := service.ListRoutes(context.Background(), RouteListQuery{Boarding: "GR"})
+			g.Assert(err).Equal(e.NewInvalidAirportErr("malformed").InField("board"))
+
+			_, err = service.ListRoutes(context.Background(), RouteListQuery{SortBy: "name"})
+			g.Assert(err).Equal(e.NewInvalidParameterErr("sort"))
+
+			_, err = service.ListRoutes(context.Background(), RouteListQuery{MinCost: 10, MaxCost: 5})
+			g.Assert(err).Equal(e.NewInvalidParameterErr("max_cost"))
+
+			_, err = service.ListRoutes(context.Background(), RouteListQuery{Limit: MaxPageSize + 1})
+			g.Assert(err).Equal(e.NewInvalidParameterErr("limit"))
+
+			_, err = service.ListRoutes(context.Background(), RouteListQuery{Cursor: "not a cursor"})
+			g.Assert(err).Equal(e.NewInvalidParameterErr("cursor"))
+		})
+	})
+}
@@ -0,0 +1,136 @@
+package routeservice
+
+import (
+	e "go-bestflight/domain/errors"
+	validation "go-bestflight/domain/services/validationservice"
+	"go-bestflight/resources/repositories/airportrepository"
+	"strings"
+)
+
+// SearchOption defines a constraint to be applied when searching for best routes.
+type SearchOption func(*searchOptions)
+
+type searchOptions struct {
+	limitStops bool
+	maxStops   int
+	avoid      []string
+	via        []string
+}
+
+// WithMaxStops limits the search to routes with at most n intermediate airports.
+func WithMaxStops(n int) SearchOption {
+	return func(opts *searchOptions) {
+		opts.limitStops = true
+		opts.maxStops = n
+	}
+}
+
+// WithAvoid excludes the given airports from the search.
+func WithAvoid(airports ...string) SearchOption {
+	return func(opts *searchOptions) {
+		opts.avoid = append(opts.avoid, airports...)
+	}
+}
+
+// WithVia forces the routes to pass through the given airports, in the given order.
+func WithVia(airports ...string) SearchOption {
+	return func(opts *searchOptions) {
+		opts.via = append(opts.via, airports...)
+	}
+}
+
+func toUpper(airports []string) []string {
+	upper := make([]string, len(airports))
+
+	for i, airport := range airports {
+		upper[i] = strings.ToUpper(airport)
+	}
+
+	return upper
+}
+
+func newSearchOptions(options []SearchOption) (searchOptions, error) {
+	opts := searchOptions{}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	opts.avoid = toUpper(opts.avoid)
+	opts.via = toUpper(opts.via)
+
+	if opts.limitStops && opts.maxStops < 0 {
+		return searchOptions{}, e.NewInvalidParameterErr("max_stops")
+	}
+
+	for _, airport := range opts.avoid {
+		if !validation.IsValidAirport(airport) {
+			return searchOptions{}, e.NewInvalidAirportErr("malformed").InField("avoid")
+		}
+	}
+
+	for _, airport := range opts.via {
+		if !validation.IsValidAirport(airport) {
+			return searchOptions{}, e.NewInvalidAirportErr("malformed").InField("via")
+		}
+	}
+
+	return opts, nil
+}
+
+// validate checks the options against the boarding and destination of the search.
+// Avoiding airports that are not registered is allowed, since there is nothing to avoid.
+func (opts searchOptions) validate(board, dest string, airports *airportrepository.AirportRepository) error {
+	avoided := map[string]bool{}
+
+	for _, airport := range opts.avoid {
+		if airport == board || airport == dest {
+			return e.NewInvalidParameterErr("avoid")
+		}
+
+		avoided[airport] = true
+	}
+
+	last := board
+
+	for _, airport := range opts.via {
+		if avoided[airport] || airport == last || airport == dest {
+			return e.NewInvalidParameterErr("via")
+		}
+
+		if !airports.IsRegistered(airport) {
+			return e.NewAirportNotRegisteredErr().InField("via")
+		}
+
+		last = airport
+	}
+
+	return nil
+}
+
+func (opts searchOptions) apply(args *dijkstraArgs) error {
+	if opts.limitStops {
+		args.maxEdges = opts.maxStops + 1
+	}
+
+	if len(opts.avoid) > 0 {
+		args.removedNodes = make(map[int]bool)
+	}
+
+	for _, airport := range opts.avoid {
+		if index, ok := args.g.Index(airport); ok {
+			args.removedNodes[index] = true
+		}
+	}
+
+	for _, airport := range opts.via {
+		index, ok := args.g.Index(airport)
+		if !ok {
+			return e.NewBestRouteNotFoundErr()
+		}
+
+		args.waypoints = append(args.waypoints, index)
+	}
+
+	return nil
+}
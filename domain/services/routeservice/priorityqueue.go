@@ -1,70 +1,112 @@
 package routeservice
 
-import (
-	"container/heap"
-)
-
-// An Item is something we manage in a priority queue.
-type Item struct {
-	node     int
-	priority int
-	index    int
+// A PriorityQueue is an indexed binary min-heap of graph nodes.
+// Each node is in the queue at most once and its position in the heap is kept by
+// node id, so its priority can be decreased in O(log n) instead of pushing duplicates.
+type PriorityQueue struct {
+	heap       []int
+	priorities []int
+	positions  []int
 }
 
-// A PriorityQueue implements heap.Interface and holds Items.
-type PriorityQueue []*Item
+// NewPriorityQueue is a constructor for a PriorityQueue of nodes from 0 to size-1.
+func NewPriorityQueue(size int) *PriorityQueue {
+	positions := make([]int, size)
 
-// NewPriorityQueue is a constructor for PriorityQueue.
-func NewPriorityQueue() *PriorityQueue {
-	pq := make(PriorityQueue, 0)
-	heap.Init(&pq)
+	for i := range positions {
+		positions[i] = -1
+	}
 
-	return &pq
+	return &PriorityQueue{
+		heap:       make([]int, 0, size),
+		priorities: make([]int, size),
+		positions:  positions,
+	}
 }
 
-func (pq PriorityQueue) Len() int { return len(pq) }
+// Len returns the number of nodes in the queue.
+func (pq *PriorityQueue) Len() int { return len(pq.heap) }
 
-func (pq PriorityQueue) Less(i, j int) bool {
-	// We want Pop to give us the lowest priority so we use lesser than here.
-	return pq[i].priority < pq[j].priority
+// Contains returns true if the node is in the queue.
+func (pq *PriorityQueue) Contains(node int) bool {
+	return pq.positions[node] != -1
 }
 
-func (pq PriorityQueue) Swap(i, j int) {
-	pq[i], pq[j] = pq[j], pq[i]
-	pq[i].index = i
-	pq[j].index = j
+// Push inserts a node into the PriorityQueue.
+func (pq *PriorityQueue) Push(node, priority int) {
+	pq.priorities[node] = priority
+	pq.positions[node] = len(pq.heap)
+	pq.heap = append(pq.heap, node)
+	pq.up(len(pq.heap) - 1)
+}
+
+// Pop removes the node with the lowest priority and returns it with its priority.
+func (pq *PriorityQueue) Pop() (int, int) {
+	node := pq.heap[0]
+	last := len(pq.heap) - 1
+
+	pq.swap(0, last)
+	pq.heap = pq.heap[:last]
+	pq.positions[node] = -1
+	pq.down(0)
+
+	return node, pq.priorities[node]
 }
 
-// Push inserts a Item into the PriorityQueue.
-func (pq *PriorityQueue) Push(x interface{}) {
-	n := len(*pq)
-	item := x.(*Item)
-	item.index = n
-	*pq = append(*pq, item)
+// DecreaseKey lowers the priority of a node already in the queue.
+func (pq *PriorityQueue) DecreaseKey(node, priority int) {
+	if priority >= pq.priorities[node] {
+		return
+	}
+
+	pq.priorities[node] = priority
+	pq.up(pq.positions[node])
 }
 
-// Pop removes an Item from the PriorityQueue and returns it.
-func (pq *PriorityQueue) Pop() interface{} {
-	old := *pq
-	n := len(old)
-	item := old[n-1]
-	old[n-1] = nil  // avoid memory leak
-	item.index = -1 // for safety
-	*pq = old[0 : n-1]
-	return item
+func (pq *PriorityQueue) less(i, j int) bool {
+	return pq.priorities[pq.heap[i]] < pq.priorities[pq.heap[j]]
 }
 
-// Update reorganizes the Items indexes.
-func (pq *PriorityQueue) Update(item *Item) {
-	heap.Fix(pq, item.index)
+func (pq *PriorityQueue) swap(i, j int) {
+	pq.heap[i], pq.heap[j] = pq.heap[j], pq.heap[i]
+	pq.positions[pq.heap[i]] = i
+	pq.positions[pq.heap[j]] = j
 }
 
-func (pq *PriorityQueue) getItemReferenceByNode(node int) *Item {
-	for _, item := range *pq {
-		if item.node == node {
-			return item
+func (pq *PriorityQueue) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+
+		if !pq.less(i, parent) {
+			break
 		}
+
+		pq.swap(i, parent)
+		i = parent
 	}
+}
+
+func (pq *PriorityQueue) down(i int) {
+	n := len(pq.heap)
+
+	for {
+		smallest := i
+		left := 2*i + 1
+		right := left + 1
+
+		if left < n && pq.less(left, smallest) {
+			smallest = left
+		}
 
-	return nil
+		if right < n && pq.less(right, smallest) {
+			smallest = right
+		}
+
+		if smallest == i {
+			return
+		}
+
+		pq.swap(i, smallest)
+		i = smallest
+	}
 }
@@ -0,0 +1,79 @@
+package routeservice
+
+import (
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for Push and Pop", func() {
+		g.It("should pop the nodes in priority order", func() {
+			pq := NewPriorityQueue(6)
+			priorities := []int{50, 10, 40, 20, 0, 30}
+
+			for node, priority := range priorities {
+				pq.Push(node, priority)
+			}
+
+			g.Assert(pq.Len()).Equal(6)
+
+			expected := []int{4, 1, 3, 5, 2, 0}
+
+			for _, node := range expected {
+				popped, priority := pq.Pop()
+
+				g.Assert(popped).Equal(node)
+				g.Assert(priority).Equal(priorities[node])
+				g.Assert(pq.Contains(node)).IsFalse()
+			}
+
+			g.Assert(pq.Len()).Equal(0)
+		})
+	})
+
+	g.Describe("Tests for DecreaseKey", func() {
+		g.It("should move a node ahead when its priority decreases", func() {
+			pq := NewPriorityQueue(4)
+
+			pq.Push(0, 10)
+			pq.Push(1, 20)
+			pq.Push(2, 30)
+			pq.Push(3, 40)
+
+			pq.DecreaseKey(3, 5)
+			pq.DecreaseKey(2, 50) // ignored, greater than the current priority
+
+			node, priority := pq.Pop()
+			g.Assert(node).Equal(3)
+			g.Assert(priority).Equal(5)
+
+			node, _ = pq.Pop()
+			g.Assert(node).Equal(0)
+
+			node, _ = pq.Pop()
+			g.Assert(node).Equal(1)
+
+			node, priority = pq.Pop()
+			g.Assert(node).Equal(2)
+			g.Assert(priority).Equal(30)
+		})
+
+		g.It("should keep every node only once", func() {
+			pq := NewPriorityQueue(3)
+
+			pq.Push(0, 10)
+			pq.Push(1, 20)
+
+			g.Assert(pq.Contains(1)).IsTrue()
+			g.Assert(pq.Contains(2)).IsFalse()
+
+			pq.DecreaseKey(1, 1)
+			pq.DecreaseKey(1, 0)
+
+			g.Assert(pq.Len()).Equal(2)
+		})
+	})
+}
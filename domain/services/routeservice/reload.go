@@ -0,0 +1,106 @@
+package routeservice
+
+import (
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	validation "go-bestflight/domain/services/validationservice"
+	"go-bestflight/resources/logger"
+	"sort"
+	"strings"
+)
+
+// diffRoutes returns the changes that turn the current routes into the wanted ones, sorted by
+// boarding and destination.
+func diffRoutes(current, wanted []r.Route) r.RouteChanges {
+	changes := r.RouteChanges{
+		Added:   []r.Route{},
+		Updated: []r.Route{},
+		Removed: []r.Route{},
+	}
+	costs := make(map[string]int, len(current))
+
+	for _, route := range current {
+		costs[route.Boarding+"-"+route.Destination] = route.Cost
+	}
+
+	for _, route := range wanted {
+		key := route.Boarding + "-" + route.Destination
+
+		cost, ok := costs[key]
+		if !ok {
+			changes.Added = append(changes.Added, route)
+			continue
+		}
+
+		if cost != route.Cost {
+			changes.Updated = append(changes.Updated, route)
+		}
+
+		delete(costs, key)
+	}
+
+	for _, route := range current {
+		if _, ok := costs[route.Boarding+"-"+route.Destination]; ok {
+			changes.Removed = append(changes.Removed, route)
+		}
+	}
+
+	for _, routes := range [][]r.Route{changes.Added, changes.Updated, changes.Removed} {
+		routes := routes
+		sort.Slice(routes, func(i, j int) bool { return compareAirports(routes[i], routes[j]) < 0 })
+	}
+
+	return changes
+}
+
+// validRoutes returns the valid routes with uppercase airports, keeping the first of the routes
+// with the same airports like LoadRoutes, and how many routes were skipped.
+func validRoutes(routes []r.Route) ([]r.Route, int) {
+	valid := []r.Route{}
+	seen := make(map[string]bool)
+
+	for _, route := range routes {
+		route.Boarding = strings.ToUpper(route.Boarding)
+		route.Destination = strings.ToUpper(route.Destination)
+		key := route.Boarding + "-" + route.Destination
+
+		if !validation.IsValidRoute(route) || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		valid = append(valid, route)
+	}
+
+	return valid, len(routes) - len(valid)
+}
+
+// ReloadRoutes makes the stored routes match the ones read again from the file, e.g. after it was
+// rewritten by someone else, and returns what changed. Invalid and duplicate routes are skipped
+// like in LoadRoutes. Every change is applied at once, so searches running meanwhile see either
+// the previous or the new routes.
+func (s *RouteService) ReloadRoutes(ctx context.Context, routes []r.Route) (r.RouteChanges, error) {
+	wanted, skipped := validRoutes(routes)
+	changes := diffRoutes(s.routes.GetAllRoutes(), wanted)
+
+	if changes.Empty() {
+		logger.FromContext(ctx).Info("routes reloaded", "added", 0, "updated", 0, "removed", 0, "skipped", skipped)
+		return changes, nil
+	}
+
+	err := s.routes.ApplyChanges(ctx, changes)
+	if err != nil {
+		logger.FromContext(ctx).Error("could not reload the routes", "error", err)
+		return r.RouteChanges{}, err
+	}
+
+	logger.FromContext(ctx).Info("routes reloaded", "added", len(changes.Added), "updated", len(changes.Updated),
+		"removed", len(changes.Removed), "skipped", skipped)
+
+	return changes, nil
+}
+
+// ReloadRoutes makes the routes stored in the shared resources match the ones read again from the file.
+func ReloadRoutes(ctx context.Context, routes []r.Route) (r.RouteChanges, error) {
+	return Default().ReloadRoutes(ctx, routes)
+}
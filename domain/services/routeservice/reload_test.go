@@ -0,0 +1,109 @@
+package routeservice
+
+import (
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/database"
+	"go-bestflight/resources/file"
+	"go-bestflight/resources/repositories/airportrepository"
+	"go-bestflight/resources/repositories/routerepository"
+	"io/ioutil"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestReloadRoutes(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	var service *RouteService
+
+	g.Describe("Tests for ReloadRoutes", func() {
+		g.BeforeEach(func() {
+			service = newIsolatedService(t, "reload.csv")
+			service.LoadRoutes(context.Background(), []r.Route{
+				{Boarding: "GRU", Destination: "CDG", Cost: 75},
+				{Boarding: "GRU", Destination: "SCL", Cost: 20},
+				{Boarding: "SCL", Destination: "ORL", Cost: 20},
+			})
+		})
+
+		g.It("should apply additions, cost changes and removals", func() {
+			changes, err := service.ReloadRoutes(context.Background(), []r.Route{
+				{Boarding: "gru", Destination: "cdg", Cost: 75},
+				{Boarding: "GRU", Destination: "SCL", Cost: 10},
+				{Boarding: "ORL", Destination: "CDG", Cost: 5},
+				{Boarding: "ORL", Destination: "CDG", Cost: 6},
+				{Boarding: "GR", Destination: "CDG", Cost: 5},
+			})
+
+			g.Assert(err).Equal(nil)
+			g.Assert(changes).Equal(r.RouteChanges{
+				Added:   []r.Route{{Boarding: "ORL", Destination: "CDG", Cost: 5}},
+				Updated: []r.Route{{Boarding: "GRU", Destination: "SCL", Cost: 10}},
+				Removed: []r.Route{{Boarding: "SCL", Destination: "ORL", Cost: 20}},
+			})
+
+			best, _ := service.GetBestRoute(context.Background(), "GRU", "CDG")
+			_, err = service.GetBestRoute(context.Background(), "GRU", "ORL")
+
+			g.Assert(best.Cost).Equal(75)
+			g.Assert(err != nil).IsTrue()
+			g.Assert(service.airports.IsRegistered("ORL")).IsTrue()
+		})
+
+		g.It("should change nothing when the routes are the same", func() {
+			changes, err := service.ReloadRoutes(context.Background(), []r.Route{
+				{Boarding: "SCL", Destination: "ORL", Cost: 20},
+				{Boarding: "GRU", Destination: "CDG", Cost: 75},
+				{Boarding: "GRU", Destination: "SCL", Cost: 20},
+			})
+
+			g.Assert(err).Equal(nil)
+			g.Assert(changes.Empty()).IsTrue()
+		})
+	})
+
+	g.Describe("Tests for ReloadRoutes after a rewrite of the routes file", func() {
+		g.It("should make the routes match the rewritten file, whatever was logged before", func() {
+			filePath := "rewritten.csv"
+			ioutil.WriteFile(filePath, []byte("GRU,CDG,75\nGRU,SCL,20\n"), 0664)
+
+			db := database.New()
+			routesFile, err := file.Open(filePath)
+			g.Assert(err).Equal(nil)
+			defer routesFile.Remove()
+
+			service := New(routerepository.New(db, db, cache.New(), routesFile), airportrepository.New(db))
+			routes, _ := routesFile.ReadFile()
+			service.LoadRoutes(context.Background(), routes)
+
+			service.UpdateRouteCost(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+			service.AddNewRoute(context.Background(), r.Route{Boarding: "SCL", Destination: "ORL", Cost: 5})
+
+			ioutil.WriteFile(filePath, []byte("GRU,CDG,80\nGRU,SCL,20\n"), 0664)
+
+			routesFile.Changed()
+			changed, err := routesFile.Changed()
+			g.Assert(err).Equal(nil)
+			g.Assert(changed).IsTrue()
+
+			routes, _ = routesFile.ReadFile()
+			changes, err := service.ReloadRoutes(context.Background(), routes)
+
+			g.Assert(err).Equal(nil)
+			g.Assert(changes).Equal(r.RouteChanges{
+				Added:   []r.Route{},
+				Updated: []r.Route{{Boarding: "GRU", Destination: "CDG", Cost: 80}},
+				Removed: []r.Route{{Boarding: "SCL", Destination: "ORL", Cost: 5}},
+			})
+
+			g.Assert(routesFile.Compact()).Equal(nil)
+
+			content, _ := ioutil.ReadFile(filePath)
+
+			g.Assert(string(content)).Equal("GRU,CDG,80\nGRU,SCL,20\n")
+		})
+	})
+}
@@ -1,19 +1,56 @@
 package routeservice
 
 import (
+	"context"
 	"errors"
 	r "go-bestflight/domain/entities/routes"
 	e "go-bestflight/domain/errors"
 	validation "go-bestflight/domain/services/validationservice"
-	"go-bestflight/resources/cache"
+	"go-bestflight/resources/logger"
+	"go-bestflight/resources/metrics"
 	"go-bestflight/resources/repositories/airportrepository"
 	"go-bestflight/resources/repositories/routerepository"
-	"log"
 	"strings"
+	"time"
 )
 
+// MaxAlternatives is the maximum number of routes that can be asked to GetBestRoutes.
+const MaxAlternatives = 10
+
+// QueryTimeout is the budget of every search of GetBestRoute and GetBestRoutes: a search taking longer
+// stops with a QueryTimeoutErr. Zero disables it, leaving the searches to run until their context ends.
+var QueryTimeout = 3 * time.Second
+
+// withBudget returns a copy of ctx that ends once QueryTimeout elapses.
+func withBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	if QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, QueryTimeout)
+}
+
+// RouteService holds the business rules for registering and searching routes.
+type RouteService struct {
+	routes   *routerepository.RouteRepository
+	airports *airportrepository.AirportRepository
+}
+
+// New is a constructor for a RouteService over the given repositories.
+func New(routes *routerepository.RouteRepository, airports *airportrepository.AirportRepository) *RouteService {
+	return &RouteService{
+		routes:   routes,
+		airports: airports,
+	}
+}
+
+// Default returns a RouteService over the resources shared by the application.
+func Default() *RouteService {
+	return New(routerepository.Default(), airportrepository.Default())
+}
+
 // AddNewRoute ...
-func AddNewRoute(route r.Route) (r.Route, error) {
+func (s *RouteService) AddNewRoute(ctx context.Context, route r.Route) (r.Route, error) {
 	boarding := strings.ToUpper(route.Boarding)
 	destination := strings.ToUpper(route.Destination)
 	newRoute := r.Route{
@@ -23,73 +60,233 @@ func AddNewRoute(route r.Route) (r.Route, error) {
 	}
 
 	if !validation.IsValidRoute(newRoute) {
-		log.Printf("invalid route format: %v\n", newRoute)
+		logger.FromContext(ctx).Debug("invalid route", "board", newRoute.Boarding, "dest", newRoute.Destination, "cost", newRoute.Cost)
 		return r.Route{}, e.NewInvalidRouteErr()
 	}
 
-	if routerepository.RouteExists(newRoute.Boarding, newRoute.Destination) {
-		log.Printf("route already stored: %v\n", newRoute)
+	if s.routes.RouteExists(newRoute.Boarding, newRoute.Destination) {
+		logger.FromContext(ctx).Debug("route already stored", "board", newRoute.Boarding, "dest", newRoute.Destination)
 		return r.Route{}, e.NewRouteAlreadyExistErr()
 	}
 
-	err := routerepository.StoreRoute(newRoute)
+	err := s.routes.StoreRoute(ctx, newRoute)
 	if err != nil {
+		if exists, ok := err.(*e.RouteAlreadyExistErr); ok {
+			return r.Route{}, exists
+		}
+
 		return r.Route{}, errors.New("could not create resource")
 	}
 
 	return route, nil
 }
 
-// LoadRoutes from file into database and cache.
-func LoadRoutes(routes []r.Route) {
-	for line, route := range routes {
-		boarding := strings.ToUpper(route.Boarding)
-		destination := strings.ToUpper(route.Destination)
-		newRoute := r.Route{
-			Boarding:    boarding,
-			Destination: destination,
-			Cost:        route.Cost,
-		}
+// UpdateRouteCost replaces the cost of an existing route in every resource.
+func (s *RouteService) UpdateRouteCost(ctx context.Context, route r.Route) (r.Route, error) {
+	updatedRoute := r.Route{
+		Boarding:    strings.ToUpper(route.Boarding),
+		Destination: strings.ToUpper(route.Destination),
+		Cost:        route.Cost,
+	}
+
+	if !validation.IsValidRoute(updatedRoute) {
+		logger.FromContext(ctx).Debug("invalid route", "board", updatedRoute.Boarding, "dest", updatedRoute.Destination, "cost", updatedRoute.Cost)
+		return r.Route{}, e.NewInvalidRouteErr()
+	}
+
+	if !s.routes.RouteExists(updatedRoute.Boarding, updatedRoute.Destination) {
+		return r.Route{}, e.NewRouteNotFoundErr()
+	}
 
-		if !validation.IsValidRoute(newRoute) {
-			log.Printf("invalid format at line: %d\n", line)
-			continue
+	err := s.routes.UpdateRoute(ctx, updatedRoute)
+	if err != nil {
+		if notFound, ok := err.(*e.RouteNotFoundErr); ok {
+			return r.Route{}, notFound
 		}
 
-		if routerepository.RouteExists(newRoute.Boarding, newRoute.Destination) {
-			log.Printf("route at line %d already stored: %v\n", line, newRoute)
-			continue
+		return r.Route{}, errors.New("could not update resource")
+	}
+
+	return updatedRoute, nil
+}
+
+// DeleteRoute removes the route between two airports from every resource and returns it.
+func (s *RouteService) DeleteRoute(ctx context.Context, boarding string, destination string) (r.Route, error) {
+	board := strings.ToUpper(boarding)
+	dest := strings.ToUpper(destination)
+
+	if !validation.IsValidAirport(board) {
+		return r.Route{}, e.NewInvalidAirportErr("malformed").InField("board")
+	}
+
+	if !validation.IsValidAirport(dest) {
+		return r.Route{}, e.NewInvalidAirportErr("malformed").InField("dest")
+	}
+
+	if !s.routes.RouteExists(board, dest) {
+		return r.Route{}, e.NewRouteNotFoundErr()
+	}
+
+	deletedRoute, err := s.routes.DeleteRoute(ctx, board, dest)
+	if err != nil {
+		if notFound, ok := err.(*e.RouteNotFoundErr); ok {
+			return r.Route{}, notFound
 		}
 
-		routerepository.StoreRouteFromFile(newRoute)
+		return r.Route{}, errors.New("could not delete resource")
 	}
+
+	return deletedRoute, nil
+}
+
+// LoadRoutes from file into database and cache, reporting the invalid and duplicate lines.
+// The accepted routes are stored at once, so the routes graph is published only once.
+func (s *RouteService) LoadRoutes(ctx context.Context, routes []r.Route) r.ImportReport {
+	report := s.classify(numberedLines(routes))
+
+	for _, line := range report.Rejected {
+		logger.FromContext(ctx).Warn("route rejected", "line", line.Line, "reason", line.Reason)
+	}
+
+	for _, line := range report.Duplicate {
+		logger.FromContext(ctx).Info("route skipped", "line", line.Line, "reason", line.Reason)
+	}
+
+	s.routes.StoreRoutesFromFile(ctx, acceptedRoutes(report))
+	report.Committed = true
+
+	metrics.LoadRejectedLines.Add(float64(len(report.Rejected)))
+
+	logger.FromContext(ctx).Info("routes loaded", "accepted", len(report.Accepted), "duplicate", len(report.Duplicate), "rejected", len(report.Rejected))
+
+	return report
 }
 
-// GetBestRoute ...
-func GetBestRoute(boarding string, destination string) (r.BestRoute, error) {
+func (s *RouteService) validateSearch(board, dest string) error {
+	if !validation.IsValidAirport(board) {
+		return e.NewInvalidAirportErr("malformed").InField("board")
+	}
+
+	if !validation.IsValidAirport(dest) {
+		return e.NewInvalidAirportErr("malformed").InField("dest")
+	}
+
+	if !s.airports.IsRegistered(board) {
+		return e.NewAirportNotRegisteredErr().InField("board")
+	}
+
+	if !s.airports.IsRegistered(dest) {
+		return e.NewAirportNotRegisteredErr().InField("dest")
+	}
+
+	if !s.routes.HasConnection(board) {
+		return e.NewBestRouteNotFoundErr()
+	}
+
+	return nil
+}
+
+// GetBestRoute returns the cheapest route between two airports satisfying the given options.
+// The search stops with a QueryTimeoutErr once it exceeds QueryTimeout or the deadline of ctx,
+// and with a QueryCanceledErr once ctx is canceled.
+func (s *RouteService) GetBestRoute(ctx context.Context, boarding string, destination string, options ...SearchOption) (r.BestRoute, error) {
 	board := strings.ToUpper(boarding)
 	dest := strings.ToUpper(destination)
 
-	if !validation.IsValidAirport(board) || !validation.IsValidAirport(dest) {
-		return r.BestRoute{}, e.NewInvalidAirportErr("malformed")
+	opts, err := newSearchOptions(options)
+	if err != nil {
+		return r.BestRoute{}, err
 	}
 
-	if !airportrepository.IsRegistered(board) || !airportrepository.IsRegistered(dest) {
-		return r.BestRoute{}, e.NewInvalidAirportErr("not registered")
+	if err := s.validateSearch(board, dest); err != nil {
+		return r.BestRoute{}, err
 	}
 
-	if !routerepository.HasConnection(board) {
-		return r.BestRoute{}, e.NewBestRouteNotFoundErr()
+	if err := opts.validate(board, dest, s.airports); err != nil {
+		return r.BestRoute{}, err
 	}
 
-	airports := airportrepository.GetAllAirports()
-	routes := cache.GetAllRoutes()
+	ctx, cancel := withBudget(ctx)
+	defer cancel()
 
-	bestRoute, err := findBestRoute(airports, routes, board, dest)
+	bestRoute, err := findBestRoute(ctx, s.routes.GetGraph(), board, dest, opts)
 	if err != nil {
-		log.Printf("error when getting best route for %s-%s: %v", board, dest, err)
+		logger.FromContext(ctx).Debug("best route not found", "board", board, "dest", dest, "error", err)
 		return r.BestRoute{}, err
 	}
 
 	return bestRoute, nil
 }
+
+// GetBestRoutes returns up to k cheapest loopless routes between two airports, in cost order.
+// Routes through waypoints may repeat airports, so WithVia is not supported here.
+// The search stops like the one of GetBestRoute.
+func (s *RouteService) GetBestRoutes(ctx context.Context, boarding string, destination string, k int, options ...SearchOption) ([]r.BestRoute, error) {
+	board := strings.ToUpper(boarding)
+	dest := strings.ToUpper(destination)
+
+	if k < 1 || k > MaxAlternatives {
+		return []r.BestRoute{}, e.NewInvalidParameterErr("alternatives")
+	}
+
+	opts, err := newSearchOptions(options)
+	if err != nil {
+		return []r.BestRoute{}, err
+	}
+
+	if len(opts.via) > 0 {
+		return []r.BestRoute{}, e.NewInvalidParameterErr("via")
+	}
+
+	if err := s.validateSearch(board, dest); err != nil {
+		return []r.BestRoute{}, err
+	}
+
+	if err := opts.validate(board, dest, s.airports); err != nil {
+		return []r.BestRoute{}, err
+	}
+
+	ctx, cancel := withBudget(ctx)
+	defer cancel()
+
+	bestRoutes, err := findBestRoutes(ctx, s.routes.GetGraph(), board, dest, k, opts)
+	if err != nil {
+		logger.FromContext(ctx).Debug("best routes not found", "board", board, "dest", dest, "error", err)
+		return []r.BestRoute{}, err
+	}
+
+	return bestRoutes, nil
+}
+
+// The functions below use the resources shared by the application.
+
+// AddNewRoute ...
+func AddNewRoute(ctx context.Context, route r.Route) (r.Route, error) {
+	return Default().AddNewRoute(ctx, route)
+}
+
+// UpdateRouteCost replaces the cost of an existing route in every resource.
+func UpdateRouteCost(ctx context.Context, route r.Route) (r.Route, error) {
+	return Default().UpdateRouteCost(ctx, route)
+}
+
+// DeleteRoute removes the route between two airports from every resource and returns it.
+func DeleteRoute(ctx context.Context, boarding string, destination string) (r.Route, error) {
+	return Default().DeleteRoute(ctx, boarding, destination)
+}
+
+// LoadRoutes from file into database and cache, reporting the invalid and duplicate lines.
+func LoadRoutes(ctx context.Context, routes []r.Route) r.ImportReport {
+	return Default().LoadRoutes(ctx, routes)
+}
+
+// GetBestRoute returns the cheapest route between two airports satisfying the given options.
+func GetBestRoute(ctx context.Context, boarding string, destination string, options ...SearchOption) (r.BestRoute, error) {
+	return Default().GetBestRoute(ctx, boarding, destination, options...)
+}
+
+// GetBestRoutes returns up to k cheapest loopless routes between two airports, in cost order.
+// Routes through waypoints may repeat airports, so WithVia is not supported here.
+func GetBestRoutes(ctx context.Context, boarding string, destination string, k int, options ...SearchOption) ([]r.BestRoute, error) {
+	return Default().GetBestRoutes(ctx, boarding, destination, k, options...)
+}
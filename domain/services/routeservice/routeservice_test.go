@@ -1,13 +1,17 @@
 package routeservice
 
 import (
+	"context"
 	r "go-bestflight/domain/entities/routes"
 	"go-bestflight/domain/errors"
 	"go-bestflight/resources/cache"
 	"go-bestflight/resources/database"
 	"go-bestflight/resources/file"
+	"go-bestflight/resources/repositories/airportrepository"
+	"go-bestflight/resources/repositories/routerepository"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/franela/goblin"
 )
@@ -32,7 +36,7 @@ func TestRouteService(t *testing.T) {
 				Cost:        1000,
 			}
 
-			r, err := AddNewRoute(route)
+			r, err := AddNewRoute(context.Background(), route)
 
 			g.Assert(r).Equal(route)
 			g.Assert(err).Equal(nil)
@@ -69,7 +73,7 @@ func TestRouteService(t *testing.T) {
 			boarding := strings.ToUpper(route.Boarding)
 			destination := strings.ToUpper(route.Destination)
 
-			_, err := AddNewRoute(route)
+			_, err := AddNewRoute(context.Background(), route)
 
 			g.Assert(err).Equal(nil)
 
@@ -96,7 +100,7 @@ func TestRouteService(t *testing.T) {
 				Cost:        1000,
 			}
 
-			_, err := AddNewRoute(route)
+			_, err := AddNewRoute(context.Background(), route)
 
 			g.Assert(err).Equal(errors.NewInvalidRouteErr())
 		})
@@ -117,11 +121,11 @@ func TestRouteService(t *testing.T) {
 				Cost:        1000,
 			}
 
-			_, err := AddNewRoute(route)
+			_, err := AddNewRoute(context.Background(), route)
 
 			g.Assert(err).Equal(nil)
 
-			_, err = AddNewRoute(route)
+			_, err = AddNewRoute(context.Background(), route)
 
 			g.Assert(err).Equal(errors.NewRouteAlreadyExistErr())
 		})
@@ -151,7 +155,7 @@ func TestRouteService(t *testing.T) {
 				},
 			}
 
-			LoadRoutes(routes)
+			LoadRoutes(context.Background(), routes)
 
 			cost, _ := database.GetRouteCost(routes[0].Boarding, routes[0].Destination)
 			cost2, _ := database.GetRouteCost(routes[1].Boarding, routes[1].Destination)
@@ -203,7 +207,7 @@ func TestRouteService(t *testing.T) {
 				},
 			}
 
-			LoadRoutes(routes)
+			LoadRoutes(context.Background(), routes)
 
 			cost, _ := database.GetRouteCost(routes[0].Boarding, routes[0].Destination)
 			cost2, _ := database.GetRouteCost(routes[1].Boarding, routes[1].Destination)
@@ -285,20 +289,20 @@ func TestRouteService(t *testing.T) {
 			file.Reset(filePath)
 
 			for _, route := range routes {
-				_, err := AddNewRoute(route)
+				_, err := AddNewRoute(context.Background(), route)
 				g.Assert(err == nil).IsTrue()
 			}
 
-			best, _ := GetBestRoute("GRU", "BRC")
-			best2, _ := GetBestRoute("GRU", "SCL")
-			best3, _ := GetBestRoute("GRU", "ORL")
-			best4, _ := GetBestRoute("GRU", "CDG")
-			best5, _ := GetBestRoute("BRC", "SCL")
-			best6, _ := GetBestRoute("SCL", "ORL")
-			best7, _ := GetBestRoute("ORL", "CDG")
-			best8, _ := GetBestRoute("BRC", "CDG")
-			best9, _ := GetBestRoute("BRC", "ORL")
-			best10, _ := GetBestRoute("SCL", "CDG")
+			best, _ := GetBestRoute(context.Background(), "GRU", "BRC")
+			best2, _ := GetBestRoute(context.Background(), "GRU", "SCL")
+			best3, _ := GetBestRoute(context.Background(), "GRU", "ORL")
+			best4, _ := GetBestRoute(context.Background(), "GRU", "CDG")
+			best5, _ := GetBestRoute(context.Background(), "BRC", "SCL")
+			best6, _ := GetBestRoute(context.Background(), "SCL", "ORL")
+			best7, _ := GetBestRoute(context.Background(), "ORL", "CDG")
+			best8, _ := GetBestRoute(context.Background(), "BRC", "CDG")
+			best9, _ := GetBestRoute(context.Background(), "BRC", "ORL")
+			best10, _ := GetBestRoute(context.Background(), "SCL", "CDG")
 
 			g.Assert(best.Route).Equal("GRU - BRC")
 			g.Assert(best.Cost).Equal(10)
@@ -331,6 +335,104 @@ func TestRouteService(t *testing.T) {
 			g.Assert(best10.Cost).Equal(25)
 		})
 
+		g.It("should get the best route with at most the given stops", func() {
+			filePath := "test.csv"
+			defer file.Remove()
+
+			database.Connect()
+			database.Truncate()
+			cache.Connect()
+			cache.Truncate()
+			file.Reset(filePath)
+
+			for _, route := range routes {
+				_, err := AddNewRoute(context.Background(), route)
+				g.Assert(err == nil).IsTrue()
+			}
+
+			best, _ := GetBestRoute(context.Background(), "GRU", "CDG", WithMaxStops(0))
+			best2, _ := GetBestRoute(context.Background(), "GRU", "CDG", WithMaxStops(1))
+			best3, _ := GetBestRoute(context.Background(), "GRU", "CDG", WithMaxStops(2))
+			best4, _ := GetBestRoute(context.Background(), "GRU", "CDG", WithMaxStops(10))
+
+			g.Assert(best).Equal(r.BestRoute{Route: "GRU - CDG", Cost: 75})
+			g.Assert(best2).Equal(r.BestRoute{Route: "GRU - ORL - CDG", Cost: 61})
+			g.Assert(best3).Equal(r.BestRoute{Route: "GRU - SCL - ORL - CDG", Cost: 45})
+			g.Assert(best4).Equal(r.BestRoute{Route: "GRU - BRC - SCL - ORL - CDG", Cost: 40})
+
+			_, err := GetBestRoute(context.Background(), "BRC", "CDG", WithMaxStops(1))
+			g.Assert(err).Equal(errors.NewBestRouteNotFoundErr())
+
+			_, err = GetBestRoute(context.Background(), "GRU", "CDG", WithMaxStops(-1))
+			g.Assert(err).Equal(errors.NewInvalidParameterErr("max_stops"))
+		})
+
+		g.It("should get the best route avoiding and passing through the given airports", func() {
+			filePath := "test.csv"
+			defer file.Remove()
+
+			database.Connect()
+			database.Truncate()
+			cache.Connect()
+			cache.Truncate()
+			file.Reset(filePath)
+
+			for _, route := range routes {
+				_, err := AddNewRoute(context.Background(), route)
+				g.Assert(err == nil).IsTrue()
+			}
+
+			best, _ := GetBestRoute(context.Background(), "GRU", "CDG", WithAvoid("brc"))
+			best2, _ := GetBestRoute(context.Background(), "GRU", "CDG", WithAvoid("SCL"), WithVia("ORL"))
+			best3, _ := GetBestRoute(context.Background(), "GRU", "CDG", WithVia("SCL"), WithMaxStops(2))
+			best4, _ := GetBestRoute(context.Background(), "GRU", "CDG", WithAvoid("XYZ"))
+
+			g.Assert(best).Equal(r.BestRoute{Route: "GRU - SCL - ORL - CDG", Cost: 45})
+			g.Assert(best2).Equal(r.BestRoute{Route: "GRU - ORL - CDG", Cost: 61})
+			g.Assert(best3).Equal(r.BestRoute{Route: "GRU - SCL - ORL - CDG", Cost: 45})
+			g.Assert(best4).Equal(r.BestRoute{Route: "GRU - BRC - SCL - ORL - CDG", Cost: 40})
+
+			best5, _ := GetBestRoute(context.Background(), "GRU", "CDG", WithAvoid("ORL"))
+			g.Assert(best5).Equal(r.BestRoute{Route: "GRU - CDG", Cost: 75})
+
+			_, err := GetBestRoute(context.Background(), "BRC", "CDG", WithAvoid("ORL"))
+			g.Assert(err).Equal(errors.NewBestRouteNotFoundErr())
+		})
+
+		g.It("should return errors for invalid avoid and via airports", func() {
+			filePath := "test.csv"
+			defer file.Remove()
+
+			database.Connect()
+			database.Truncate()
+			cache.Connect()
+			cache.Truncate()
+			file.Reset(filePath)
+
+			for _, route := range routes {
+				_, err := AddNewRoute(context.Background(), route)
+				g.Assert(err == nil).IsTrue()
+			}
+
+			_, err := GetBestRoute(context.Background(), "GRU", "CDG", WithAvoid("SC"))
+			g.Assert(err).Equal(errors.NewInvalidAirportErr("malformed").InField("avoid"))
+
+			_, err = GetBestRoute(context.Background(), "GRU", "CDG", WithVia("1RL"))
+			g.Assert(err).Equal(errors.NewInvalidAirportErr("malformed").InField("via"))
+
+			_, err = GetBestRoute(context.Background(), "GRU", "CDG", WithVia("XYZ"))
+			g.Assert(err).Equal(errors.NewAirportNotRegisteredErr().InField("via"))
+
+			_, err = GetBestRoute(context.Background(), "GRU", "CDG", WithAvoid("GRU"))
+			g.Assert(err).Equal(errors.NewInvalidParameterErr("avoid"))
+
+			_, err = GetBestRoute(context.Background(), "GRU", "CDG", WithAvoid("ORL"), WithVia("ORL"))
+			g.Assert(err).Equal(errors.NewInvalidParameterErr("via"))
+
+			_, err = GetBestRoutes(context.Background(), "GRU", "CDG", 2, WithVia("ORL"))
+			g.Assert(err).Equal(errors.NewInvalidParameterErr("via"))
+		})
+
 		g.It("should return InvalidAirportErr when an airport is not stored or has invalid format", func() {
 			filePath := "test.csv"
 			defer file.Remove()
@@ -342,16 +444,16 @@ func TestRouteService(t *testing.T) {
 			file.Reset(filePath)
 
 			for _, route := range routes {
-				_, err := AddNewRoute(route)
+				_, err := AddNewRoute(context.Background(), route)
 				g.Assert(err == nil).IsTrue()
 			}
 
-			_, err := GetBestRoute("SCL", "XY")
-			g.Assert(err).Equal(errors.NewInvalidAirportErr("malformed"))
+			_, err := GetBestRoute(context.Background(), "SCL", "XY")
+			g.Assert(err).Equal(errors.NewInvalidAirportErr("malformed").InField("dest"))
 
-			_, err = GetBestRoute("SCL", "XYZ")
+			_, err = GetBestRoute(context.Background(), "SCL", "XYZ")
 
-			g.Assert(err).Equal(errors.NewInvalidAirportErr("not registered"))
+			g.Assert(err).Equal(errors.NewAirportNotRegisteredErr().InField("dest"))
 		})
 
 		g.It("should return BestRouteNotFoundErr when a route is unreachable", func() {
@@ -371,13 +473,279 @@ func TestRouteService(t *testing.T) {
 			})
 
 			for _, route := range newRoutes {
-				_, err := AddNewRoute(route)
+				_, err := AddNewRoute(context.Background(), route)
+				g.Assert(err == nil).IsTrue()
+			}
+
+			_, err := GetBestRoute(context.Background(), "SCL", "XYZ")
+
+			g.Assert(err).Equal(errors.NewBestRouteNotFoundErr())
+		})
+
+		g.It("should return QueryCanceledErr when the context is canceled", func() {
+			filePath := "test.csv"
+			defer file.Remove()
+
+			database.Connect()
+			database.Truncate()
+			cache.Connect()
+			cache.Truncate()
+			file.Reset(filePath)
+
+			for _, route := range routes {
+				AddNewRoute(context.Background(), route)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := GetBestRoute(ctx, "GRU", "CDG")
+
+			g.Assert(err).Equal(errors.NewQueryCanceledErr())
+		})
+	})
+
+	g.Describe("Tests for withBudget", func() {
+		g.It("should give the context a deadline of QueryTimeout unless it is zero", func() {
+			defer func(timeout time.Duration) { QueryTimeout = timeout }(QueryTimeout)
+
+			QueryTimeout = time.Minute
+			ctx, cancel := withBudget(context.Background())
+			deadline, ok := ctx.Deadline()
+			cancel()
+
+			g.Assert(ok).IsTrue()
+			g.Assert(time.Until(deadline) <= time.Minute).IsTrue()
+			g.Assert(ctx.Err()).Equal(context.Canceled)
+
+			QueryTimeout = 0
+			ctx, cancel = withBudget(context.Background())
+			defer cancel()
+
+			_, ok = ctx.Deadline()
+
+			g.Assert(ok).IsFalse()
+		})
+	})
+
+	g.Describe("Tests for GetBestRoutes", func() {
+		routes := []r.Route{
+			{Boarding: "GRU", Destination: "BRC", Cost: 10},
+			{Boarding: "BRC", Destination: "SCL", Cost: 5},
+			{Boarding: "GRU", Destination: "CDG", Cost: 75},
+			{Boarding: "GRU", Destination: "SCL", Cost: 20},
+			{Boarding: "GRU", Destination: "ORL", Cost: 56},
+			{Boarding: "ORL", Destination: "CDG", Cost: 5},
+			{Boarding: "SCL", Destination: "ORL", Cost: 20},
+		}
+
+		g.BeforeEach(func() {
+			database.Connect()
+			database.Truncate()
+			cache.Connect()
+			cache.Truncate()
+			file.Reset("test.csv")
+
+			for _, route := range routes {
+				_, err := AddNewRoute(context.Background(), route)
 				g.Assert(err == nil).IsTrue()
 			}
+		})
+
+		g.AfterEach(func() {
+			file.Remove()
+		})
+
+		g.It("should get the k best routes in cost order", func() {
+			bestRoutes, err := GetBestRoutes(context.Background(), "gru", "CDG", 3)
+
+			g.Assert(err).Equal(nil)
+			g.Assert(bestRoutes).Equal([]r.BestRoute{
+				{Route: "GRU - BRC - SCL - ORL - CDG", Cost: 40},
+				{Route: "GRU - SCL - ORL - CDG", Cost: 45},
+				{Route: "GRU - ORL - CDG", Cost: 61},
+			})
+		})
+
+		g.It("should return the single best route when k is 1", func() {
+			bestRoutes, err := GetBestRoutes(context.Background(), "GRU", "CDG", 1)
+			bestRoute, _ := GetBestRoute(context.Background(), "GRU", "CDG")
+
+			g.Assert(err).Equal(nil)
+			g.Assert(bestRoutes).Equal([]r.BestRoute{bestRoute})
+		})
+
+		g.It("should get the k best routes with at most the given stops", func() {
+			bestRoutes, err := GetBestRoutes(context.Background(), "GRU", "CDG", 3, WithMaxStops(1))
 
-			_, err := GetBestRoute("SCL", "XYZ")
+			g.Assert(err).Equal(nil)
+			g.Assert(bestRoutes).Equal([]r.BestRoute{
+				{Route: "GRU - ORL - CDG", Cost: 61},
+				{Route: "GRU - CDG", Cost: 75},
+			})
+		})
 
+		g.It("should get the k best routes avoiding the given airports", func() {
+			bestRoutes, err := GetBestRoutes(context.Background(), "GRU", "CDG", 3, WithAvoid("BRC"))
+
+			g.Assert(err).Equal(nil)
+			g.Assert(bestRoutes).Equal([]r.BestRoute{
+				{Route: "GRU - SCL - ORL - CDG", Cost: 45},
+				{Route: "GRU - ORL - CDG", Cost: 61},
+				{Route: "GRU - CDG", Cost: 75},
+			})
+		})
+
+		g.It("should return InvalidParameterErr for an out of range k", func() {
+			_, err := GetBestRoutes(context.Background(), "GRU", "CDG", 0)
+			g.Assert(err).Equal(errors.NewInvalidParameterErr("alternatives"))
+
+			_, err = GetBestRoutes(context.Background(), "GRU", "CDG", MaxAlternatives+1)
+			g.Assert(err).Equal(errors.NewInvalidParameterErr("alternatives"))
+		})
+
+		g.It("should return InvalidAirportErr and BestRouteNotFoundErr like GetBestRoute", func() {
+			_, err := GetBestRoutes(context.Background(), "GR", "CDG", 2)
+			g.Assert(err).Equal(errors.NewInvalidAirportErr("malformed").InField("board"))
+
+			_, err = GetBestRoutes(context.Background(), "XYZ", "CDG", 2)
+			g.Assert(err).Equal(errors.NewAirportNotRegisteredErr().InField("board"))
+
+			_, err = GetBestRoutes(context.Background(), "SCL", "GRU", 2)
 			g.Assert(err).Equal(errors.NewBestRouteNotFoundErr())
 		})
 	})
+
+	g.Describe("Tests for DeleteRoute", func() {
+		g.BeforeEach(func() {
+			database.Connect()
+			database.Truncate()
+			cache.Connect()
+			cache.Truncate()
+			file.Reset("test.csv")
+		})
+
+		g.AfterEach(func() {
+			file.Remove()
+		})
+
+		g.It("should delete a route and stop using it in searches", func() {
+			AddNewRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+			AddNewRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "ORL", Cost: 56})
+			AddNewRoute(context.Background(), r.Route{Boarding: "ORL", Destination: "CDG", Cost: 5})
+
+			deleted, err := DeleteRoute(context.Background(), "gru", "orl")
+
+			g.Assert(err).Equal(nil)
+			g.Assert(deleted).Equal(r.Route{Boarding: "GRU", Destination: "ORL", Cost: 56})
+
+			best, _ := GetBestRoute(context.Background(), "GRU", "CDG")
+			routesFromFile, _ := file.ReadFile()
+
+			g.Assert(best).Equal(r.BestRoute{Route: "GRU - CDG", Cost: 75})
+			g.Assert(len(routesFromFile)).Equal(2)
+		})
+
+		g.It("should unregister airports left without routes", func() {
+			AddNewRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+
+			_, err := DeleteRoute(context.Background(), "GRU", "CDG")
+			g.Assert(err).Equal(nil)
+
+			_, err = GetBestRoute(context.Background(), "GRU", "CDG")
+			g.Assert(err).Equal(errors.NewAirportNotRegisteredErr().InField("board"))
+		})
+
+		g.It("should return InvalidAirportErr and RouteNotFoundErr", func() {
+			_, err := DeleteRoute(context.Background(), "GR", "CDG")
+			g.Assert(err).Equal(errors.NewInvalidAirportErr("malformed").InField("board"))
+
+			_, err = DeleteRoute(context.Background(), "GRU", "CDG")
+			g.Assert(err).Equal(errors.NewRouteNotFoundErr())
+		})
+	})
+
+	g.Describe("Tests for UpdateRouteCost", func() {
+		g.BeforeEach(func() {
+			database.Connect()
+			database.Truncate()
+			cache.Connect()
+			cache.Truncate()
+			file.Reset("test.csv")
+		})
+
+		g.AfterEach(func() {
+			file.Remove()
+		})
+
+		g.It("should update the cost used in searches", func() {
+			AddNewRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+			AddNewRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "ORL", Cost: 56})
+			AddNewRoute(context.Background(), r.Route{Boarding: "ORL", Destination: "CDG", Cost: 5})
+
+			updated, err := UpdateRouteCost(context.Background(), r.Route{Boarding: "gru", Destination: "cdg", Cost: 30})
+
+			g.Assert(err).Equal(nil)
+			g.Assert(updated).Equal(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+
+			best, _ := GetBestRoute(context.Background(), "GRU", "CDG")
+
+			g.Assert(best).Equal(r.BestRoute{Route: "GRU - CDG", Cost: 30})
+		})
+
+		g.It("should return InvalidRouteErr and RouteNotFoundErr", func() {
+			_, err := UpdateRouteCost(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: -1})
+			g.Assert(err).Equal(errors.NewInvalidRouteErr())
+
+			_, err = UpdateRouteCost(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+			g.Assert(err).Equal(errors.NewRouteNotFoundErr())
+		})
+	})
+}
+
+func newIsolatedService(t *testing.T, filePath string) *RouteService {
+	db := database.New()
+	routesFile, err := file.Open(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(routesFile.Remove)
+
+	return New(
+		routerepository.New(db, db, cache.New(), routesFile),
+		airportrepository.New(db),
+	)
+}
+
+func TestIsolatedRouteServices(t *testing.T) {
+	costs := map[string]int{"isolated-a.csv": 10, "isolated-b.csv": 20}
+
+	for filePath, cost := range costs {
+		filePath, cost := filePath, cost
+
+		t.Run(filePath, func(t *testing.T) {
+			t.Parallel()
+
+			service := newIsolatedService(t, filePath)
+
+			_, err := service.AddNewRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: cost})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			best, err := service.GetBestRoute(context.Background(), "GRU", "CDG")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if best.Cost != cost {
+				t.Fatalf("expected cost %d, got %d", cost, best.Cost)
+			}
+
+			if airports := service.airports.GetAllAirports(); len(airports) != 2 {
+				t.Fatalf("expected only the airports of this service, got %v", airports)
+			}
+		})
+	}
 }
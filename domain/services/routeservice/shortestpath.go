@@ -1,73 +1,101 @@
 package routeservice
 
 import (
-	"container/heap"
+	"context"
 	r "go-bestflight/domain/entities/routes"
 	"go-bestflight/domain/errors"
+	"go-bestflight/resources/logger"
+	"go-bestflight/resources/metrics"
+	"go-bestflight/resources/tracing"
 	"strings"
+	"time"
 )
 
-type indexes map[interface{}]interface{}
-
-type routesGraph [][]r.Connection
-
-type mapper struct {
-	indxs     indexes
-	distances []int
-	previous  []int
+// edge identifies a connection between two nodes of the graph.
+type edge struct {
+	from int
+	to   int
 }
 
 type dijkstraArgs struct {
-	start int
-	end   int
-	dist  []int
-	prev  []int
-	indxs indexes
-	g     routesGraph
+	start        int
+	end          int
+	dist         []int
+	prev         []int
+	g            *r.Graph
+	removedNodes map[int]bool
+	removedEdges map[edge]bool
+	maxEdges     int // zero means no limit
+	waypoints    []int
+	expanded     *int // counts the nodes expanded by the search, when not nil
+}
+
+// expand counts a node expanded by the search.
+func (args dijkstraArgs) expand() {
+	if args.expanded != nil {
+		*args.expanded++
+	}
 }
 
 const (
 	maxInt = int(^uint(0) >> 1)
 )
 
-func convertRouteToNamed(route []int, indxs indexes) string {
+// checkInterval is the number of nodes a search expands between two checks of its context, so
+// a canceled search stops quickly without paying for a check at every node.
+const checkInterval = 256
+
+// checkContext returns the error of ctx once every checkInterval calls, counted by n.
+func checkContext(ctx context.Context, n int) error {
+	if n%checkInterval != 0 {
+		return nil
+	}
+
+	return ctx.Err()
+}
+
+// searchErr returns the error reported for a search stopped by the error of its context.
+func searchErr(err error) error {
+	if err == context.DeadlineExceeded {
+		return errors.NewQueryTimeoutErr()
+	}
+
+	return errors.NewQueryCanceledErr()
+}
+
+func convertRouteToNamed(route []int, g *r.Graph) string {
 	airports := []string{}
 
 	for _, node := range route {
-		airports = append(airports, indxs[node].(string))
+		airports = append(airports, g.Airport(node))
 	}
 
 	return strings.Join(airports, " - ")
 }
 
-func buildMapper(airports []string) mapper {
-	indxs := make(indexes)
-	distances := make([]int, len(airports))
-	previous := make([]int, len(airports))
+func newDistances(size int) ([]int, []int) {
+	distances := make([]int, size)
+	previous := make([]int, size)
 
-	for i, airport := range airports {
-		indxs[airport] = i
-		indxs[i] = airport
+	for i := range distances {
 		distances[i] = maxInt
 		previous[i] = -1
 	}
 
-	return mapper{
-		indxs:     indxs,
-		distances: distances,
-		previous:  previous,
-	}
+	return distances, previous
 }
 
-func buildGraph(routes r.Routes, indxs indexes, graphSize int) routesGraph {
-	graph := make([][]r.Connection, graphSize)
+func newDijkstraArgs(g *r.Graph, start, end int) dijkstraArgs {
+	dist, prev := newDistances(g.Size())
 
-	for boarding, destinations := range routes {
-		i := indxs[boarding].(int)
-		graph[i] = destinations
+	return dijkstraArgs{
+		start:    start,
+		end:      end,
+		dist:     dist,
+		prev:     prev,
+		g:        g,
+		expanded: new(int),
 	}
-
-	return graph
 }
 
 func reverseRoute(route []int) []int {
@@ -97,81 +125,154 @@ func reconstructRoute(start, end int, previous []int) []int {
 }
 
 // DijkstraSTP implements the Dijkstra's Shortest Path algorithm.
-func DijkstraSTP(args dijkstraArgs) ([]int, int) {
-	pq := NewPriorityQueue()
+// The queue is keyed by node, so a node found through a shorter path has its
+// priority decreased instead of being pushed again. It stops with the error of
+// ctx once ctx is canceled.
+func DijkstraSTP(ctx context.Context, args dijkstraArgs) ([]int, int, error) {
+	pq := NewPriorityQueue(len(args.dist))
 	visited := make([]bool, len(args.dist))
 
 	args.dist[args.start] = 0
 	args.prev[args.start] = args.start
-	heap.Push(pq, &Item{
-		node:     args.start,
-		priority: 0,
-	})
+	pq.Push(args.start, 0)
+
+	for popped := 0; pq.Len() != 0; popped++ {
+		if err := checkContext(ctx, popped); err != nil {
+			return []int{}, -1, err
+		}
 
-	for pq.Len() != 0 {
-		var nodeMinDistance *Item = heap.Pop(pq).(*Item)
+		node, distance := pq.Pop()
+		visited[node] = true
+		args.expand()
 
-		if visited[nodeMinDistance.node] {
-			continue
+		if node == args.end {
+			break
 		}
 
-		for _, destination := range args.g[nodeMinDistance.node] {
-			destinationNode := args.indxs[destination.Airport].(int)
-			newDistance := args.dist[nodeMinDistance.node] + destination.Cost
+		for _, destination := range args.g.Edges(node) {
+			destinationNode := destination.To
+
+			if visited[destinationNode] ||
+				args.removedNodes[destinationNode] ||
+				args.removedEdges[edge{from: node, to: destinationNode}] {
+				continue
+			}
+
+			newDistance := distance + destination.Cost
 
 			if newDistance >= args.dist[destinationNode] {
 				continue
 			}
 
 			args.dist[destinationNode] = newDistance
-			args.prev[destinationNode] = nodeMinDistance.node
-
-			// Lazy implementation, but better than using an update on the current
-			// PriorityQueue implementation.
-			heap.Push(pq, &Item{
-				node:     destinationNode,
-				priority: destination.Cost,
-			})
-		}
-
-		visited[nodeMinDistance.node] = true
+			args.prev[destinationNode] = node
 
-		if nodeMinDistance.node == args.end {
-			break
+			if pq.Contains(destinationNode) {
+				pq.DecreaseKey(destinationNode, newDistance)
+			} else {
+				pq.Push(destinationNode, newDistance)
+			}
 		}
 	}
 
 	if args.prev[args.end] == -1 {
-		return []int{}, -1
+		return []int{}, -1, nil
 	}
 
 	bestRoute := reconstructRoute(args.start, args.end, args.prev)
 	cost := args.dist[args.end]
 
-	return bestRoute, cost
+	return bestRoute, cost, nil
+}
+
+// The algorithms of the searches, as reported by the metrics.
+const (
+	dijkstraAlgorithm   = "dijkstra"
+	hopBoundedAlgorithm = "hop_bounded"
+	waypointAlgorithm   = "waypoint"
+	yenAlgorithm        = "yen"
+)
+
+// algorithm tells which algorithm shortestPath uses for the arguments.
+func algorithm(args dijkstraArgs) string {
+	if len(args.waypoints) > 0 {
+		return waypointAlgorithm
+	}
+
+	if args.maxEdges > 0 {
+		return hopBoundedAlgorithm
+	}
+
+	return dijkstraAlgorithm
+}
+
+// shortestPath searches with WaypointSTP when there are waypoints, with HopBoundedSTP
+// when the number of connections is limited and with DijkstraSTP otherwise.
+func shortestPath(ctx context.Context, args dijkstraArgs) ([]int, int, error) {
+	switch algorithm(args) {
+	case waypointAlgorithm:
+		return WaypointSTP(ctx, args)
+	case hopBoundedAlgorithm:
+		return HopBoundedSTP(ctx, args)
+	default:
+		return DijkstraSTP(ctx, args)
+	}
+}
+
+// observeSearch records, logs and adds to the span of ctx the duration and the nodes expanded by a
+// search started at start.
+func observeSearch(ctx context.Context, name string, args dijkstraArgs, start time.Time) {
+	latency := time.Since(start)
+
+	metrics.SearchDuration.Observe(latency.Seconds(), name)
+	metrics.SearchNodesExpanded.Observe(float64(*args.expanded), name)
+
+	if span := tracing.FromContext(ctx); span != nil {
+		span.SetAttributes("algorithm", name, "expanded", *args.expanded)
+	}
+
+	logger.FromContext(ctx).Debug("search finished", "algorithm", name, "board", args.g.Airport(args.start),
+		"dest", args.g.Airport(args.end), "expanded", *args.expanded, "latency", latency)
 }
 
-func findBestRoute(airports []string, routes r.Routes, boarding, destination string) (r.BestRoute, error) {
-	m := buildMapper(airports)
-	g := buildGraph(routes, m.indxs, len(m.distances))
-	args := dijkstraArgs{
-		start: m.indxs[boarding].(int),
-		end:   m.indxs[destination].(int),
-		dist:  m.distances,
-		indxs: m.indxs,
-		prev:  m.previous,
-		g:     g,
+func findBestRoute(ctx context.Context, g *r.Graph, boarding, destination string, opts searchOptions) (r.BestRoute, error) {
+	ctx, span := tracing.Start(ctx, "search", "board", boarding, "dest", destination)
+	defer span.End()
+
+	start, okStart := g.Index(boarding)
+	end, okEnd := g.Index(destination)
+
+	if !okStart || !okEnd {
+		return r.BestRoute{}, errors.NewBestRouteNotFoundErr()
+	}
+
+	args := newDijkstraArgs(g, start, end)
+
+	if err := opts.apply(&args); err != nil {
+		return r.BestRoute{}, err
+	}
+
+	searchStart := time.Now()
+	bestRoute, cost, err := shortestPath(ctx, args)
+	observeSearch(ctx, algorithm(args), args, searchStart)
+
+	if err != nil {
+		err = searchErr(err)
+		span.RecordError(err)
+
+		return r.BestRoute{}, err
 	}
-	bestRoute, cost := DijkstraSTP(args)
 
 	if cost == maxInt || cost == -1 {
 		return r.BestRoute{}, errors.NewBestRouteNotFoundErr()
 	}
 
 	best := r.BestRoute{
-		Route: convertRouteToNamed(bestRoute, m.indxs),
+		Route: convertRouteToNamed(bestRoute, g),
 		Cost:  cost,
 	}
 
+	span.SetAttributes("cost", cost)
+
 	return best, nil
 }
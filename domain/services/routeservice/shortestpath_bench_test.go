@@ -0,0 +1,135 @@
+package routeservice
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	r "go-bestflight/domain/entities/routes"
+	"math/rand"
+	"testing"
+)
+
+// lazyItem and lazyQueue keep the previous approach of DijkstraSTP as a reference:
+// a container/heap queue where a node is pushed again every time its distance
+// decreases and the outdated entries are skipped when popped.
+type lazyItem struct {
+	node     int
+	priority int
+}
+
+type lazyQueue []lazyItem
+
+func (q lazyQueue) Len() int            { return len(q) }
+func (q lazyQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q lazyQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *lazyQueue) Push(x interface{}) { *q = append(*q, x.(lazyItem)) }
+func (q *lazyQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func lazyDijkstraSTP(args dijkstraArgs) int {
+	q := &lazyQueue{}
+	visited := make([]bool, len(args.dist))
+
+	args.dist[args.start] = 0
+	heap.Push(q, lazyItem{node: args.start, priority: 0})
+
+	for q.Len() != 0 {
+		item := heap.Pop(q).(lazyItem)
+
+		if visited[item.node] {
+			continue
+		}
+
+		visited[item.node] = true
+
+		if item.node == args.end {
+			break
+		}
+
+		for _, destination := range args.g.Edges(item.node) {
+			newDistance := args.dist[item.node] + destination.Cost
+
+			if newDistance >= args.dist[destination.To] {
+				continue
+			}
+
+			args.dist[destination.To] = newDistance
+			heap.Push(q, lazyItem{node: destination.To, priority: newDistance})
+		}
+	}
+
+	if args.dist[args.end] == maxInt {
+		return -1
+	}
+
+	return args.dist[args.end]
+}
+
+// generateGraph builds a random network of airports with the given average of connections each.
+func generateGraph(airports, connections int, seed int64) *r.Graph {
+	random := rand.New(rand.NewSource(seed))
+	routes := make([]r.Route, 0, airports*connections)
+
+	for i := 0; i < airports*connections; i++ {
+		routes = append(routes, r.Route{
+			Boarding:    fmt.Sprintf("A%d", random.Intn(airports)),
+			Destination: fmt.Sprintf("A%d", random.Intn(airports)),
+			Cost:        random.Intn(1000) + 1,
+		})
+	}
+
+	return r.NewGraph().WithRoutes(routes)
+}
+
+func TestDijkstraSTPOnGeneratedGraphs(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		graph := generateGraph(300, 4, seed)
+		random := rand.New(rand.NewSource(seed))
+
+		for i := 0; i < 20; i++ {
+			start := random.Intn(graph.Size())
+			end := random.Intn(graph.Size())
+
+			route, cost, _ := DijkstraSTP(context.Background(), newDijkstraArgs(graph, start, end))
+			expectedCost := lazyDijkstraSTP(newDijkstraArgs(graph, start, end))
+
+			if cost != expectedCost {
+				t.Fatalf("seed %d, %d to %d: expected cost %d, got %d", seed, start, end, expectedCost, cost)
+			}
+
+			if cost != -1 && pathCost(route, graph) != cost {
+				t.Fatalf("seed %d, %d to %d: route %v does not cost %d", seed, start, end, route, cost)
+			}
+		}
+	}
+}
+
+func benchmarkSearch(b *testing.B, airports int, search func(args dijkstraArgs)) {
+	graph := generateGraph(airports, 8, 42)
+	random := rand.New(rand.NewSource(42))
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		start := random.Intn(graph.Size())
+		end := random.Intn(graph.Size())
+
+		search(newDijkstraArgs(graph, start, end))
+	}
+}
+
+func BenchmarkDijkstraSTP(b *testing.B) {
+	for _, airports := range []int{1000, 20000} {
+		b.Run(fmt.Sprintf("indexed/%d", airports), func(b *testing.B) {
+			benchmarkSearch(b, airports, func(args dijkstraArgs) { DijkstraSTP(context.Background(), args) })
+		})
+
+		b.Run(fmt.Sprintf("lazy/%d", airports), func(b *testing.B) {
+			benchmarkSearch(b, airports, func(args dijkstraArgs) { lazyDijkstraSTP(args) })
+		})
+	}
+}
@@ -1,72 +1,63 @@
 package routeservice
 
 import (
+	"context"
 	r "go-bestflight/domain/entities/routes"
+	e "go-bestflight/domain/errors"
+	"go-bestflight/resources/metrics"
 	"testing"
+	"time"
 
 	"github.com/franela/goblin"
 )
 
-func TestShortestPath(t *testing.T) {
-	g := goblin.Goblin(t)
+// Tests based on:
+//
+//	GRU,BRC,10
+//	BRC,SCL,5
+//	GRU,CDG,75
+//	GRU,SCL,20
+//	GRU,ORL,56
+//	ORL,CDG,5
+//	SCL,ORL,20
+var testRoutes = []r.Route{
+	{Boarding: "GRU", Destination: "BRC", Cost: 10},
+	{Boarding: "BRC", Destination: "SCL", Cost: 5},
+	{Boarding: "GRU", Destination: "CDG", Cost: 75},
+	{Boarding: "GRU", Destination: "SCL", Cost: 20},
+	{Boarding: "GRU", Destination: "ORL", Cost: 56},
+	{Boarding: "ORL", Destination: "CDG", Cost: 5},
+	{Boarding: "SCL", Destination: "ORL", Cost: 20},
+}
 
-	airports := []string{
-		"ORL",
-		"BRC",
-		"GRU",
-		"CDG",
-		"SCL",
-	}
-
-	routes := r.Routes{
-		"GRU": []r.Connection{
-			{Airport: "BRC", Cost: 10},
-			{Airport: "CDG", Cost: 75},
-			{Airport: "SCL", Cost: 20},
-			{Airport: "ORL", Cost: 56},
-		},
-		"BRC": []r.Connection{
-			{Airport: "SCL", Cost: 5},
-		},
-		"ORL": []r.Connection{
-			{Airport: "CDG", Cost: 5},
-		},
-		"SCL": []r.Connection{
-			{Airport: "ORL", Cost: 20},
-		},
-	}
-
-	g.Describe("Tests for buildMapper", func() {
-		g.It("should successfully build a mapper indexes, distances and previous", func() {
-			maxInt := int(^uint(0) >> 1)
-			m := buildMapper(airports)
+func indexOf(g *r.Graph, airport string) int {
+	index, _ := g.Index(airport)
 
-			g.Assert(m.indxs[airports[0]]).Equal(0)
-			g.Assert(m.indxs[airports[1]]).Equal(1)
-			g.Assert(m.indxs[airports[2]]).Equal(2)
-			g.Assert(m.indxs[airports[3]]).Equal(3)
-			g.Assert(m.indxs[airports[4]]).Equal(4)
+	return index
+}
 
-			g.Assert(len(m.distances)).Equal(len(airports))
+func newTestArgs(g *r.Graph, start, end string) dijkstraArgs {
+	return newDijkstraArgs(g, indexOf(g, start), indexOf(g, end))
+}
 
-			for _, distance := range m.distances {
-				g.Assert(distance).Equal(maxInt)
-			}
-		})
-	})
+func TestShortestPath(t *testing.T) {
+	g := goblin.Goblin(t)
 
-	g.Describe("Tests for buildGraph", func() {
-		g.It("should successfully build a graph", func() {
-			m := buildMapper(airports)
-			graph := buildGraph(routes, m.indxs, len(m.distances))
+	graph := r.NewGraph().WithRoutes(testRoutes)
 
-			g.Assert(len(graph)).Equal(len(m.distances))
+	g.Describe("Tests for newDijkstraArgs", func() {
+		g.It("should successfully build the distances and previous for every airport", func() {
+			maxInt := int(^uint(0) >> 1)
+			args := newTestArgs(graph, "GRU", "CDG")
 
-			for _, airport := range airports {
-				airportIndex := m.indxs[airport].(int)
+			g.Assert(args.start).Equal(indexOf(graph, "GRU"))
+			g.Assert(args.end).Equal(indexOf(graph, "CDG"))
+			g.Assert(len(args.dist)).Equal(graph.Size())
+			g.Assert(len(args.prev)).Equal(graph.Size())
 
-				g.Assert(len(graph[airportIndex])).Equal(len(routes[airport]))
-				g.Assert(graph[airportIndex]).Equal(routes[airport])
+			for i := range args.dist {
+				g.Assert(args.dist[i]).Equal(maxInt)
+				g.Assert(args.prev[i]).Equal(-1)
 			}
 		})
 	})
@@ -97,7 +88,7 @@ func TestShortestPath(t *testing.T) {
 			// 	GRU,ORL,56
 			// 	ORL,CDG,5
 			// 	SCL,ORL,20
-			// Where m.indxs are:
+			// Where the indexes are:
 			// 	GRU = 0
 			// 	BRC = 1
 			// 	SCL = 2
@@ -122,7 +113,7 @@ func TestShortestPath(t *testing.T) {
 			// 	GRU,ORL,56
 			// 	ORL,CDG,5
 			// 	SCL,ORL,20
-			// Where m.indxs are:
+			// Where the indexes are:
 			// 	GRU = 0
 			// 	BRC = 1
 			// 	SCL = 2
@@ -141,187 +132,131 @@ func TestShortestPath(t *testing.T) {
 
 	g.Describe("Tests for DijkstraSTP", func() {
 		g.It("should retrieve the shortest path for a short distance", func() {
-			m := buildMapper(airports)
-			gph := buildGraph(routes, m.indxs, len(m.distances))
-			args := dijkstraArgs{
-				start: m.indxs["BRC"].(int),
-				end:   m.indxs["SCL"].(int),
-				dist:  m.distances,
-				prev:  m.previous,
-				indxs: m.indxs,
-				g:     gph,
-			}
-			bestRoute, cost := DijkstraSTP(args)
-
-			expectedRoute := []int{
-				m.indxs["BRC"].(int),
-				m.indxs["SCL"].(int),
-			}
+			args := newTestArgs(graph, "BRC", "SCL")
+			bestRoute, cost, _ := DijkstraSTP(context.Background(), args)
 
-			g.Assert(bestRoute).Equal(expectedRoute)
+			g.Assert(convertRouteToNamed(bestRoute, graph)).Equal("BRC - SCL")
 			g.Assert(cost).Equal(5)
 		})
 
+		g.It("should count the nodes it expands", func() {
+			args := newTestArgs(graph, "BRC", "SCL")
+			DijkstraSTP(context.Background(), args)
+
+			g.Assert(*args.expanded).Equal(2)
+		})
+
 		g.It("should retrieve the shortest path for a long distance", func() {
-			m := buildMapper(airports)
-			graph := buildGraph(routes, m.indxs, len(m.distances))
-			args := dijkstraArgs{
-				start: m.indxs["GRU"].(int),
-				end:   m.indxs["CDG"].(int),
-				dist:  m.distances,
-				prev:  m.previous,
-				indxs: m.indxs,
-				g:     graph,
-			}
-			bestRoute, cost := DijkstraSTP(args)
-
-			expectedRoute := []int{
-				m.indxs["GRU"].(int),
-				m.indxs["BRC"].(int),
-				m.indxs["SCL"].(int),
-				m.indxs["ORL"].(int),
-				m.indxs["CDG"].(int),
-			}
+			args := newTestArgs(graph, "GRU", "CDG")
+			bestRoute, cost, _ := DijkstraSTP(context.Background(), args)
 
-			g.Assert(bestRoute).Equal(expectedRoute)
+			g.Assert(convertRouteToNamed(bestRoute, graph)).Equal("GRU - BRC - SCL - ORL - CDG")
 			g.Assert(cost).Equal(40)
 		})
 
 		g.It("should retrieve the shortest path after add new routes", func() {
-			newRoutes := make(r.Routes)
-			for k, v := range routes {
-				newRoutes[k] = v
-			}
-			newRoutes["X"] = []r.Connection{
-				{Airport: "GRU", Cost: 7},
-			}
-			newAirports := append(airports, "X")
-
-			m := buildMapper(newAirports)
-			graph := buildGraph(newRoutes, m.indxs, len(m.distances))
-
-			args := dijkstraArgs{
-				start: m.indxs["X"].(int),
-				end:   m.indxs["GRU"].(int),
-				dist:  m.distances,
-				prev:  m.previous,
-				indxs: m.indxs,
-				g:     graph,
-			}
-
-			bestRoute, cost := DijkstraSTP(args)
+			newGraph := graph.WithRoute(r.Route{Boarding: "X", Destination: "GRU", Cost: 7})
+			args := newTestArgs(newGraph, "X", "GRU")
+			bestRoute, cost, _ := DijkstraSTP(context.Background(), args)
 
-			expectedRoute := []int{
-				m.indxs["X"].(int),
-				m.indxs["GRU"].(int),
-			}
-
-			g.Assert(bestRoute).Equal(expectedRoute)
+			g.Assert(convertRouteToNamed(bestRoute, newGraph)).Equal("X - GRU")
 			g.Assert(cost).Equal(7)
 		})
 
 		g.It("should retrieve the shortest path even with reversed routes", func() {
-			newRoutes := make(r.Routes)
-			for k, v := range routes {
-				newRoutes[k] = v
-			}
-			newRoutes["CDG"] = []r.Connection{
-				{Airport: "GRU", Cost: 70},
-			}
-			newRoutes["ORL"] = append(newRoutes["ORL"], r.Connection{Airport: "GRU", Cost: 50})
-
-			m := buildMapper(airports)
-			graph := buildGraph(newRoutes, m.indxs, len(m.distances))
-
-			args := dijkstraArgs{
-				start: m.indxs["GRU"].(int),
-				end:   m.indxs["CDG"].(int),
-				dist:  m.distances,
-				prev:  m.previous,
-				indxs: m.indxs,
-				g:     graph,
-			}
+			newGraph := graph.WithRoutes([]r.Route{
+				{Boarding: "CDG", Destination: "GRU", Cost: 70},
+				{Boarding: "ORL", Destination: "GRU", Cost: 50},
+			})
+			args := newTestArgs(newGraph, "GRU", "CDG")
+			bestRoute, cost, _ := DijkstraSTP(context.Background(), args)
+
+			g.Assert(convertRouteToNamed(bestRoute, newGraph)).Equal("GRU - BRC - SCL - ORL - CDG")
+			g.Assert(cost).Equal(40)
+		})
 
-			bestRoute, cost := DijkstraSTP(args)
+		g.It("should retrieve the shortest path after add new routes disassociated from others", func() {
+			newGraph := graph.WithRoutes([]r.Route{
+				{Boarding: "X", Destination: "Y", Cost: 15},
+				{Boarding: "Y", Destination: "X", Cost: 15},
+				{Boarding: "Y", Destination: "Z", Cost: 16},
+			})
+			args := newTestArgs(newGraph, "X", "Z")
+			bestRoute, cost, _ := DijkstraSTP(context.Background(), args)
+
+			g.Assert(convertRouteToNamed(bestRoute, newGraph)).Equal("X - Y - Z")
+			g.Assert(cost).Equal(31)
+		})
 
-			expectedRoute := []int{
-				m.indxs["GRU"].(int),
-				m.indxs["BRC"].(int),
-				m.indxs["SCL"].(int),
-				m.indxs["ORL"].(int),
-				m.indxs["CDG"].(int),
-			}
+		g.It("should retrieve cost equal -1 for unreachable connection", func() {
+			newGraph := graph.WithRoute(r.Route{Boarding: "X", Destination: "Y", Cost: 15})
+			args := newTestArgs(newGraph, "CDG", "X") // can not go from CDG to X
+			bestRoute, cost, _ := DijkstraSTP(context.Background(), args)
 
-			g.Assert(bestRoute).Equal(expectedRoute)
-			g.Assert(cost).Equal(40)
+			g.Assert(bestRoute).Equal([]int{})
+			g.Assert(cost).Equal(-1)
 		})
 
-		g.It("should retrieve the shortest path after add new routes disassociated from others", func() {
-			newRoutes := make(r.Routes)
-			for k, v := range routes {
-				newRoutes[k] = v
-			}
-			newRoutes["X"] = []r.Connection{
-				{Airport: "Y", Cost: 15},
-			}
-			newRoutes["Y"] = []r.Connection{
-				{Airport: "X", Cost: 15},
-				{Airport: "Z", Cost: 16},
-			}
-			newAirports := append(airports, "X", "Y", "Z")
-
-			m := buildMapper(newAirports)
-			graph := buildGraph(newRoutes, m.indxs, len(m.distances))
-
-			args := dijkstraArgs{
-				start: m.indxs["X"].(int),
-				end:   m.indxs["Z"].(int),
-				dist:  m.distances,
-				prev:  m.previous,
-				indxs: m.indxs,
-				g:     graph,
-			}
+		g.It("should stop with the error of a canceled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
 
-			bestRoute, cost := DijkstraSTP(args)
+			bestRoute, cost, err := DijkstraSTP(ctx, newTestArgs(graph, "GRU", "CDG"))
 
-			expectedRoute := []int{
-				m.indxs["X"].(int),
-				m.indxs["Y"].(int),
-				m.indxs["Z"].(int),
-			}
+			g.Assert(err).Equal(context.Canceled)
+			g.Assert(bestRoute).Equal([]int{})
+			g.Assert(cost).Equal(-1)
+		})
+	})
 
-			g.Assert(bestRoute).Equal(expectedRoute)
-			g.Assert(cost).Equal(31)
+	g.Describe("Tests for findBestRoute", func() {
+		g.It("should find the best route by airport names", func() {
+			best, err := findBestRoute(context.Background(), graph, "GRU", "SCL", searchOptions{})
+
+			g.Assert(err).Equal(nil)
+			g.Assert(best).Equal(r.BestRoute{Route: "GRU - BRC - SCL", Cost: 15})
 		})
 
-		g.It("should retrieve cost equal -1 for unreachable connection", func() {
-			newRoutes := make(r.Routes)
-			for k, v := range routes {
-				newRoutes[k] = v
-			}
-			newRoutes["X"] = []r.Connection{
-				{Airport: "Y", Cost: 15},
-			}
-			newAirports := append(airports, "X", "Y")
-
-			m := buildMapper(newAirports)
-			graph := buildGraph(newRoutes, m.indxs, len(m.distances))
-
-			args := dijkstraArgs{
-				start: m.indxs["CDG"].(int), // can not go from CDG to X
-				end:   m.indxs["X"].(int),
-				dist:  m.distances,
-				prev:  m.previous,
-				indxs: m.indxs,
-				g:     graph,
+		g.It("should observe the search by algorithm", func() {
+			dijkstra := metrics.SearchDuration.Count(dijkstraAlgorithm)
+			hopBounded := metrics.SearchNodesExpanded.Count(hopBoundedAlgorithm)
+
+			findBestRoute(context.Background(), graph, "GRU", "CDG", searchOptions{})
+			findBestRoute(context.Background(), graph, "GRU", "CDG", searchOptions{limitStops: true, maxStops: 1})
+
+			g.Assert(metrics.SearchDuration.Count(dijkstraAlgorithm)).Equal(dijkstra + 1)
+			g.Assert(metrics.SearchNodesExpanded.Count(hopBoundedAlgorithm)).Equal(hopBounded + 1)
+		})
+
+		g.It("should return BestRouteNotFoundErr for airports out of the graph", func() {
+			_, err := findBestRoute(context.Background(), graph, "GRU", "XYZ", searchOptions{})
+
+			g.Assert(err != nil).IsTrue()
+		})
+
+		g.It("should return QueryTimeoutErr once the deadline passed, whatever the algorithm", func() {
+			ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			defer cancel()
+
+			for _, opts := range []searchOptions{
+				{},
+				{limitStops: true, maxStops: 2},
+				{via: []string{"ORL"}},
+				{via: []string{"ORL"}, limitStops: true, maxStops: 2},
+			} {
+				_, err := findBestRoute(ctx, graph, "GRU", "CDG", opts)
+
+				g.Assert(err).Equal(e.NewQueryTimeoutErr())
 			}
+		})
 
-			bestRoute, cost := DijkstraSTP(args)
+		g.It("should return QueryCanceledErr once the context is canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
 
-			expectedRoute := []int{}
+			_, err := findBestRoute(ctx, graph, "GRU", "CDG", searchOptions{})
 
-			g.Assert(bestRoute).Equal(expectedRoute)
-			g.Assert(cost).Equal(-1)
+			g.Assert(err).Equal(e.NewQueryCanceledErr())
 		})
 	})
 }
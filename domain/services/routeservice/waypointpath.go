@@ -0,0 +1,118 @@
+package routeservice
+
+import "context"
+
+func segmentArgs(args dijkstraArgs, start, end int) dijkstraArgs {
+	dist, prev := newDistances(len(args.dist))
+
+	return dijkstraArgs{
+		start:        start,
+		end:          end,
+		dist:         dist,
+		prev:         prev,
+		g:            args.g,
+		removedNodes: args.removedNodes,
+		removedEdges: args.removedEdges,
+		maxEdges:     args.maxEdges,
+		expanded:     args.expanded,
+	}
+}
+
+func lastLayer(layers [][]int, hops int) int {
+	if hops > len(layers)-1 {
+		return len(layers) - 1
+	}
+
+	return hops
+}
+
+// WaypointSTP finds the shortest path from args.start to args.end passing through
+// every args.waypoints node in the given order. Without a limit of connections it
+// joins the shortest path of every segment between consecutive stops.
+func WaypointSTP(ctx context.Context, args dijkstraArgs) ([]int, int, error) {
+	stops := append(append([]int{args.start}, args.waypoints...), args.end)
+
+	if args.maxEdges > 0 {
+		return hopBoundedWaypointSTP(ctx, args, stops)
+	}
+
+	route := []int{args.start}
+	cost := 0
+
+	for i := 0; i < len(stops)-1; i++ {
+		segment, segmentCost, err := DijkstraSTP(ctx, segmentArgs(args, stops[i], stops[i+1]))
+		if err != nil {
+			return []int{}, -1, err
+		}
+
+		if segmentCost == maxInt || segmentCost == -1 {
+			return []int{}, -1, nil
+		}
+
+		route = append(route, segment[1:]...)
+		cost += segmentCost
+	}
+
+	return route, cost, nil
+}
+
+// hopBoundedWaypointSTP shares the limit of connections among the segments. The best
+// cost of every segment is known for each number of connections, so the budget is
+// split among them the same way a knapsack problem is solved.
+func hopBoundedWaypointSTP(ctx context.Context, args dijkstraArgs, stops []int) ([]int, int, error) {
+	segments := len(stops) - 1
+	layers := make([][][]int, segments)
+	choices := make([][]int, segments)
+	best := make([]int, args.maxEdges+1)
+
+	for i := 0; i < segments; i++ {
+		distances, previous, err := hopBoundedLayers(ctx, segmentArgs(args, stops[i], stops[i+1]))
+		if err != nil {
+			return []int{}, -1, err
+		}
+
+		next := make([]int, args.maxEdges+1)
+		choices[i] = make([]int, args.maxEdges+1)
+		layers[i] = previous
+
+		for budget := range next {
+			next[budget] = maxInt
+
+			for hops := 1; hops <= budget; hops++ {
+				segmentCost := distances[lastLayer(distances, hops)][stops[i+1]]
+				previousCost := best[budget-hops]
+
+				if segmentCost == maxInt || previousCost == maxInt {
+					continue
+				}
+
+				if previousCost+segmentCost < next[budget] {
+					next[budget] = previousCost + segmentCost
+					choices[i][budget] = hops
+				}
+			}
+		}
+
+		best = next
+	}
+
+	cost := best[args.maxEdges]
+
+	if cost == maxInt {
+		return []int{}, -1, nil
+	}
+
+	route := []int{}
+	budget := args.maxEdges
+
+	for i := segments - 1; i >= 0; i-- {
+		hops := choices[i][budget]
+		previous := layers[i][:lastLayer(layers[i], hops)+1]
+		segment := reconstructHopBoundedRoute(stops[i], stops[i+1], previous)
+
+		route = append(segment[:len(segment)-1], route...)
+		budget -= hops
+	}
+
+	return append(route, args.end), cost, nil
+}
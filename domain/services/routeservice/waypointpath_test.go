@@ -0,0 +1,73 @@
+package routeservice
+
+import (
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestWaypointPath(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	graph := r.NewGraph().WithRoutes(testRoutes)
+
+	newArgs := func(start, end string, waypoints ...string) dijkstraArgs {
+		args := newTestArgs(graph, start, end)
+
+		for _, waypoint := range waypoints {
+			args.waypoints = append(args.waypoints, indexOf(graph, waypoint))
+		}
+
+		return args
+	}
+
+	g.Describe("Tests for WaypointSTP", func() {
+		g.It("should retrieve the shortest path passing through the waypoints", func() {
+			bestRoute, cost, _ := WaypointSTP(context.Background(), newArgs("GRU", "CDG", "SCL"))
+
+			g.Assert(convertRouteToNamed(bestRoute, graph)).Equal("GRU - BRC - SCL - ORL - CDG")
+			g.Assert(cost).Equal(40)
+		})
+
+		g.It("should retrieve the shortest path passing through the waypoints avoiding nodes", func() {
+			args := newArgs("GRU", "CDG", "ORL")
+			args.removedNodes = map[int]bool{indexOf(graph, "SCL"): true}
+			bestRoute, cost, _ := WaypointSTP(context.Background(), args)
+
+			g.Assert(convertRouteToNamed(bestRoute, graph)).Equal("GRU - ORL - CDG")
+			g.Assert(cost).Equal(61)
+		})
+
+		g.It("should share the limit of connections among the segments", func() {
+			args := newArgs("GRU", "CDG", "SCL")
+			args.maxEdges = 3
+			bestRoute, cost, _ := WaypointSTP(context.Background(), args)
+
+			g.Assert(convertRouteToNamed(bestRoute, graph)).Equal("GRU - SCL - ORL - CDG")
+			g.Assert(cost).Equal(45)
+
+			args = newArgs("GRU", "CDG", "BRC", "ORL")
+			args.maxEdges = 4
+			bestRoute, cost, _ = WaypointSTP(context.Background(), args)
+
+			g.Assert(convertRouteToNamed(bestRoute, graph)).Equal("GRU - BRC - SCL - ORL - CDG")
+			g.Assert(cost).Equal(40)
+		})
+
+		g.It("should retrieve cost equal -1 when no path passes through the waypoints", func() {
+			args := newArgs("GRU", "CDG", "BRC")
+			args.maxEdges = 3
+			bestRoute, cost, _ := WaypointSTP(context.Background(), args)
+
+			g.Assert(bestRoute).Equal([]int{})
+			g.Assert(cost).Equal(-1)
+
+			bestRoute, cost, _ = WaypointSTP(context.Background(), newArgs("GRU", "ORL", "CDG"))
+
+			g.Assert(bestRoute).Equal([]int{})
+			g.Assert(cost).Equal(-1)
+		})
+	})
+}
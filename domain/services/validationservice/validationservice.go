@@ -2,7 +2,6 @@ package validationservice
 
 import (
 	r "go-bestflight/domain/entities/routes"
-	"log"
 	"regexp"
 )
 
@@ -11,18 +10,15 @@ const (
 	max = 1000000
 )
 
+// airportPattern is compiled once, as imports validate the airports of every line.
+var airportPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
 func isValidCost(cost int) bool {
 	return (cost >= min) && (cost <= max)
 }
 
 func IsValidAirport(airport string) bool {
-	pattern := `^[A-Z]{3}$`
-	match, err := regexp.MatchString(pattern, airport)
-	if err != nil {
-		log.Println(err)
-	}
-
-	return match
+	return airportPattern.MatchString(airport)
 }
 
 // IsValidRoute ...
@@ -0,0 +1,113 @@
+package airportfile
+
+import (
+	"encoding/csv"
+	"go-bestflight/domain/entities/airports"
+	"go-bestflight/resources/logger"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Columns of the OpenFlights airports.dat format.
+const (
+	nameColumn = iota + 1
+	cityColumn
+	countryColumn
+	iataColumn
+	icaoColumn
+	latitudeColumn
+	longitudeColumn
+	altitudeColumn
+	offsetColumn
+	dstColumn
+	timezoneColumn
+	columns
+)
+
+// null is how OpenFlights represents missing values.
+const null = `\N`
+
+// ReadFile reads an airports reference file in the OpenFlights airports.dat format.
+// Lines without an IATA code or that can not be parsed are skipped.
+func ReadFile(filePath string) ([]airports.Airport, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		logger.Error("could not open the airports file", "path", filePath, "error", err)
+		return []airports.Airport{}, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
+
+// Read reads airports in the OpenFlights airports.dat format from the reader.
+func Read(reader io.Reader) ([]airports.Airport, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	result := []airports.Airport{}
+	lineNumber := 0
+
+	for {
+		fields, err := csvReader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+
+		lineNumber++
+
+		if err != nil {
+			logger.Warn("invalid airport line", "line", lineNumber, "error", err)
+			continue
+		}
+
+		airport, ok := fieldsToAirport(fields)
+		if !ok {
+			continue
+		}
+
+		result = append(result, airport)
+	}
+}
+
+func value(field string) string {
+	if field == null {
+		return ""
+	}
+
+	return strings.TrimSpace(field)
+}
+
+func fieldsToAirport(fields []string) (airports.Airport, bool) {
+	if len(fields) < columns {
+		return airports.Airport{}, false
+	}
+
+	code := strings.ToUpper(value(fields[iataColumn]))
+	if len(code) != 3 {
+		return airports.Airport{}, false
+	}
+
+	latitude, err := strconv.ParseFloat(fields[latitudeColumn], 64)
+	if err != nil {
+		return airports.Airport{}, false
+	}
+
+	longitude, err := strconv.ParseFloat(fields[longitudeColumn], 64)
+	if err != nil {
+		return airports.Airport{}, false
+	}
+
+	airport := airports.Airport{
+		Code:     code,
+		Name:     value(fields[nameColumn]),
+		City:     value(fields[cityColumn]),
+		Country:  value(fields[countryColumn]),
+		Timezone: value(fields[timezoneColumn]),
+	}.At(latitude, longitude)
+
+	return airport, true
+}
@@ -0,0 +1,79 @@
+package airportfile
+
+import (
+	"encoding/json"
+	"go-bestflight/domain/entities/airports"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+const sample = `2564,"Guarulhos - Governador Andre Franco Montoro International Airport","Sao Paulo","Brazil","GRU","SBGR",-23.435556411743164,-46.47305679321289,2459,-3,"S","America/Sao_Paulo","airport","OurAirports"
+1382,"Charles de Gaulle International Airport","Paris","France","CDG","LFPG",49.0127983093,2.54999995232,392,1,"E","Europe/Paris","airport","OurAirports"
+5,"Nadzab Airport","Nadzab","Papua New Guinea",\N,"AYNZ",-6.569803,146.725977,239,10,"U","Pacific/Port_Moresby","airport","OurAirports"
+3797,"John F Kennedy International Airport","New York","United States","JFK","KJFK",40.63980103,not a number,13,-5,"A","America/New_York","airport","OurAirports"
+1,"Incomplete"
+`
+
+func TestAirportFile(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	gru := airports.Airport{
+		Code:     "GRU",
+		Name:     "Guarulhos - Governador Andre Franco Montoro International Airport",
+		City:     "Sao Paulo",
+		Country:  "Brazil",
+		Timezone: "America/Sao_Paulo",
+	}.At(-23.435556411743164, -46.47305679321289)
+
+	g.Describe("Tests for Read", func() {
+		g.It("should read the airports with an IATA code and skip the invalid lines", func() {
+			result, err := Read(strings.NewReader(sample))
+
+			g.Assert(err).Equal(nil)
+			g.Assert(len(result)).Equal(2)
+			g.Assert(result[0]).Equal(gru)
+			g.Assert(result[1].Code).Equal("CDG")
+			g.Assert(result[1].Timezone).Equal("Europe/Paris")
+		})
+
+		g.It("should keep the coordinates of an airport at 0 degrees", func() {
+			line := `9999,"Zero Airport","Zero","Nowhere","ZZZ","ZZZZ",0,-60.5,10,0,"N","Etc/UTC","airport","OurAirports"` + "\n"
+
+			result, _ := Read(strings.NewReader(line))
+			body, _ := json.Marshal(result[0])
+
+			g.Assert(result[0].HasCoordinates()).IsTrue()
+			g.Assert(*result[0].Latitude).Equal(0.0)
+			g.Assert(strings.Contains(string(body), `"latitude":0,"longitude":-60.5`)).IsTrue()
+
+			body, _ = json.Marshal(airports.Airport{Code: "ZZZ"})
+
+			g.Assert(airports.Airport{Code: "ZZZ"}.HasCoordinates()).IsFalse()
+			g.Assert(string(body)).Equal(`{"code":"ZZZ"}`)
+		})
+	})
+
+	g.Describe("Tests for ReadFile", func() {
+		g.It("should read the airports from a file", func() {
+			filePath := "airports.dat"
+			defer os.Remove(filePath)
+
+			ioutil.WriteFile(filePath, []byte(sample), 0664)
+
+			result, err := ReadFile(filePath)
+
+			g.Assert(err).Equal(nil)
+			g.Assert(result[0]).Equal(gru)
+		})
+
+		g.It("should return an error when the file does not exist", func() {
+			_, err := ReadFile("unknown.dat")
+
+			g.Assert(err != nil).IsTrue()
+		})
+	})
+}
@@ -3,12 +3,17 @@ package cache
 import (
 	r "go-bestflight/domain/entities/routes"
 	"sync"
+	"sync/atomic"
 )
 
 // Memcache represents a cache service, e.g. Redis.
 // It allows a constant ready to use easy routes format.
+// Besides the routes, it keeps an integer-indexed graph that is updated on every change
+// and published as an immutable snapshot, so searches can read it without locks.
+// It implements ports.RouteGraphCache.
 type Memcache struct {
 	routes r.Routes
+	graph  atomic.Value
 	sync.RWMutex
 }
 
@@ -17,55 +22,191 @@ var (
 	once     sync.Once
 )
 
+// New is a constructor for an empty Memcache, independent from the one shared by Connect.
+func New() *Memcache {
+	m := &Memcache{
+		routes: make(r.Routes),
+	}
+
+	m.graph.Store(r.NewGraph())
+
+	return m
+}
+
 // Connect iniciates the memcache instance only once.
 func Connect() {
 	once.Do(func() {
-		instance = &Memcache{
-			routes: make(r.Routes),
-		}
+		instance = New()
 	})
 }
 
+// Default returns the Memcache shared by the application.
+func Default() *Memcache {
+	return instance
+}
+
 // Truncate ...
 func Truncate() {
-	instance = &Memcache{
-		routes: make(r.Routes),
-	}
+	instance = New()
 }
 
-// AddRoute ...
-func AddRoute(route r.Route) r.Route {
-	instance.Lock()
-	defer instance.Unlock()
-
-	destinations, ok := instance.routes[route.Boarding]
+// addRoute appends the connection of a new route, or replaces its cost when the route is already cached.
+func (m *Memcache) addRoute(route r.Route) {
+	destinations, ok := m.routes[route.Boarding]
 	if ok {
+		for i, connection := range destinations {
+			if connection.Airport == route.Destination {
+				destinations[i].Cost = route.Cost
+				return
+			}
+		}
+
 		dest := r.Connection{Airport: route.Destination, Cost: route.Cost}
-		instance.routes[route.Boarding] = append(destinations, dest)
-		return route
+		m.routes[route.Boarding] = append(destinations, dest)
+		return
 	}
 
 	dest := []r.Connection{{Airport: route.Destination, Cost: route.Cost}}
-	instance.routes[route.Boarding] = dest
+	m.routes[route.Boarding] = dest
+}
+
+// AddRoute adds a route to the cache, replacing its cost if it is already there.
+func (m *Memcache) AddRoute(route r.Route) r.Route {
+	m.Lock()
+	defer m.Unlock()
+
+	m.addRoute(route)
+	m.graph.Store(m.GetGraph().WithRoute(route))
 
 	return route
 }
 
-// AddRoutes adds multiple routes to the cache.
-func AddRoutes(routes []r.Route) {
+// UpdateRoute replaces the cost of a cached route.
+func (m *Memcache) UpdateRoute(route r.Route) r.Route {
+	return m.AddRoute(route)
+}
+
+// AddRoutes adds multiple routes to the cache, publishing the graph only once.
+func (m *Memcache) AddRoutes(routes []r.Route) {
+	m.Lock()
+	defer m.Unlock()
+
 	for _, route := range routes {
-		AddRoute(route)
+		m.addRoute(route)
 	}
+
+	m.graph.Store(m.GetGraph().WithRoutes(routes))
+}
+
+// ApplyChanges adds or replaces the upserted routes and removes the removed ones, publishing the
+// graph only once, so searches see either none or all of the changes.
+func (m *Memcache) ApplyChanges(upserted, removed []r.Route) {
+	m.Lock()
+	defer m.Unlock()
+
+	graph := m.GetGraph()
+
+	for _, route := range removed {
+		m.removeRoute(route)
+		graph = graph.WithoutRoute(route)
+	}
+
+	for _, route := range upserted {
+		m.addRoute(route)
+	}
+
+	graph = graph.WithRoutes(upserted)
+
+	m.graph.Store(m.withoutUnusedAirports(graph, removed))
+}
+
+// withoutUnusedAirports removes from the graph the airports of the removed routes that no route uses anymore.
+// The airports in use are found once, however many routes were removed.
+func (m *Memcache) withoutUnusedAirports(graph *r.Graph, removed []r.Route) *r.Graph {
+	if len(removed) == 0 {
+		return graph
+	}
+
+	inUse := m.airportsInUse()
+
+	for _, route := range removed {
+		for _, airport := range []string{route.Boarding, route.Destination} {
+			if !inUse[airport] {
+				graph = graph.WithoutAirport(airport)
+			}
+		}
+	}
+
+	return graph
+}
+
+func (m *Memcache) airportsInUse() map[string]bool {
+	inUse := make(map[string]bool)
+
+	for boarding, connections := range m.routes {
+		inUse[boarding] = true
+
+		for _, connection := range connections {
+			inUse[connection.Airport] = true
+		}
+	}
+
+	return inUse
+}
+
+// removeRoute removes the connection of a route, and the boarding airport when it has no connection left.
+func (m *Memcache) removeRoute(route r.Route) {
+	connections := m.routes[route.Boarding]
+	remaining := make([]r.Connection, 0, len(connections))
+
+	for _, connection := range connections {
+		if connection.Airport != route.Destination {
+			remaining = append(remaining, connection)
+		}
+	}
+
+	if len(remaining) == 0 {
+		delete(m.routes, route.Boarding)
+	} else {
+		m.routes[route.Boarding] = remaining
+	}
+}
+
+// DeleteRoute removes a route from the cache. Airports left without any route are
+// removed from the graph as well.
+func (m *Memcache) DeleteRoute(route r.Route) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.removeRoute(route)
+
+	graph := m.GetGraph().WithoutRoute(route)
+
+	m.graph.Store(m.withoutUnusedAirports(graph, []r.Route{route}))
+}
+
+// Len returns the number of cached routes.
+func (m *Memcache) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+
+	routes := 0
+
+	for _, connections := range m.routes {
+		routes += len(connections)
+	}
+
+	return routes
 }
 
 // GetAllRoutes returna all current routes in cache.
-func GetAllRoutes() r.Routes {
+func (m *Memcache) GetAllRoutes() r.Routes {
 	routesCopy := make(r.Routes)
 
-	instance.RLock()
-	defer instance.RUnlock()
+	m.RLock()
+	defer m.RUnlock()
 
-	for boarding, connections := range instance.routes {
+	for boarding, connections := range m.routes {
 		connectionsCopy := make([]r.Connection, len(connections))
 
 		copy(connectionsCopy, connections)
@@ -75,3 +216,46 @@ func GetAllRoutes() r.Routes {
 
 	return routesCopy
 }
+
+// GetGraph returns the current snapshot of the routes graph. It never blocks.
+func (m *Memcache) GetGraph() *r.Graph {
+	return m.graph.Load().(*r.Graph)
+}
+
+// The functions below use the Memcache shared by the application.
+
+// AddRoute adds a route to the cache, replacing its cost if it is already there.
+func AddRoute(route r.Route) r.Route {
+	return instance.AddRoute(route)
+}
+
+// UpdateRoute replaces the cost of a cached route.
+func UpdateRoute(route r.Route) r.Route {
+	return instance.UpdateRoute(route)
+}
+
+// AddRoutes adds multiple routes to the cache, publishing the graph only once.
+func AddRoutes(routes []r.Route) {
+	instance.AddRoutes(routes)
+}
+
+// DeleteRoute removes a route from the cache. Airports left without any route are
+// removed from the graph as well.
+func DeleteRoute(route r.Route) {
+	instance.DeleteRoute(route)
+}
+
+// ApplyChanges adds or replaces the upserted routes and removes the removed ones, publishing the graph only once.
+func ApplyChanges(upserted, removed []r.Route) {
+	instance.ApplyChanges(upserted, removed)
+}
+
+// GetAllRoutes returna all current routes in cache.
+func GetAllRoutes() r.Routes {
+	return instance.GetAllRoutes()
+}
+
+// GetGraph returns the current snapshot of the routes graph. It never blocks.
+func GetGraph() *r.Graph {
+	return instance.GetGraph()
+}
@@ -8,42 +8,25 @@ import (
 )
 
 func TestCache(t *testing.T) {
-	g := goblin.Goblin(t)
-
-	g.Describe("Tests for Connect", func() {
-		g.It("should create only one instance on multiple calls", func() {
-			Connect()
-
-			instance.routes["GRU"] = []r.Connection{{Airport: "CDG", Cost: 5}}
-
-			Connect()
+	t.Parallel()
 
-			connections, ok := instance.routes["GRU"]
-
-			g.Assert(ok).Equal(true)
-			g.Assert(connections).Equal([]r.Connection{{Airport: "CDG", Cost: 5}})
+	g := goblin.Goblin(t)
 
-			Truncate()
-		})
-	})
+	var m *Memcache
 
 	g.Describe("Tests for AddRoute", func() {
 		g.BeforeEach(func() {
-			Connect()
-		})
-
-		g.AfterEach(func() {
-			Truncate()
+			m = New()
 		})
 
 		g.It("should successfully insert a new route to the cache", func() {
-			AddRoute(r.Route{
+			m.AddRoute(r.Route{
 				Boarding:    "GRU",
 				Destination: "CDG",
 				Cost:        75,
 			})
 
-			for k, v := range instance.routes {
+			for k, v := range m.routes {
 				g.Assert(k).Equal("GRU")
 				g.Assert(v[0]).Equal(r.Connection{Airport: "CDG", Cost: 75})
 			}
@@ -61,13 +44,13 @@ func TestCache(t *testing.T) {
 				Cost:        56,
 			}
 
-			AddRoute(route)
-			AddRoute(route2)
+			m.AddRoute(route)
+			m.AddRoute(route2)
 
-			g.Assert(instance.routes["GRU"][0]).Equal(
+			g.Assert(m.routes["GRU"][0]).Equal(
 				r.Connection{Airport: route.Destination, Cost: route.Cost},
 			)
-			g.Assert(instance.routes["GRU"][1]).Equal(
+			g.Assert(m.routes["GRU"][1]).Equal(
 				r.Connection{Airport: route2.Destination, Cost: route2.Cost},
 			)
 		})
@@ -84,21 +67,46 @@ func TestCache(t *testing.T) {
 				Cost:        20,
 			}
 
-			AddRoute(route)
-			AddRoute(route2)
+			m.AddRoute(route)
+			m.AddRoute(route2)
 
-			g.Assert(instance.routes["GRU"][0]).Equal(
+			g.Assert(m.routes["GRU"][0]).Equal(
 				r.Connection{Airport: route.Destination, Cost: route.Cost},
 			)
-			g.Assert(instance.routes["SCL"][0]).Equal(
+			g.Assert(m.routes["SCL"][0]).Equal(
 				r.Connection{Airport: route2.Destination, Cost: route2.Cost},
 			)
 		})
 	})
 
+	g.Describe("Tests for UpdateRoute", func() {
+		g.BeforeEach(func() {
+			m = New()
+		})
+
+		g.It("should replace the cost of the connection instead of appending a new one", func() {
+			m.AddRoute(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+			m.AddRoute(r.Route{Boarding: "GRU", Destination: "ORL", Cost: 56})
+
+			m.UpdateRoute(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+
+			g.Assert(m.routes["GRU"]).Equal([]r.Connection{
+				{Airport: "CDG", Cost: 30},
+				{Airport: "ORL", Cost: 56},
+			})
+
+			graph := m.GetGraph()
+			gru, _ := graph.Index("GRU")
+			cdg, _ := graph.Index("CDG")
+
+			g.Assert(graph.Edges(gru)[0]).Equal(r.Edge{To: cdg, Cost: 30})
+			g.Assert(len(graph.Edges(gru))).Equal(2)
+		})
+	})
+
 	g.Describe("Tests for GetAllRoutes", func() {
 		g.It("should insert multiple routes from a list", func() {
-			Connect()
+			m = New()
 
 			route := r.Route{
 				Boarding:    "GRU",
@@ -116,25 +124,23 @@ func TestCache(t *testing.T) {
 				Cost:        5,
 			}
 
-			AddRoutes([]r.Route{route, route2, route3})
+			m.AddRoutes([]r.Route{route, route2, route3})
 
-			g.Assert(instance.routes["GRU"][0]).Equal(
+			g.Assert(m.routes["GRU"][0]).Equal(
 				r.Connection{Airport: route.Destination, Cost: route.Cost},
 			)
-			g.Assert(instance.routes["SCL"][0]).Equal(
+			g.Assert(m.routes["SCL"][0]).Equal(
 				r.Connection{Airport: route2.Destination, Cost: route2.Cost},
 			)
-			g.Assert(instance.routes["BRC"][0]).Equal(
+			g.Assert(m.routes["BRC"][0]).Equal(
 				r.Connection{Airport: route3.Destination, Cost: route3.Cost},
 			)
-
-			Truncate()
 		})
 	})
 
 	g.Describe("Tests for GetAllRoutes", func() {
 		g.It("should successfully return a copy of all current routes in cache", func() {
-			Connect()
+			m = New()
 
 			route := r.Route{
 				Boarding:    "GRU",
@@ -152,9 +158,9 @@ func TestCache(t *testing.T) {
 				Cost:        5,
 			}
 
-			AddRoutes([]r.Route{route, route2, route3})
+			m.AddRoutes([]r.Route{route, route2, route3})
 
-			routes := GetAllRoutes()
+			routes := m.GetAllRoutes()
 
 			g.Assert(routes["GRU"][0]).Equal(
 				r.Connection{Airport: route.Destination, Cost: route.Cost},
@@ -165,8 +171,123 @@ func TestCache(t *testing.T) {
 			g.Assert(routes["BRC"][0]).Equal(
 				r.Connection{Airport: route3.Destination, Cost: route3.Cost},
 			)
+		})
+	})
+
+	g.Describe("Tests for GetGraph", func() {
+		g.It("should publish a new graph snapshot on every change", func() {
+			m = New()
+
+			empty := m.GetGraph()
+
+			m.AddRoute(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+
+			first := m.GetGraph()
+
+			m.AddRoutes([]r.Route{
+				{Boarding: "SCL", Destination: "ORL", Cost: 20},
+				{Boarding: "GRU", Destination: "SCL", Cost: 20},
+			})
+
+			second := m.GetGraph()
+			gru, _ := second.Index("GRU")
+
+			g.Assert(empty.Size()).Equal(0)
+			g.Assert(first.Size()).Equal(2)
+			g.Assert(second.Size()).Equal(4)
+			g.Assert(len(second.Edges(gru))).Equal(2)
+		})
+	})
+
+	g.Describe("Tests for DeleteRoute", func() {
+		g.It("should remove the route and the airports left without routes", func() {
+			m = New()
+
+			m.AddRoutes([]r.Route{
+				{Boarding: "GRU", Destination: "CDG", Cost: 75},
+				{Boarding: "GRU", Destination: "SCL", Cost: 20},
+				{Boarding: "SCL", Destination: "ORL", Cost: 20},
+			})
+
+			m.DeleteRoute(r.Route{Boarding: "GRU", Destination: "CDG"})
+
+			routes := m.GetAllRoutes()
+			_, okCDG := m.GetGraph().Index("CDG")
+			_, okGRU := m.GetGraph().Index("GRU")
+
+			g.Assert(routes["GRU"]).Equal([]r.Connection{{Airport: "SCL", Cost: 20}})
+			g.Assert(okCDG).IsFalse()
+			g.Assert(okGRU).IsTrue()
+
+			m.DeleteRoute(r.Route{Boarding: "SCL", Destination: "ORL"})
+
+			_, okSCL := m.GetGraph().Index("SCL")
+			_, okORL := m.GetGraph().Index("ORL")
+			_, ok := m.GetAllRoutes()["SCL"]
+
+			g.Assert(ok).IsFalse()
+			g.Assert(okSCL).IsTrue()
+			g.Assert(okORL).IsFalse()
+		})
+	})
+
+	g.Describe("Tests for ApplyChanges", func() {
+		g.It("should apply every change and publish the graph once", func() {
+			m = New()
+			m.AddRoutes([]r.Route{
+				{Boarding: "GRU", Destination: "CDG", Cost: 75},
+				{Boarding: "GRU", Destination: "SCL", Cost: 20},
+			})
+
+			before := m.GetGraph()
+
+			m.ApplyChanges(
+				[]r.Route{{Boarding: "GRU", Destination: "SCL", Cost: 10}, {Boarding: "SCL", Destination: "ORL", Cost: 5}},
+				[]r.Route{{Boarding: "GRU", Destination: "CDG", Cost: 75}},
+			)
+
+			_, okCDG := m.GetGraph().Index("CDG")
+			_, okORL := m.GetGraph().Index("ORL")
+			_, okBefore := before.Index("ORL")
+
+			g.Assert(m.GetAllRoutes()).Equal(r.Routes{
+				"GRU": {{Airport: "SCL", Cost: 10}},
+				"SCL": {{Airport: "ORL", Cost: 5}},
+			})
+			g.Assert(okCDG).IsFalse()
+			g.Assert(okORL).IsTrue()
+			g.Assert(okBefore).IsFalse()
+		})
+	})
+}
+
+func TestConnect(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for Connect", func() {
+		g.It("should create only one instance on multiple calls", func() {
+			Connect()
+
+			instance.routes["GRU"] = []r.Connection{{Airport: "CDG", Cost: 5}}
+
+			Connect()
+
+			connections, ok := instance.routes["GRU"]
+
+			g.Assert(ok).Equal(true)
+			g.Assert(connections).Equal([]r.Connection{{Airport: "CDG", Cost: 5}})
+		})
+	})
+
+	g.Describe("Tests for Truncate", func() {
+		g.It("should empty the shared instance and its graph", func() {
+			Connect()
+			AddRoute(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
 
 			Truncate()
+
+			g.Assert(len(GetAllRoutes())).Equal(0)
+			g.Assert(GetGraph().Size()).Equal(0)
 		})
 	})
 }
@@ -1,54 +1,67 @@
 package database
 
 import (
+	"go-bestflight/domain/entities/airports"
 	r "go-bestflight/domain/entities/routes"
 	"go-bestflight/domain/errors"
 	"sync"
 )
 
 // Database is reponsible for storing routes and airports data in memory.
+// It implements both ports.RouteStore and ports.AirportStore.
+// The airports of the routes are kept apart from the reference data of the airports, so an
+// airport can be part of routes without reference data and the other way around.
 type Database struct {
-	routeTable   map[string]map[string]int
-	airportTable map[string]struct{}
+	routeTable          map[string]map[string]int
+	airportTable        map[string]struct{}
+	airportDetailsTable map[string]airports.Airport
 	sync.RWMutex
 }
 
 var (
-	instance Database
+	instance *Database
 	once     sync.Once
 )
 
+// New is a constructor for an empty Database, independent from the one shared by Connect.
+func New() *Database {
+	return &Database{
+		routeTable:          make(map[string]map[string]int),
+		airportTable:        make(map[string]struct{}),
+		airportDetailsTable: make(map[string]airports.Airport),
+	}
+}
+
 // Connect ...
 func Connect() {
 	once.Do(func() {
-		instance = Database{
-			routeTable:   make(map[string]map[string]int),
-			airportTable: make(map[string]struct{}),
-		}
+		instance = New()
 	})
 }
 
+// Default returns the Database shared by the application.
+func Default() *Database {
+	return instance
+}
+
 func Truncate() {
-	instance = Database{
-		routeTable:   make(map[string]map[string]int),
-		airportTable: make(map[string]struct{}),
-	}
+	instance = New()
 }
 
 // StoreRoute ...
-func StoreRoute(route r.Route) r.Route {
-	instance.Lock()
-	defer instance.Unlock()
+func (db *Database) StoreRoute(route r.Route) r.Route {
+	db.Lock()
+	defer db.Unlock()
 
-	_, okBoarding := instance.routeTable[route.Boarding]
+	_, okBoarding := db.routeTable[route.Boarding]
 
 	if okBoarding {
-		instance.routeTable[route.Boarding][route.Destination] = route.Cost
+		db.routeTable[route.Boarding][route.Destination] = route.Cost
 
 		return route
 	}
 
-	instance.routeTable[route.Boarding] = map[string]int{
+	db.routeTable[route.Boarding] = map[string]int{
 		route.Destination: route.Cost,
 	}
 
@@ -56,31 +69,31 @@ func StoreRoute(route r.Route) r.Route {
 }
 
 // DeleteRoute deletes a given route from database.
-func DeleteRoute(route r.Route) {
-	instance.Lock()
-	defer instance.Unlock()
+func (db *Database) DeleteRoute(route r.Route) {
+	db.Lock()
+	defer db.Unlock()
 
-	destinations, okBoarding := instance.routeTable[route.Boarding]
+	destinations, okBoarding := db.routeTable[route.Boarding]
 
 	if okBoarding {
 		_, okDestination := destinations[route.Destination]
 
 		if okDestination {
-			delete(instance.routeTable[route.Boarding], route.Destination)
+			delete(db.routeTable[route.Boarding], route.Destination)
 		}
 	}
 
-	if len(instance.routeTable[route.Boarding]) == 0 {
-		delete(instance.routeTable, route.Boarding)
+	if len(db.routeTable[route.Boarding]) == 0 {
+		delete(db.routeTable, route.Boarding)
 	}
 }
 
 // GetRouteCost ...
-func GetRouteCost(boarding, destination string) (int, error) {
-	instance.RLock()
-	defer instance.RUnlock()
+func (db *Database) GetRouteCost(boarding, destination string) (int, error) {
+	db.RLock()
+	defer db.RUnlock()
 
-	connections, ok := instance.routeTable[boarding]
+	connections, ok := db.routeTable[boarding]
 	if !ok {
 		return -1, errors.NewRouteNotFoundErr()
 	}
@@ -93,46 +106,180 @@ func GetRouteCost(boarding, destination string) (int, error) {
 	return cost, nil
 }
 
-func HasConnection(boarding string) bool {
-	_, ok := instance.routeTable[boarding]
+func (db *Database) HasConnection(boarding string) bool {
+	db.RLock()
+	defer db.RUnlock()
+
+	_, ok := db.routeTable[boarding]
 
 	return ok
 }
 
+// GetAllRoutes returns a copy of every stored route, in no particular order.
+func (db *Database) GetAllRoutes() []r.Route {
+	db.RLock()
+	defer db.RUnlock()
+
+	routes := []r.Route{}
+
+	for boarding, destinations := range db.routeTable {
+		for destination, cost := range destinations {
+			routes = append(routes, r.Route{Boarding: boarding, Destination: destination, Cost: cost})
+		}
+	}
+
+	return routes
+}
+
 // StoreRoutes ...
-func StoreRoutes(routes []r.Route) {
+func (db *Database) StoreRoutes(routes []r.Route) {
 	for _, route := range routes {
-		StoreRoute(route)
+		db.StoreRoute(route)
 	}
 }
 
 // StoreAirport ...
-func StoreAirport(airport string) string {
-	instance.Lock()
-	defer instance.Unlock()
+func (db *Database) StoreAirport(airport string) string {
+	db.Lock()
+	defer db.Unlock()
 
-	instance.airportTable[airport] = struct{}{}
+	db.airportTable[airport] = struct{}{}
 
 	return airport
 }
 
 // GetAirport returns true if the specified airport is found.
-func GetAirport(airport string) bool {
-	_, ok := instance.airportTable[airport]
+func (db *Database) GetAirport(airport string) bool {
+	db.RLock()
+	defer db.RUnlock()
+
+	_, ok := db.airportTable[airport]
 
 	return ok
 }
 
+// DeleteAirport deletes a given airport from database.
+func (db *Database) DeleteAirport(airport string) {
+	db.Lock()
+	defer db.Unlock()
+
+	delete(db.airportTable, airport)
+}
+
+// IsAirportInUse returns true if the airport is the boarding or the destination of any stored route.
+func (db *Database) IsAirportInUse(airport string) bool {
+	db.RLock()
+	defer db.RUnlock()
+
+	if _, ok := db.routeTable[airport]; ok {
+		return true
+	}
+
+	for _, destinations := range db.routeTable {
+		if _, ok := destinations[airport]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 // GetAllAirports ...
-func GetAllAirports() []string {
-	instance.RLock()
-	defer instance.RUnlock()
+func (db *Database) GetAllAirports() []string {
+	db.RLock()
+	defer db.RUnlock()
 
 	airports := []string{}
 
-	for airport := range instance.airportTable {
+	for airport := range db.airportTable {
 		airports = append(airports, airport)
 	}
 
 	return airports
 }
+
+// StoreAirportsDetails stores the reference data of the airports, replacing the existing one.
+func (db *Database) StoreAirportsDetails(details []airports.Airport) {
+	db.Lock()
+	defer db.Unlock()
+
+	for _, airport := range details {
+		db.airportDetailsTable[airport.Code] = airport
+	}
+}
+
+// GetAirportDetails returns the reference data of an airport and whether it is stored.
+func (db *Database) GetAirportDetails(code string) (airports.Airport, bool) {
+	db.RLock()
+	defer db.RUnlock()
+
+	airport, ok := db.airportDetailsTable[code]
+
+	return airport, ok
+}
+
+// The functions below use the Database shared by the application.
+
+// StoreRoute ...
+func StoreRoute(route r.Route) r.Route {
+	return instance.StoreRoute(route)
+}
+
+// DeleteRoute deletes a given route from database.
+func DeleteRoute(route r.Route) {
+	instance.DeleteRoute(route)
+}
+
+// GetRouteCost ...
+func GetRouteCost(boarding, destination string) (int, error) {
+	return instance.GetRouteCost(boarding, destination)
+}
+
+func HasConnection(boarding string) bool {
+	return instance.HasConnection(boarding)
+}
+
+// GetAllRoutes returns a copy of every stored route, in no particular order.
+func GetAllRoutes() []r.Route {
+	return instance.GetAllRoutes()
+}
+
+// StoreRoutes ...
+func StoreRoutes(routes []r.Route) {
+	instance.StoreRoutes(routes)
+}
+
+// StoreAirport ...
+func StoreAirport(airport string) string {
+	return instance.StoreAirport(airport)
+}
+
+// GetAirport returns true if the specified airport is found.
+func GetAirport(airport string) bool {
+	return instance.GetAirport(airport)
+}
+
+// DeleteAirport deletes a given airport from database.
+func DeleteAirport(airport string) {
+	instance.DeleteAirport(airport)
+}
+
+// IsAirportInUse returns true if the airport is the boarding or the destination of any stored route.
+func IsAirportInUse(airport string) bool {
+	return instance.IsAirportInUse(airport)
+}
+
+// GetAllAirports ...
+func GetAllAirports() []string {
+	return instance.GetAllAirports()
+}
+
+// StoreAirportsDetails stores the reference data of the airports, replacing the existing one.
+func StoreAirportsDetails(details []airports.Airport) {
+	instance.StoreAirportsDetails(details)
+}
+
+// GetAirportDetails returns the reference data of an airport and whether it is stored.
+func GetAirportDetails(code string) (airports.Airport, bool) {
+	return instance.GetAirportDetails(code)
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"go-bestflight/domain/entities/airports"
 	r "go-bestflight/domain/entities/routes"
 	"go-bestflight/domain/errors"
 	"testing"
@@ -9,32 +10,15 @@ import (
 )
 
 func TestDatabase(t *testing.T) {
-	g := goblin.Goblin(t)
-
-	g.Describe("Tests for Connect", func() {
-		g.It("should always return the same instance of db", func() {
-			Connect()
-
-			instance.airportTable["GRU"] = struct{}{}
-			instance.airportTable["SCL"] = struct{}{}
-
-			Connect()
+	t.Parallel()
 
-			_, ok := instance.airportTable["GRU"]
-			_, ok2 := instance.airportTable["SCL"]
+	g := goblin.Goblin(t)
 
-			g.Assert(ok).Equal(true)
-			g.Assert(ok2).Equal(true)
-		})
-	})
+	var db *Database
 
 	g.Describe("Tests for StoreRoute", func() {
 		g.BeforeEach(func() {
-			Connect()
-		})
-
-		g.AfterEach(func() {
-			Truncate()
+			db = New()
 		})
 
 		g.It("should successfully store a route", func() {
@@ -43,7 +27,7 @@ func TestDatabase(t *testing.T) {
 				Destination: "CDG",
 				Cost:        75,
 			}
-			result := StoreRoute(route)
+			result := db.StoreRoute(route)
 
 			g.Assert(result).Equal(route)
 		})
@@ -60,9 +44,9 @@ func TestDatabase(t *testing.T) {
 				Cost:        75,
 			}
 
-			StoreRoute(route1)
+			db.StoreRoute(route1)
 
-			result := StoreRoute(route2)
+			result := db.StoreRoute(route2)
 
 			g.Assert(result).Equal(route2)
 		})
@@ -79,9 +63,9 @@ func TestDatabase(t *testing.T) {
 				Cost:        56,
 			}
 
-			StoreRoute(route)
+			db.StoreRoute(route)
 
-			result := StoreRoute(route2)
+			result := db.StoreRoute(route2)
 
 			g.Assert(result).Equal(route2)
 		})
@@ -98,9 +82,9 @@ func TestDatabase(t *testing.T) {
 				Cost:        5,
 			}
 
-			StoreRoute(route)
+			db.StoreRoute(route)
 
-			result := StoreRoute(route2)
+			result := db.StoreRoute(route2)
 
 			g.Assert(result).Equal(route2)
 		})
@@ -117,9 +101,9 @@ func TestDatabase(t *testing.T) {
 				Cost:        5,
 			}
 
-			StoreRoute(route)
+			db.StoreRoute(route)
 
-			result := StoreRoute(route2)
+			result := db.StoreRoute(route2)
 
 			g.Assert(result).Equal(route2)
 		})
@@ -127,11 +111,7 @@ func TestDatabase(t *testing.T) {
 
 	g.Describe("Tests for DeleteRoute", func() {
 		g.BeforeEach(func() {
-			Connect()
-		})
-
-		g.AfterEach(func() {
-			Truncate()
+			db = New()
 		})
 
 		g.It("should successfully delete a stored route", func() {
@@ -141,16 +121,16 @@ func TestDatabase(t *testing.T) {
 				Cost:        75,
 			}
 
-			StoreRoute(route)
+			db.StoreRoute(route)
 
-			cost, ok := instance.routeTable[route.Boarding][route.Destination]
+			cost, ok := db.routeTable[route.Boarding][route.Destination]
 
 			g.Assert(ok).IsTrue()
 			g.Assert(cost).Equal(75)
 
-			DeleteRoute(route)
+			db.DeleteRoute(route)
 
-			_, ok = instance.routeTable[route.Boarding]
+			_, ok = db.routeTable[route.Boarding]
 
 			g.Assert(ok).IsFalse()
 		})
@@ -167,43 +147,39 @@ func TestDatabase(t *testing.T) {
 				Cost:        20,
 			}
 
-			StoreRoute(route)
-			StoreRoute(route2)
+			db.StoreRoute(route)
+			db.StoreRoute(route2)
 
-			cost, ok := instance.routeTable[route.Boarding][route.Destination]
+			cost, ok := db.routeTable[route.Boarding][route.Destination]
 			g.Assert(ok).IsTrue()
 			g.Assert(cost).Equal(75)
 
-			cost, ok = instance.routeTable[route.Boarding][route2.Destination]
+			cost, ok = db.routeTable[route.Boarding][route2.Destination]
 			g.Assert(ok).IsTrue()
 			g.Assert(cost).Equal(20)
 
-			DeleteRoute(route)
+			db.DeleteRoute(route)
 
-			_, ok = instance.routeTable[route.Boarding][route.Destination]
+			_, ok = db.routeTable[route.Boarding][route.Destination]
 			g.Assert(ok).IsFalse()
 
-			cost, ok = instance.routeTable[route2.Boarding][route2.Destination]
+			cost, ok = db.routeTable[route2.Boarding][route2.Destination]
 			g.Assert(ok).IsTrue()
 			g.Assert(cost).Equal(20)
 
-			DeleteRoute(route2)
+			db.DeleteRoute(route2)
 
-			_, ok = instance.routeTable[route2.Boarding]
+			_, ok = db.routeTable[route2.Boarding]
 			g.Assert(ok).IsFalse()
 
-			g.Assert(len(instance.routeTable)).Equal(0)
+			g.Assert(len(db.routeTable)).Equal(0)
 		})
 
 	})
 
 	g.Describe("Tests for GetRouteCost", func() {
 		g.BeforeEach(func() {
-			Connect()
-		})
-
-		g.AfterEach(func() {
-			Truncate()
+			db = New()
 		})
 
 		g.It("should successfully return a cost for a stored route", func() {
@@ -216,9 +192,9 @@ func TestDatabase(t *testing.T) {
 				Cost:        cost,
 			}
 
-			StoreRoute(route)
+			db.StoreRoute(route)
 
-			result, err := GetRouteCost(boarding, destination)
+			result, err := db.GetRouteCost(boarding, destination)
 
 			g.Assert(err).Equal(nil)
 			g.Assert(result).Equal(cost)
@@ -228,7 +204,7 @@ func TestDatabase(t *testing.T) {
 			boarding := "GRU"
 			destination := "ORL"
 
-			result, err := GetRouteCost(boarding, destination)
+			result, err := db.GetRouteCost(boarding, destination)
 
 			g.Assert(err).Equal(errors.NewRouteNotFoundErr())
 			g.Assert(result).Equal(-1)
@@ -271,22 +247,22 @@ func TestDatabase(t *testing.T) {
 				Cost:        20,
 			}
 
-			StoreRoute(route)
-			StoreRoute(route2)
-			StoreRoute(route3)
-			StoreRoute(route4)
-			StoreRoute(route5)
-			StoreRoute(route6)
-			StoreRoute(route7)
-
-			result, err := GetRouteCost("GRU", "BRC")
-			result2, err2 := GetRouteCost("BRC", "SCL")
-			result3, err3 := GetRouteCost("GRU", "CDG")
-			result4, err4 := GetRouteCost("GRU", "SCL")
-			result5, err5 := GetRouteCost("GRU", "ORL")
-			result6, err6 := GetRouteCost("ORL", "CDG")
-			result7, err7 := GetRouteCost("SCL", "ORL")
-			result8, err8 := GetRouteCost("A", "B")
+			db.StoreRoute(route)
+			db.StoreRoute(route2)
+			db.StoreRoute(route3)
+			db.StoreRoute(route4)
+			db.StoreRoute(route5)
+			db.StoreRoute(route6)
+			db.StoreRoute(route7)
+
+			result, err := db.GetRouteCost("GRU", "BRC")
+			result2, err2 := db.GetRouteCost("BRC", "SCL")
+			result3, err3 := db.GetRouteCost("GRU", "CDG")
+			result4, err4 := db.GetRouteCost("GRU", "SCL")
+			result5, err5 := db.GetRouteCost("GRU", "ORL")
+			result6, err6 := db.GetRouteCost("ORL", "CDG")
+			result7, err7 := db.GetRouteCost("SCL", "ORL")
+			result8, err8 := db.GetRouteCost("A", "B")
 
 			g.Assert(err).Equal(nil)
 			g.Assert(err2).Equal(nil)
@@ -309,7 +285,7 @@ func TestDatabase(t *testing.T) {
 
 	g.Describe("Tests for StoreRoutes", func() {
 		g.It("should successfully store multiple r", func() {
-			Connect()
+			db = New()
 
 			r := []r.Route{
 				{
@@ -349,16 +325,16 @@ func TestDatabase(t *testing.T) {
 				},
 			}
 
-			StoreRoutes(r)
+			db.StoreRoutes(r)
 
-			result, err := GetRouteCost("GRU", "BRC")
-			result2, err2 := GetRouteCost("BRC", "SCL")
-			result3, err3 := GetRouteCost("GRU", "CDG")
-			result4, err4 := GetRouteCost("GRU", "SCL")
-			result5, err5 := GetRouteCost("GRU", "ORL")
-			result6, err6 := GetRouteCost("ORL", "CDG")
-			result7, err7 := GetRouteCost("SCL", "ORL")
-			result8, err8 := GetRouteCost("A", "B")
+			result, err := db.GetRouteCost("GRU", "BRC")
+			result2, err2 := db.GetRouteCost("BRC", "SCL")
+			result3, err3 := db.GetRouteCost("GRU", "CDG")
+			result4, err4 := db.GetRouteCost("GRU", "SCL")
+			result5, err5 := db.GetRouteCost("GRU", "ORL")
+			result6, err6 := db.GetRouteCost("ORL", "CDG")
+			result7, err7 := db.GetRouteCost("SCL", "ORL")
+			result8, err8 := db.GetRouteCost("A", "B")
 
 			g.Assert(err).Equal(nil)
 			g.Assert(err2).Equal(nil)
@@ -376,27 +352,23 @@ func TestDatabase(t *testing.T) {
 			g.Assert(result6).Equal(5)
 			g.Assert(result7).Equal(20)
 			g.Assert(result8).Equal(-1)
-
-			Truncate()
 		})
 	})
 
 	g.Describe("Tests for StoreAirport", func() {
 		g.It("should successfully store an airport", func() {
-			Connect()
+			db = New()
 
 			airport := "GRU"
-			result := StoreAirport(airport)
+			result := db.StoreAirport(airport)
 
 			g.Assert(result).Equal(airport)
-
-			Truncate()
 		})
 	})
 
 	g.Describe("Tests for GetAllAirports", func() {
 		g.It("should successfully return all stored airports", func() {
-			Connect()
+			db = New()
 
 			airports := map[string]struct{}{
 				"GRU": {},
@@ -406,10 +378,10 @@ func TestDatabase(t *testing.T) {
 			}
 
 			for airport := range airports {
-				StoreAirport(airport)
+				db.StoreAirport(airport)
 			}
 
-			result := GetAllAirports()
+			result := db.GetAllAirports()
 
 			g.Assert(len(result)).Equal(4)
 
@@ -418,8 +390,83 @@ func TestDatabase(t *testing.T) {
 
 				g.Assert(ok).Equal(true)
 			}
+		})
+	})
+
+	g.Describe("Tests for IsAirportInUse and DeleteAirport", func() {
+		g.It("should tell if an airport is part of any route and delete it", func() {
+			db = New()
+
+			db.StoreAirport("GRU")
+			db.StoreAirport("CDG")
+			db.StoreAirport("ORL")
+			db.StoreRoute(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+
+			g.Assert(db.IsAirportInUse("GRU")).IsTrue()
+			g.Assert(db.IsAirportInUse("CDG")).IsTrue()
+			g.Assert(db.IsAirportInUse("ORL")).IsFalse()
+
+			db.DeleteAirport("ORL")
 
-			Truncate()
+			g.Assert(db.GetAirport("ORL")).IsFalse()
+			g.Assert(db.GetAirport("GRU")).IsTrue()
+		})
+	})
+
+	g.Describe("Tests for StoreAirportsDetails and GetAirportDetails", func() {
+		g.It("should store the reference data apart from the registered airports", func() {
+			db = New()
+
+			gru := airports.Airport{Code: "GRU", Name: "Guarulhos", City: "Sao Paulo"}
+
+			db.StoreAirportsDetails([]airports.Airport{gru})
+
+			details, ok := db.GetAirportDetails("GRU")
+			_, okUnknown := db.GetAirportDetails("CDG")
+
+			g.Assert(ok).IsTrue()
+			g.Assert(details).Equal(gru)
+			g.Assert(okUnknown).IsFalse()
+			g.Assert(db.GetAirport("GRU")).IsFalse()
+		})
+	})
+
+	g.Describe("Tests for GetAllRoutes", func() {
+		g.It("should return a copy of every stored route", func() {
+			db = New()
+
+			db.StoreRoute(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+			db.StoreRoute(r.Route{Boarding: "GRU", Destination: "ORL", Cost: 56})
+
+			routes := db.GetAllRoutes()
+
+			g.Assert(len(routes)).Equal(2)
+
+			routes[0].Cost = 1
+
+			cost, _ := db.GetRouteCost(routes[0].Boarding, routes[0].Destination)
+			g.Assert(cost != 1).IsTrue()
+		})
+	})
+}
+
+func TestConnect(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for Connect", func() {
+		g.It("should always return the same instance of db", func() {
+			Connect()
+
+			instance.airportTable["GRU"] = struct{}{}
+			instance.airportTable["SCL"] = struct{}{}
+
+			Connect()
+
+			_, ok := instance.airportTable["GRU"]
+			_, ok2 := instance.airportTable["SCL"]
+
+			g.Assert(ok).Equal(true)
+			g.Assert(ok2).Equal(true)
 		})
 	})
 }
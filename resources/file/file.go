@@ -5,51 +5,86 @@ import (
 	"errors"
 	"fmt"
 	r "go-bestflight/domain/entities/routes"
-	"log"
+	"go-bestflight/resources/logger"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
 )
 
-// RoutesFile ...
+// RoutesFile manages the source file of the routes. It implements ports.RouteFile.
+// The file itself is a snapshot: changes are appended to an operations log next to it and
+// are only merged into the snapshot when the log is compacted.
 type RoutesFile struct {
 	filePath string
+	records  int
+	known    stamp
+	pending  *stamp
 	sync.RWMutex
 }
 
 var (
-	instance RoutesFile
+	instance = &RoutesFile{}
 	once     sync.Once
 )
 
-func openOrCreate(filePath string, source string) {
+// Open opens or creates the file and replays the operations left in its log by the last run,
+// discarding a tail corrupted by a crash in the middle of a write.
+// The returned RoutesFile is independent from the one shared by Sync.
+func Open(filePath string) (*RoutesFile, error) {
+	f := &RoutesFile{
+		filePath: filePath,
+	}
+
 	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0666)
-	if err != nil && source == "sync" {
-		log.Fatal(err)
+	if err != nil {
+		return f, err
 	}
 
 	err = file.Close()
-	if err != nil && source == "sync" {
-		log.Fatal(err)
+	if err != nil {
+		return f, err
 	}
 
-	instance = RoutesFile{
-		filePath: filePath,
+	err = f.recoverLog()
+	f.remember()
+
+	return f, err
+}
+
+func openOrCreate(filePath string, source string) {
+	f, err := Open(filePath)
+	if err != nil && source == "sync" {
+		logger.Fatal("could not open the routes file", "path", filePath, "error", err)
 	}
+
+	instance = f
 }
 
-// Sync ...
+// Sync opens or creates the file shared by the application only once.
 func Sync(filePath string) {
 	once.Do(func() {
 		openOrCreate(filePath, "sync")
 	})
 }
 
+// Default returns the RoutesFile shared by the application.
+func Default() *RoutesFile {
+	return instance
+}
+
 // Remove must be used ONLY for tests.
 func Remove() {
-	if isSynced() {
-		os.Remove(instance.filePath)
+	instance.Remove()
+}
+
+// Remove deletes the file and its operations log.
+func (f *RoutesFile) Remove() {
+	if f.isSynced() {
+		os.Remove(f.filePath)
+		os.Remove(f.logPath())
+		os.Remove(f.tempPath())
 	}
 }
 
@@ -60,8 +95,13 @@ func Reset(filePath string) {
 	openOrCreate(filePath, "reset")
 }
 
-func isSynced() bool {
-	return len(instance.filePath) > 0
+func (f *RoutesFile) isSynced() bool {
+	return len(f.filePath) > 0
+}
+
+// Synced tells whether the file shared by the application was opened.
+func Synced() bool {
+	return instance.isSynced()
 }
 
 func cleanLine(line string) string {
@@ -72,7 +112,7 @@ func lineToRoute(line string, lineN int) (r.Route, error) {
 	components := strings.Split(cleanLine(line), ",")
 
 	if len(components) != 3 {
-		log.Printf("invalid format at line: %d\n", lineN)
+		logger.Warn("invalid route format", "line", lineN)
 		return r.Route{}, errors.New("invalid line format")
 	}
 
@@ -81,8 +121,8 @@ func lineToRoute(line string, lineN int) (r.Route, error) {
 
 	cost, err := strconv.Atoi(components[2])
 	if err != nil {
-		log.Printf("error at line %d: %v\n", lineN, err)
-		return r.Route{}, err
+		logger.Warn("invalid route cost", "line", lineN, "error", err)
+		return r.Route{}, errors.New("invalid cost")
 	}
 
 	route := r.Route{
@@ -94,48 +134,136 @@ func lineToRoute(line string, lineN int) (r.Route, error) {
 	return route, nil
 }
 
-func isEmpty(line string) bool {
-	return len(line) == 1 && []byte(line)[0] == '\n'
-}
+// ParseRoutes reads routes in the format of the routes file, e.g. from an import, keeping the
+// line numbers. Lines that can not be read come with the reason and without a route.
+func ParseRoutes(reader io.Reader) ([]r.ImportLine, error) {
+	lines := []r.ImportLine{}
+	scan := bufio.NewScanner(reader)
+	lineNumber := 0
 
-// Write ...
-func Write(route r.Route) error {
-	instance.Lock()
-	defer instance.Unlock()
+	for scan.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scan.Text())
 
-	file, err := os.OpenFile(instance.filePath, os.O_APPEND|os.O_WRONLY, 0664)
-	if err != nil {
-		log.Printf("could not open the file: %v\n", err)
-		return err
-	}
+		if line == "" {
+			continue
+		}
 
-	strLine := fmt.Sprintf("%s,%s,%d\n", route.Boarding, route.Destination, route.Cost)
+		route, err := lineToRoute(line, lineNumber)
+		if err != nil {
+			lines = append(lines, r.ImportLine{Line: lineNumber, Reason: err.Error()})
+			continue
+		}
 
-	_, err = file.WriteString(strLine)
-	if err != nil {
-		log.Printf("could not write to the file: %v\n", err)
-		return err
+		lines = append(lines, r.ImportLine{Line: lineNumber, Route: &route})
 	}
 
-	err = file.Close()
-	if err != nil {
-		log.Printf("could not close the file: %v\n", err)
-		return err
+	return lines, scan.Err()
+}
+
+func routeToLine(route r.Route) string {
+	return fmt.Sprintf("%s,%s,%d\n", route.Boarding, route.Destination, route.Cost)
+}
+
+// WriteRoutes writes routes in the format of the routes file, e.g. for an export.
+func WriteRoutes(writer io.Writer, routes []r.Route) error {
+	for _, route := range routes {
+		_, err := io.WriteString(writer, routeToLine(route))
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// ReadFile ...
-func ReadFile() ([]r.Route, error) {
-	instance.RLock()
-	defer instance.RUnlock()
+// Write logs the adding of a route.
+func (f *RoutesFile) Write(route r.Route) error {
+	f.Lock()
+	defer f.Unlock()
 
-	routes := []r.Route{}
-	file, err := os.OpenFile(instance.filePath, os.O_RDONLY, 0444)
+	return failed(f.appendRecord(record{operation: addOperation, route: route}))
+}
+
+// Update logs the replacement of the cost of the route with the same boarding and destination.
+func (f *RoutesFile) Update(route r.Route) error {
+	f.Lock()
+	defer f.Unlock()
+
+	return failed(f.appendRecord(record{operation: updateOperation, route: route}))
+}
+
+// Delete logs the removal of the route with the same boarding and destination.
+func (f *RoutesFile) Delete(route r.Route) error {
+	f.Lock()
+	defer f.Unlock()
+
+	return failed(f.appendRecord(record{operation: deleteOperation, route: route}))
+}
+
+// WriteAll logs the adding of routes at once, with a single write to the log.
+func (f *RoutesFile) WriteAll(routes []r.Route) error {
+	f.Lock()
+	defer f.Unlock()
+
+	return failed(f.appendRecords(records(addOperation, routes)))
+}
+
+// DeleteAll logs the removal of routes at once, with a single write to the log.
+func (f *RoutesFile) DeleteAll(routes []r.Route) error {
+	f.Lock()
+	defer f.Unlock()
+
+	return failed(f.appendRecords(records(deleteOperation, routes)))
+}
+
+func records(op operation, routes []r.Route) []record {
+	recs := make([]record, len(routes))
+
+	for i, route := range routes {
+		recs[i] = record{operation: op, route: route}
+	}
+
+	return recs
+}
+
+// Compact merges the operations log into the snapshot and empties the log.
+func (f *RoutesFile) Compact() error {
+	f.Lock()
+	defer f.Unlock()
+
+	return failed(f.compact())
+}
+
+// ReadFile returns the routes of the snapshot with the logged operations applied.
+func (f *RoutesFile) ReadFile() ([]r.Route, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.currentRoutes()
+}
+
+// Read returns the routes of the file at the given path with its logged operations applied, without
+// changing the file or its log, e.g. while another process owns them. It fails if the file does not exist.
+func Read(filePath string) ([]r.Route, error) {
+	f := &RoutesFile{filePath: filePath}
+
+	return f.ReadFile()
+}
+
+func (f *RoutesFile) readSnapshot() ([]r.Route, error) {
+	lines, err := f.readSnapshotLines()
+
+	return routesOf(lines), err
+}
+
+// readSnapshotLines reads the lines of the snapshot, skipping the blank ones.
+func (f *RoutesFile) readSnapshotLines() ([]snapshotLine, error) {
+	lines := []snapshotLine{}
+	file, err := os.OpenFile(f.filePath, os.O_RDONLY, 0444)
 	if err != nil {
-		log.Println(err)
-		return routes, err
+		logger.Error("could not open the routes file", "path", f.filePath, "error", err)
+		return lines, err
 	}
 
 	scan := bufio.NewScanner(file)
@@ -145,21 +273,69 @@ func ReadFile() ([]r.Route, error) {
 		lineNumber++
 		line := scan.Text()
 
-		if isEmpty(line) {
+		if strings.TrimSpace(line) == "" {
 			continue
 		}
 
 		route, err := lineToRoute(line, lineNumber)
-		if err == nil {
-			routes = append(routes, route)
-		}
+		lines = append(lines, snapshotLine{route: route, text: line, valid: err == nil})
 	}
 
 	err = file.Close()
 	if err != nil {
-		log.Println(err)
-		return routes, err
+		logger.Error("could not close the routes file", "path", f.filePath, "error", err)
+		return lines, err
 	}
 
-	return routes, nil
+	return lines, nil
+}
+
+// routesOf returns the routes of the lines of a snapshot.
+func routesOf(lines []snapshotLine) []r.Route {
+	routes := []r.Route{}
+
+	for _, line := range lines {
+		if line.valid {
+			routes = append(routes, line.route)
+		}
+	}
+
+	return routes
+}
+
+// The functions below use the RoutesFile shared by the application.
+
+// Write logs the adding of a route.
+func Write(route r.Route) error {
+	return instance.Write(route)
+}
+
+// Update logs the replacement of the cost of the route with the same boarding and destination.
+func Update(route r.Route) error {
+	return instance.Update(route)
+}
+
+// Delete logs the removal of the route with the same boarding and destination.
+func Delete(route r.Route) error {
+	return instance.Delete(route)
+}
+
+// WriteAll logs the adding of routes at once.
+func WriteAll(routes []r.Route) error {
+	return instance.WriteAll(routes)
+}
+
+// DeleteAll logs the removal of routes at once.
+func DeleteAll(routes []r.Route) error {
+	return instance.DeleteAll(routes)
+}
+
+// Compact merges the operations log into the snapshot and empties the log.
+func Compact() error {
+	return instance.Compact()
+}
+
+// ReadFile returns the routes of the snapshot with the logged operations applied.
+func ReadFile() ([]r.Route, error) {
+	return instance.ReadFile()
 }
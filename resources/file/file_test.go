@@ -1,12 +1,15 @@
 package file
 
 import (
-	"bufio"
+	"errors"
 	r "go-bestflight/domain/entities/routes"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/franela/goblin"
 )
@@ -83,14 +86,11 @@ func TestFile(t *testing.T) {
 				Cost:        75,
 			}
 
-			Write(route)
-
-			file, _ := os.Open(filePath)
-			reader := bufio.NewReader(file)
-			line, _ := reader.ReadString('\n')
-			routeFromFile, _ := lineToRoute(line, 1)
+			err := Write(route)
+			routes, _ := ReadFile()
 
-			g.Assert(routeFromFile).Equal(route)
+			g.Assert(err).Equal(nil)
+			g.Assert(routes).Equal([]r.Route{route})
 		})
 
 		g.It("should successfully write to file concurrently", func() {
@@ -146,22 +146,12 @@ func TestFile(t *testing.T) {
 
 			wg.Wait()
 
-			file, err := os.Open(filePath)
+			routes, err := ReadFile()
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			reader := bufio.NewReader(file)
-			counter := 0
-
-			for line, err := reader.ReadString('\n'); err == nil; line, err = reader.ReadString('\n') {
-				_, errLine := lineToRoute(line, counter+1)
-				if errLine == nil {
-					counter++
-				}
-			}
-
-			g.Assert(counter).Equal(5)
+			g.Assert(len(routes)).Equal(5)
 		})
 	})
 
@@ -212,4 +202,320 @@ func TestFile(t *testing.T) {
 			Remove()
 		})
 	})
+
+	g.Describe("Tests for Update", func() {
+		g.BeforeEach(func() {
+			Reset(filePath)
+		})
+
+		g.AfterEach(func() {
+			Remove()
+		})
+
+		g.It("should rewrite only the line of the given route with its new cost", func() {
+			route := r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75}
+			route2 := r.Route{Boarding: "GRU", Destination: "BRC", Cost: 10}
+
+			Write(route)
+			Write(route2)
+
+			err := Update(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+
+			g.Assert(err).Equal(nil)
+
+			routes, _ := ReadFile()
+
+			g.Assert(routes).Equal([]r.Route{{Boarding: "GRU", Destination: "CDG", Cost: 30}, route2})
+		})
+
+		g.It("should return an error when the file can not be read", func() {
+			Reset("")
+
+			err := Update(r.Route{Boarding: "GRU", Destination: "BRC", Cost: 5})
+
+			g.Assert(err != nil).IsTrue()
+		})
+	})
+
+	g.Describe("Tests for Delete", func() {
+		g.BeforeEach(func() {
+			Reset(filePath)
+		})
+
+		g.AfterEach(func() {
+			Remove()
+		})
+
+		g.It("should remove only the line of the given route", func() {
+			route := r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75}
+			route2 := r.Route{Boarding: "GRU", Destination: "BRC", Cost: 10}
+			route3 := r.Route{Boarding: "BRC", Destination: "SCL", Cost: 5}
+
+			Write(route)
+			Write(route2)
+			Write(route3)
+
+			err := Delete(r.Route{Boarding: "GRU", Destination: "BRC"})
+
+			g.Assert(err).Equal(nil)
+
+			routes, _ := ReadFile()
+
+			g.Assert(routes).Equal([]r.Route{route, route3})
+		})
+
+		g.It("should return an error when the file can not be read", func() {
+			Reset("")
+
+			err := Delete(r.Route{Boarding: "GRU", Destination: "BRC"})
+
+			g.Assert(err != nil).IsTrue()
+		})
+	})
+
+	g.Describe("Tests for the operations log", func() {
+		route := r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75}
+		route2 := r.Route{Boarding: "GRU", Destination: "BRC", Cost: 10}
+
+		g.BeforeEach(func() {
+			Reset(filePath)
+		})
+
+		g.AfterEach(func() {
+			Remove()
+			compactionThreshold = 1000
+		})
+
+		g.It("should encode and decode records with a checksum", func() {
+			rec := record{operation: updateOperation, route: route}
+			line := rec.encode()
+
+			decoded, err := decodeRecord(strings.TrimSuffix(line, "\n"))
+
+			g.Assert(err).Equal(nil)
+			g.Assert(decoded).Equal(rec)
+
+			_, err = decodeRecord(strings.Replace(strings.TrimSuffix(line, "\n"), "75", "76", 1))
+
+			g.Assert(err).Equal(errors.New("invalid record checksum"))
+		})
+
+		g.It("should log the changes without touching the snapshot", func() {
+			Write(route)
+			Write(route2)
+			Update(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+			Delete(route2)
+
+			snapshot, _ := instance.readSnapshot()
+			records, _, _ := instance.readLog()
+			routes, _ := ReadFile()
+
+			g.Assert(snapshot).Equal([]r.Route{})
+			g.Assert(len(records)).Equal(4)
+			g.Assert(routes).Equal([]r.Route{{Boarding: "GRU", Destination: "CDG", Cost: 30}})
+		})
+
+		g.It("should compact the log into the snapshot, keeping the lines that are not routes", func() {
+			ioutil.WriteFile(filePath, []byte("GRU,CDG,75\ninvalid line\n\nSCL,ORL,20\n"), 0664)
+
+			Write(route2)
+			Update(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+			Delete(r.Route{Boarding: "SCL", Destination: "ORL"})
+
+			g.Assert(Compact()).Equal(nil)
+
+			content, _ := ioutil.ReadFile(filePath)
+			_, err := os.Stat(filePath + ".wal")
+
+			g.Assert(string(content)).Equal("GRU,CDG,30\ninvalid line\nGRU,BRC,10\n")
+			g.Assert(os.IsNotExist(err)).IsTrue()
+		})
+
+		g.It("should compact the log once it reaches the threshold", func() {
+			compactionThreshold = 2
+
+			Write(route)
+			Write(route2)
+
+			content, _ := ioutil.ReadFile(filePath)
+
+			g.Assert(string(content)).Equal("GRU,CDG,75\nGRU,BRC,10\n")
+			g.Assert(instance.records).Equal(0)
+		})
+
+		g.It("should log the adding and removal of routes at once", func() {
+			g.Assert(WriteAll([]r.Route{route, route2})).Equal(nil)
+
+			records, _, _ := instance.readLog()
+			routes, _ := ReadFile()
+
+			g.Assert(len(records)).Equal(2)
+			g.Assert(instance.records).Equal(2)
+			g.Assert(routes).Equal([]r.Route{route, route2})
+
+			g.Assert(DeleteAll([]r.Route{route, route2})).Equal(nil)
+
+			routes, _ = ReadFile()
+
+			g.Assert(routes).Equal([]r.Route{})
+		})
+
+		g.It("should compact a batch as large as the threshold without logging it", func() {
+			compactionThreshold = 2
+
+			Write(r.Route{Boarding: "SCL", Destination: "ORL", Cost: 20})
+			g.Assert(WriteAll([]r.Route{route, route2})).Equal(nil)
+
+			content, _ := ioutil.ReadFile(filePath)
+			_, err := os.Stat(filePath + ".wal")
+
+			g.Assert(string(content)).Equal("SCL,ORL,20\nGRU,CDG,75\nGRU,BRC,10\n")
+			g.Assert(os.IsNotExist(err)).IsTrue()
+			g.Assert(instance.records).Equal(0)
+		})
+
+		g.It("should replay the log into the snapshot when synced again", func() {
+			Write(route)
+			Delete(route)
+			Write(route2)
+
+			openOrCreate(filePath, "reset")
+
+			content, _ := ioutil.ReadFile(filePath)
+			routes, _ := ReadFile()
+
+			g.Assert(string(content)).Equal("GRU,BRC,10\n")
+			g.Assert(routes).Equal([]r.Route{route2})
+		})
+
+		g.It("should be idempotent when a compaction stopped before removing the log", func() {
+			Write(route)
+			Update(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+
+			logContent, _ := ioutil.ReadFile(filePath + ".wal")
+
+			Compact()
+			ioutil.WriteFile(filePath+".wal", logContent, 0664)
+			openOrCreate(filePath, "reset")
+
+			routes, _ := ReadFile()
+
+			g.Assert(routes).Equal([]r.Route{{Boarding: "GRU", Destination: "CDG", Cost: 30}})
+		})
+
+		g.It("should discard a truncated or corrupted tail of the log", func() {
+			Write(route)
+			Write(route2)
+
+			logFile, _ := os.OpenFile(filePath+".wal", os.O_APPEND|os.O_WRONLY, 0664)
+			logFile.WriteString("delete,GRU,CDG,75,00000000\nadd,GRU,SC")
+			logFile.Close()
+
+			records, valid, _ := instance.readLog()
+			g.Assert(len(records)).Equal(2)
+
+			openOrCreate(filePath, "reset")
+
+			routes, _ := ReadFile()
+			_, err := os.Stat(filePath + ".wal")
+
+			g.Assert(valid > 0).IsTrue()
+			g.Assert(os.IsNotExist(err)).IsTrue()
+			g.Assert(routes).Equal([]r.Route{route, route2})
+
+			Write(route)
+			routes, _ = ReadFile()
+
+			g.Assert(routes).Equal([]r.Route{route, route2})
+		})
+
+		g.It("should return an error when writing before syncing", func() {
+			Reset("")
+
+			g.Assert(Write(route)).Equal(errNotSynced)
+		})
+	})
+
+	g.Describe("Tests for ParseRoutes", func() {
+		g.It("should number the lines and give the reason of the unreadable ones", func() {
+			csv := "GRU,CDG,75\n\nGRU,BRC\nGRU,SCL,abc\nORL,CDG,5\n"
+
+			lines, err := ParseRoutes(strings.NewReader(csv))
+
+			g.Assert(err).Equal(nil)
+			g.Assert(lines).Equal([]r.ImportLine{
+				{Line: 1, Route: &r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75}},
+				{Line: 3, Reason: "invalid line format"},
+				{Line: 4, Reason: "invalid cost"},
+				{Line: 5, Route: &r.Route{Boarding: "ORL", Destination: "CDG", Cost: 5}},
+			})
+		})
+	})
+
+	g.Describe("Tests for Changed", func() {
+		g.BeforeEach(func() {
+			Reset(filePath)
+		})
+
+		g.AfterEach(func() {
+			Remove()
+		})
+
+		g.It("should report a rewritten file once it stops changing", func() {
+			changed, err := Changed()
+			g.Assert(err).Equal(nil)
+			g.Assert(changed).IsFalse()
+
+			ioutil.WriteFile(filePath, []byte("GRU,CDG,75\n"), 0664)
+
+			changed, _ = Changed()
+			g.Assert(changed).IsFalse()
+
+			changed, _ = Changed()
+			g.Assert(changed).IsTrue()
+
+			changed, _ = Changed()
+			g.Assert(changed).IsFalse()
+		})
+
+		g.It("should discard the operations logged before a rewrite", func() {
+			Update(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+			Write(r.Route{Boarding: "SCL", Destination: "ORL", Cost: 5})
+
+			ioutil.WriteFile(filePath, []byte("GRU,CDG,80\n"), 0664)
+
+			Changed()
+			changed, err := Changed()
+
+			g.Assert(err).Equal(nil)
+			g.Assert(changed).IsTrue()
+
+			routes, _ := ReadFile()
+
+			g.Assert(routes).Equal([]r.Route{{Boarding: "GRU", Destination: "CDG", Cost: 80}})
+
+			_, err = os.Stat(filePath + ".wal")
+
+			g.Assert(os.IsNotExist(err)).IsTrue()
+		})
+
+		g.It("should not report a file whose modification time is only in another location", func() {
+			instance.known.modTime = instance.known.modTime.In(time.FixedZone("UTC+1", 3600))
+
+			Changed()
+			changed, _ := Changed()
+
+			g.Assert(changed).IsFalse()
+		})
+
+		g.It("should not report the changes made by a compaction", func() {
+			Write(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+			Compact()
+
+			Changed()
+			changed, _ := Changed()
+
+			g.Assert(changed).IsFalse()
+		})
+	})
 }
@@ -0,0 +1,50 @@
+package file
+
+import (
+	"errors"
+	r "go-bestflight/domain/entities/routes"
+)
+
+// ErrReadOnly is returned by the changes made to a ReadOnlyFile.
+var ErrReadOnly = errors.New("the routes file is read-only")
+
+// ReadOnlyFile holds routes read once from a routes file and refuses every change, for the services
+// that must never change the file, e.g. while a server owns it. It implements ports.RouteFile.
+type ReadOnlyFile struct {
+	routes []r.Route
+}
+
+// NewReadOnly is a constructor for a ReadOnlyFile holding the given routes.
+func NewReadOnly(routes []r.Route) *ReadOnlyFile {
+	return &ReadOnlyFile{routes: append([]r.Route{}, routes...)}
+}
+
+// Write refuses the adding of a route.
+func (f *ReadOnlyFile) Write(route r.Route) error {
+	return ErrReadOnly
+}
+
+// Update refuses the replacement of a route cost.
+func (f *ReadOnlyFile) Update(route r.Route) error {
+	return ErrReadOnly
+}
+
+// Delete refuses the removal of a route.
+func (f *ReadOnlyFile) Delete(route r.Route) error {
+	return ErrReadOnly
+}
+
+// WriteAll refuses the adding of routes.
+func (f *ReadOnlyFile) WriteAll(routes []r.Route) error {
+	return ErrReadOnly
+}
+
+// DeleteAll refuses the removal of routes.
+func (f *ReadOnlyFile) DeleteAll(routes []r.Route) error {
+	return ErrReadOnly
+}
+
+// ReadFile returns the routes held.
+func (f *ReadOnlyFile) ReadFile() ([]r.Route, error) {
+	return append([]r.Route{}, f.routes...), nil
+}
@@ -0,0 +1,401 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/resources/logger"
+	"go-bestflight/resources/metrics"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type operation string
+
+const (
+	addOperation    operation = "add"
+	updateOperation operation = "update"
+	deleteOperation operation = "delete"
+)
+
+// compactionThreshold is the number of logged operations that triggers a compaction.
+var compactionThreshold = 1000
+
+var errNotSynced = errors.New("file not synced")
+
+// record is an operation of the log. It is stored as a line like "add,GRU,CDG,75,2f1e0c9a",
+// where the last field is the CRC-32 checksum of the rest of the line.
+type record struct {
+	operation operation
+	route     r.Route
+}
+
+func (rec record) encode() string {
+	payload := fmt.Sprintf("%s,%s,%s,%d", rec.operation, rec.route.Boarding, rec.route.Destination, rec.route.Cost)
+
+	return fmt.Sprintf("%s,%08x\n", payload, crc32.ChecksumIEEE([]byte(payload)))
+}
+
+func decodeRecord(line string) (record, error) {
+	separator := strings.LastIndex(line, ",")
+	if separator == -1 {
+		return record{}, errors.New("invalid record format")
+	}
+
+	payload := line[:separator]
+
+	if fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(payload))) != line[separator+1:] {
+		return record{}, errors.New("invalid record checksum")
+	}
+
+	components := strings.Split(payload, ",")
+	if len(components) != 4 {
+		return record{}, errors.New("invalid record format")
+	}
+
+	op := operation(components[0])
+	if op != addOperation && op != updateOperation && op != deleteOperation {
+		return record{}, fmt.Errorf("invalid record operation: %s", op)
+	}
+
+	cost, err := strconv.Atoi(components[3])
+	if err != nil {
+		return record{}, err
+	}
+
+	rec := record{
+		operation: op,
+		route: r.Route{
+			Boarding:    components[1],
+			Destination: components[2],
+			Cost:        cost,
+		},
+	}
+
+	return rec, nil
+}
+
+func (f *RoutesFile) logPath() string {
+	return f.filePath + ".wal"
+}
+
+func (f *RoutesFile) tempPath() string {
+	return f.filePath + ".tmp"
+}
+
+// readLog returns the records of the log and the size of its valid part. The log is read until
+// its end or until the first record that is incomplete or does not match its checksum.
+func (f *RoutesFile) readLog() ([]record, int, error) {
+	content, err := ioutil.ReadFile(f.logPath())
+	if os.IsNotExist(err) {
+		return []record{}, 0, nil
+	}
+
+	if err != nil {
+		logger.Error("could not read the operations log", "path", f.logPath(), "error", err)
+		return nil, 0, err
+	}
+
+	records := []record{}
+	valid := 0
+
+	for valid < len(content) {
+		end := bytes.IndexByte(content[valid:], '\n')
+		if end == -1 {
+			break
+		}
+
+		rec, err := decodeRecord(string(content[valid : valid+end]))
+		if err != nil {
+			logger.Warn("invalid record in the operations log", "path", f.logPath(), "byte", valid, "error", err)
+			break
+		}
+
+		records = append(records, rec)
+		valid += end + 1
+	}
+
+	return records, valid, nil
+}
+
+// recoverLog truncates a corrupted tail of the log and replays the log into the snapshot.
+func (f *RoutesFile) recoverLog() error {
+	os.Remove(f.tempPath())
+
+	records, valid, err := f.readLog()
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(f.logPath()); err == nil && info.Size() > int64(valid) {
+		logger.Warn("discarding the end of the operations log", "path", f.logPath(), "bytes", info.Size()-int64(valid))
+
+		err = os.Truncate(f.logPath(), int64(valid))
+		if err != nil {
+			logger.Error("could not truncate the operations log", "path", f.logPath(), "error", err)
+			return err
+		}
+	}
+
+	f.records = len(records)
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	logger.Info("replaying the operations log", "path", f.logPath(), "operations", len(records))
+
+	return f.compact()
+}
+
+// failed counts the writes that failed, except the ones refused because the file is not synced.
+func failed(err error) error {
+	if err != nil && err != errNotSynced {
+		metrics.FileWriteFailures.Inc()
+	}
+
+	return err
+}
+
+// appendRecord writes a record at the end of the log and flushes it to the disk.
+func (f *RoutesFile) appendRecord(rec record) error {
+	return f.appendRecords([]record{rec})
+}
+
+// appendRecords writes records at the end of the log with a single write and flushes them to the disk.
+// Records that could not be fully written are cut off, so the log never keeps a partial record.
+// As many records as the compaction threshold are compacted into the snapshot right away instead.
+func (f *RoutesFile) appendRecords(recs []record) error {
+	if !f.isSynced() {
+		return errNotSynced
+	}
+
+	if len(recs) == 0 {
+		return nil
+	}
+
+	if len(recs) >= compactionThreshold {
+		return f.compact(recs...)
+	}
+
+	var builder strings.Builder
+
+	for _, rec := range recs {
+		builder.WriteString(rec.encode())
+	}
+
+	file, err := os.OpenFile(f.logPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0664)
+	if err != nil {
+		logger.Error("could not open the operations log", "path", f.logPath(), "error", err)
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		logger.Error("could not stat the operations log", "path", f.logPath(), "error", err)
+		file.Close()
+		return err
+	}
+
+	_, err = file.WriteString(builder.String())
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if err != nil {
+		logger.Error("could not write to the operations log", "path", f.logPath(), "error", err)
+		file.Truncate(info.Size())
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		logger.Error("could not close the operations log", "path", f.logPath(), "error", err)
+		return err
+	}
+
+	f.records += len(recs)
+
+	if f.records >= compactionThreshold {
+		if err := failed(f.compact()); err != nil {
+			logger.Error("could not compact the operations log", "path", f.logPath(), "error", err)
+		}
+	}
+
+	return nil
+}
+
+// snapshotLine is a line of the snapshot: a route or, when it can not be read as one, its text,
+// which compactions keep as it is so they never drop what the user wrote in the file.
+type snapshotLine struct {
+	route r.Route
+	text  string
+	valid bool
+}
+
+func routeKey(route r.Route) string {
+	return route.Boarding + "," + route.Destination
+}
+
+// replay applies the records to the lines of the snapshot, keeping their order and appending the
+// new routes. Adding an existing route replaces its cost, so replaying a record that is already
+// part of the snapshot changes nothing. The lines are indexed by route first, so replaying costs
+// the number of lines plus the number of records.
+func replay(lines []snapshotLine, records []record) []snapshotLine {
+	positions := make(map[string][]int, len(lines))
+	removed := make([]bool, len(lines))
+
+	for i, line := range lines {
+		if line.valid {
+			key := routeKey(line.route)
+			positions[key] = append(positions[key], i)
+		}
+	}
+
+	for _, rec := range records {
+		key := routeKey(rec.route)
+
+		if rec.operation == deleteOperation {
+			for _, i := range positions[key] {
+				removed[i] = true
+			}
+
+			delete(positions, key)
+
+			continue
+		}
+
+		if stored, ok := positions[key]; ok {
+			for _, i := range stored {
+				lines[i].route.Cost = rec.route.Cost
+			}
+
+			continue
+		}
+
+		positions[key] = []int{len(lines)}
+		lines = append(lines, snapshotLine{route: rec.route, valid: true})
+		removed = append(removed, false)
+	}
+
+	remaining := lines[:0]
+
+	for i, line := range lines {
+		if !removed[i] {
+			remaining = append(remaining, line)
+		}
+	}
+
+	return remaining
+}
+
+func (f *RoutesFile) currentLines() ([]snapshotLine, error) {
+	lines, err := f.readSnapshotLines()
+	if err != nil {
+		return lines, err
+	}
+
+	records, _, err := f.readLog()
+	if err != nil {
+		return lines, err
+	}
+
+	return replay(lines, records), nil
+}
+
+func (f *RoutesFile) currentRoutes() ([]r.Route, error) {
+	lines, err := f.currentLines()
+
+	return routesOf(lines), err
+}
+
+// compact writes the current routes, with the given records applied after the logged ones, to a
+// temporary file renamed over the snapshot and then removes the log. If it stops in the middle,
+// the log is replayed again on the next sync, without the given records.
+// The lines of the snapshot that are not routes are kept where they were.
+func (f *RoutesFile) compact(recs ...record) error {
+	if !f.isSynced() {
+		return errNotSynced
+	}
+
+	lines, err := f.currentLines()
+	if err != nil {
+		return err
+	}
+
+	lines = replay(lines, recs)
+
+	var builder strings.Builder
+
+	for _, line := range lines {
+		if line.valid {
+			builder.WriteString(routeToLine(line.route))
+		} else {
+			builder.WriteString(line.text + "\n")
+		}
+	}
+
+	file, err := os.OpenFile(f.tempPath(), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0664)
+	if err != nil {
+		logger.Error("could not open the temporary file", "path", f.tempPath(), "error", err)
+		return err
+	}
+
+	_, err = file.WriteString(builder.String())
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		logger.Error("could not write to the temporary file", "path", f.tempPath(), "error", err)
+		os.Remove(f.tempPath())
+		return err
+	}
+
+	err = os.Rename(f.tempPath(), f.filePath)
+	if err != nil {
+		logger.Error("could not replace the file", "path", f.filePath, "error", err)
+		os.Remove(f.tempPath())
+		return err
+	}
+
+	f.remember()
+
+	return f.removeLog()
+}
+
+// removeLog removes the log, once its operations are part of the snapshot or no longer apply to it.
+func (f *RoutesFile) removeLog() error {
+	err := os.Remove(f.logPath())
+	if err != nil && !os.IsNotExist(err) {
+		logger.Error("could not remove the operations log", "path", f.logPath(), "error", err)
+		return err
+	}
+
+	f.records = 0
+
+	return nil
+}
+
+// discardLog removes the log without merging it, e.g. once the snapshot was rewritten by someone else.
+func (f *RoutesFile) discardLog() error {
+	records, _, err := f.readLog()
+	if err != nil {
+		return err
+	}
+
+	if len(records) > 0 {
+		logger.Warn("discarding the operations log of the previous file", "path", f.logPath(), "operations", len(records))
+	}
+
+	return f.removeLog()
+}
@@ -0,0 +1,86 @@
+package file
+
+import (
+	"fmt"
+	r "go-bestflight/domain/entities/routes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// BenchmarkReadLog reads logs of growing sizes: the time per record must stay about the same.
+func BenchmarkReadLog(b *testing.B) {
+	dir, _ := ioutil.TempDir("", "wal")
+	defer os.RemoveAll(dir)
+
+	for _, records := range []int{1000, 10000, 100000} {
+		var builder strings.Builder
+
+		for i := 0; i < records; i++ {
+			rec := record{operation: addOperation, route: r.Route{Boarding: "GRU", Destination: fmt.Sprintf("%03d", i%1000), Cost: i + 1}}
+			builder.WriteString(rec.encode())
+		}
+
+		f := &RoutesFile{filePath: filepath.Join(dir, fmt.Sprintf("routes-%d.csv", records))}
+		ioutil.WriteFile(f.logPath(), []byte(builder.String()), 0664)
+
+		b.Run(fmt.Sprintf("records/%d", records), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				read, _, _ := f.readLog()
+				if len(read) != records {
+					b.Fatalf("read %d records, want %d", len(read), records)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCompact compacts logs of growing sizes into snapshots of growing sizes: the time must
+// grow with the number of routes plus the number of records, not with their product.
+func BenchmarkCompact(b *testing.B) {
+	dir, _ := ioutil.TempDir("", "wal")
+	defer os.RemoveAll(dir)
+
+	airport := func(i int) string {
+		return fmt.Sprintf("%c%c%c", 'A'+i/676%26, 'A'+i/26%26, 'A'+i%26)
+	}
+
+	for _, routes := range []int{1000, 10000, 100000} {
+		var snapshot strings.Builder
+
+		for i := 0; i < routes; i++ {
+			snapshot.WriteString(routeToLine(r.Route{Boarding: airport(i / 10), Destination: airport(i), Cost: 10}))
+		}
+
+		for _, records := range []int{100, 1000} {
+			var log strings.Builder
+
+			for i := 0; i < records; i++ {
+				// Half of the records update routes of the snapshot and half add new ones.
+				rec := record{operation: updateOperation, route: r.Route{Boarding: airport(i * 7 % routes / 10), Destination: airport(i * 7 % routes), Cost: 20}}
+				if i%2 == 1 {
+					rec = record{operation: addOperation, route: r.Route{Boarding: "ZZZ", Destination: airport(i), Cost: 5}}
+				}
+
+				log.WriteString(rec.encode())
+			}
+
+			f := &RoutesFile{filePath: filepath.Join(dir, fmt.Sprintf("routes-%d-%d.csv", routes, records))}
+
+			b.Run(fmt.Sprintf("routes/%d/records/%d", routes, records), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					ioutil.WriteFile(f.filePath, []byte(snapshot.String()), 0664)
+					ioutil.WriteFile(f.logPath(), []byte(log.String()), 0664)
+					b.StartTimer()
+
+					if err := f.compact(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package file
+
+import (
+	"os"
+	"time"
+)
+
+// stamp identifies a version of the file by its modification time and size.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// equal compares the modification times with Equal, as == also compares their locations.
+func (s stamp) equal(other stamp) bool {
+	return s.size == other.size && s.modTime.Equal(other.modTime)
+}
+
+func (f *RoutesFile) stat() (stamp, error) {
+	info, err := os.Stat(f.filePath)
+	if err != nil {
+		return stamp{}, err
+	}
+
+	return stamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// remember keeps the current version of the file as the one already read, e.g. after a compaction,
+// so the file is not taken as changed by someone else.
+func (f *RoutesFile) remember() {
+	current, err := f.stat()
+	if err != nil {
+		return
+	}
+
+	f.known = current
+	f.pending = nil
+}
+
+// Changed tells whether the file was replaced or rewritten by someone else since it was opened,
+// compacted or last reported as changed, based on its modification time and size.
+// A change is only reported once the file is the same on two calls in a row, so a file that is
+// still being written is not taken as changed yet.
+// The rewritten file is the whole truth: the operations logged before it were made over the previous
+// file, so the log is discarded before the change is reported, and they are not replayed over it.
+func (f *RoutesFile) Changed() (bool, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if !f.isSynced() {
+		return false, errNotSynced
+	}
+
+	current, err := f.stat()
+	if err != nil {
+		return false, err
+	}
+
+	if current.equal(f.known) {
+		f.pending = nil
+		return false, nil
+	}
+
+	if f.pending == nil || !f.pending.equal(current) {
+		f.pending = &current
+		return false, nil
+	}
+
+	if err := f.discardLog(); err != nil {
+		return false, err
+	}
+
+	f.known = current
+	f.pending = nil
+
+	return true, nil
+}
+
+// Changed tells whether the file shared by the application was changed by someone else.
+func Changed() (bool, error) {
+	return instance.Changed()
+}
@@ -0,0 +1,316 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level is the severity of a log entry. Entries below the level of a Logger are discarded.
+type Level int
+
+// The levels, from the least to the most severe.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// String returns the name of the level, as written in the entries.
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "level(" + strconv.Itoa(int(l)) + ")"
+	}
+
+	return levelNames[l]
+}
+
+// ParseLevel returns the level of a name, e.g. "warn".
+func ParseLevel(name string) (Level, error) {
+	for i, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(i), nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("invalid log level %q, it must be one of: %s", name, strings.Join(levelNames, ", "))
+}
+
+// Format is the encoding of the log entries.
+type Format string
+
+const (
+	// Logfmt writes an entry per line as key=value pairs, e.g. level=info msg="routes loaded".
+	Logfmt Format = "logfmt"
+	// JSON writes an entry per line as a JSON object.
+	JSON Format = "json"
+)
+
+// Formats are the valid formats, the default one first.
+var Formats = []Format{Logfmt, JSON}
+
+// ParseFormat returns the format of a name, e.g. "json".
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+
+	return Logfmt, fmt.Errorf("invalid log format %q, it must be one of: %s", name, strings.Join(names, ", "))
+}
+
+// sink is the destination shared by a Logger and the loggers derived from it with With,
+// so their entries are never interleaved.
+type sink struct {
+	w      io.Writer
+	closer io.Closer // the file opened by Open, if any
+	sync.Mutex
+}
+
+// Logger writes levelled entries made of a message and fields, given as alternating keys and values.
+// The time, the level and the message come first, followed by the fields of With and then the fields
+// of the entry. Errors, durations and other fmt.Stringer values are written as their text.
+type Logger struct {
+	sink   *sink
+	level  Level
+	format Format
+	fields []interface{}
+	now    func() time.Time
+}
+
+// New is a constructor for a Logger writing the entries of at least the given level to w.
+func New(w io.Writer, level Level, format Format) *Logger {
+	return &Logger{
+		sink:   &sink{w: w},
+		level:  level,
+		format: format,
+		now:    time.Now,
+	}
+}
+
+// Discard returns a Logger that writes nothing.
+func Discard() *Logger {
+	return New(ioutil.Discard, LevelError+1, Logfmt)
+}
+
+var instance atomic.Value
+
+func init() {
+	instance.Store(New(os.Stderr, LevelInfo, Logfmt))
+}
+
+// Default returns the Logger shared by the application. It writes the info entries to the standard
+// error in logfmt until SetDefault replaces it.
+func Default() *Logger {
+	return instance.Load().(*Logger)
+}
+
+// SetDefault replaces the Logger shared by the application.
+func SetDefault(l *Logger) {
+	instance.Store(l)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx holding l, e.g. a Logger with the fields of a request, so every
+// entry about the request has them.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Logger held by ctx, or the Default one when it holds none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+
+	return Default()
+}
+
+// With returns a Logger that adds the given fields to every entry.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	derived := *l
+	derived.fields = append(append([]interface{}{}, l.fields...), keyvals...)
+
+	return &derived
+}
+
+// Enabled tells whether the entries of a level are written.
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+// Debug writes an entry about the details of the work being done.
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LevelDebug, msg, keyvals)
+}
+
+// Info writes an entry about the normal work of the application.
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.log(LevelInfo, msg, keyvals)
+}
+
+// Warn writes an entry about something unexpected that the application recovered from.
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.log(LevelWarn, msg, keyvals)
+}
+
+// Error writes an entry about something that failed.
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.log(LevelError, msg, keyvals)
+}
+
+// Fatal writes an error entry and exits the program with the status 1.
+func (l *Logger) Fatal(msg string, keyvals ...interface{}) {
+	l.log(LevelError, msg, keyvals)
+	os.Exit(1)
+}
+
+// Close closes the file opened by Open for the Logger. The writers given to New are left open.
+func (l *Logger) Close() error {
+	if l.sink.closer == nil {
+		return nil
+	}
+
+	return l.sink.closer.Close()
+}
+
+// badKey is the key of a value given without one.
+const badKey = "!BADKEY"
+
+func (l *Logger) log(level Level, msg string, keyvals []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	fields := []interface{}{
+		"time", l.now().UTC().Format(time.RFC3339Nano),
+		"level", level.String(),
+		"msg", msg,
+	}
+	fields = append(append(fields, l.fields...), keyvals...)
+
+	if len(fields)%2 != 0 {
+		fields = append(fields[:len(fields)-1], badKey, fields[len(fields)-1])
+	}
+
+	var entry bytes.Buffer
+
+	if l.format == JSON {
+		encodeJSON(&entry, fields)
+	} else {
+		encodeLogfmt(&entry, fields)
+	}
+
+	entry.WriteByte('\n')
+
+	l.sink.Lock()
+	defer l.sink.Unlock()
+
+	l.sink.w.Write(entry.Bytes())
+}
+
+// value returns what is written for a field value: the text of errors and fmt.Stringer values,
+// and the value itself otherwise.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+func encodeJSON(entry *bytes.Buffer, fields []interface{}) {
+	entry.WriteByte('{')
+
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			entry.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(fmt.Sprint(fields[i]))
+		entry.Write(key)
+		entry.WriteByte(':')
+
+		encoded, err := json.Marshal(value(fields[i+1]))
+		if err != nil {
+			encoded, _ = json.Marshal(fmt.Sprint(fields[i+1]))
+		}
+
+		entry.Write(encoded)
+	}
+
+	entry.WriteByte('}')
+}
+
+func encodeLogfmt(entry *bytes.Buffer, fields []interface{}) {
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			entry.WriteByte(' ')
+		}
+
+		entry.WriteString(fmt.Sprint(fields[i]))
+		entry.WriteByte('=')
+
+		text := fmt.Sprint(value(fields[i+1]))
+
+		if text == "" || strings.ContainsAny(text, " =\"\\\t\r\n") {
+			text = strconv.Quote(text)
+		}
+
+		entry.WriteString(text)
+	}
+}
+
+// The functions below use the Logger shared by the application.
+
+// With returns a Logger that adds the given fields to every entry.
+func With(keyvals ...interface{}) *Logger {
+	return Default().With(keyvals...)
+}
+
+// Debug writes an entry about the details of the work being done.
+func Debug(msg string, keyvals ...interface{}) {
+	Default().Debug(msg, keyvals...)
+}
+
+// Info writes an entry about the normal work of the application.
+func Info(msg string, keyvals ...interface{}) {
+	Default().Info(msg, keyvals...)
+}
+
+// Warn writes an entry about something unexpected that the application recovered from.
+func Warn(msg string, keyvals ...interface{}) {
+	Default().Warn(msg, keyvals...)
+}
+
+// Error writes an entry about something that failed.
+func Error(msg string, keyvals ...interface{}) {
+	Default().Error(msg, keyvals...)
+}
+
+// Fatal writes an error entry and exits the program with the status 1.
+func Fatal(msg string, keyvals ...interface{}) {
+	Default().Fatal(msg, keyvals...)
+}
@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+)
+
+func newTestLogger(level Level, format Format) (*Logger, *bytes.Buffer) {
+	var output bytes.Buffer
+
+	l := New(&output, level, format)
+	l.now = func() time.Time { return time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	return l, &output
+}
+
+func TestLogger(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for Logger", func() {
+		g.It("should write logfmt entries, quoting the values when needed", func() {
+			l, output := newTestLogger(LevelInfo, Logfmt)
+
+			l.With("request_id", "abc").Info("best route found", "board", "GRU", "route", "GRU - CDG", "latency", 1500*time.Microsecond)
+			l.Error("could not write", "error", errors.New(`disk "full"`), "path", "")
+
+			g.Assert(output.String()).Equal(`time=2020-06-01T12:00:00Z level=info msg="best route found" request_id=abc board=GRU route="GRU - CDG" latency=1.5ms` + "\n" +
+				`time=2020-06-01T12:00:00Z level=error msg="could not write" error="disk \"full\"" path=""` + "\n")
+		})
+
+		g.It("should write JSON entries keeping the order of the fields", func() {
+			l, output := newTestLogger(LevelInfo, JSON)
+
+			l.With("request_id", "abc").Warn("route rejected", "line", 3, "cost", 1.5, "ok", false, "lonely")
+
+			g.Assert(output.String()).Equal(`{"time":"2020-06-01T12:00:00Z","level":"warn","msg":"route rejected",` +
+				`"request_id":"abc","line":3,"cost":1.5,"ok":false,"!BADKEY":"lonely"}` + "\n")
+		})
+
+		g.It("should discard the entries below its level", func() {
+			l, output := newTestLogger(LevelWarn, Logfmt)
+
+			l.Debug("debug")
+			l.Info("info")
+			l.Warn("warn")
+
+			g.Assert(output.String()).Equal("time=2020-06-01T12:00:00Z level=warn msg=warn\n")
+			g.Assert(l.Enabled(LevelError)).IsTrue()
+			g.Assert(l.Enabled(LevelInfo)).IsFalse()
+		})
+
+		g.It("should parse the levels and the formats", func() {
+			level, err := ParseLevel("WARN")
+			g.Assert(err).Equal(nil)
+			g.Assert(level).Equal(LevelWarn)
+
+			format, err := ParseFormat("json")
+			g.Assert(err).Equal(nil)
+			g.Assert(format).Equal(JSON)
+
+			_, err = ParseLevel("verbose")
+			g.Assert(err == nil).IsFalse()
+
+			_, err = ParseFormat("xml")
+			g.Assert(err == nil).IsFalse()
+		})
+	})
+
+	g.Describe("Tests for RotatingFile", func() {
+		g.It("should rotate the file by size, keeping the given number of backups", func() {
+			dir, _ := ioutil.TempDir("", "logger")
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "info.log")
+
+			file, err := OpenRotatingFile(path, 10, 2)
+			g.Assert(err).Equal(nil)
+
+			for _, entry := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+				file.Write([]byte(entry))
+			}
+
+			g.Assert(file.Close()).Equal(nil)
+
+			for name, content := range map[string]string{"": "fourth\n", ".1": "third\n", ".2": "second\n"} {
+				written, err := ioutil.ReadFile(path + name)
+				g.Assert(err).Equal(nil)
+				g.Assert(string(written)).Equal(content)
+			}
+		})
+
+		g.It("should keep appending to the file and warn once when a backup cannot be shifted", func() {
+			dir, _ := ioutil.TempDir("", "logger")
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "info.log")
+			ioutil.WriteFile(path+".1", []byte("backup\n"), 0666)
+			os.MkdirAll(filepath.Join(path+".2", "taken"), 0777) // a backup cannot be renamed over a directory
+
+			file, err := OpenRotatingFile(path, 10, 2)
+			g.Assert(err).Equal(nil)
+
+			var warnings bytes.Buffer
+			file.warnings = &warnings
+
+			for _, entry := range []string{"first\n", "second\n", "third\n"} {
+				file.Write([]byte(entry))
+			}
+
+			g.Assert(file.Close()).Equal(nil)
+
+			written, _ := ioutil.ReadFile(path)
+			backup, _ := ioutil.ReadFile(path + ".1")
+
+			g.Assert(string(written)).Equal("first\nsecond\nthird\n")
+			g.Assert(string(backup)).Equal("backup\n")
+			g.Assert(bytes.Count(warnings.Bytes(), []byte("could not rotate the log file"))).Equal(1)
+		})
+
+		g.It("should append to an existing file and write the entries through Open", func() {
+			dir, _ := ioutil.TempDir("", "logger")
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "info.log")
+			ioutil.WriteFile(path, []byte("before\n"), 0666)
+
+			l, err := Open(Config{Level: LevelInfo, Format: Logfmt, Output: path})
+			g.Assert(err).Equal(nil)
+
+			l.Info("after")
+			g.Assert(l.Close()).Equal(nil)
+
+			written, _ := ioutil.ReadFile(path)
+			g.Assert(bytes.HasPrefix(written, []byte("before\ntime="))).IsTrue()
+			g.Assert(bytes.HasSuffix(written, []byte("level=info msg=after\n"))).IsTrue()
+		})
+
+		g.It("should fail to open a file in a missing directory", func() {
+			dir, _ := ioutil.TempDir("", "logger")
+			defer os.RemoveAll(dir)
+
+			_, err := Open(Config{Output: filepath.Join(dir, "missing", "info.log")})
+
+			g.Assert(err == nil).IsFalse()
+		})
+	})
+}
@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// The outputs of Config that are not files.
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+)
+
+// Config tells where and how a Logger opened by Open writes.
+type Config struct {
+	Level  Level
+	Format Format
+	// Output is Stdout, Stderr or the path of a file, created if it does not exist.
+	Output string
+	// MaxSize is the size in bytes a file reaches before it is rotated, 0 to never rotate it.
+	MaxSize int64
+	// Backups is how many rotated files are kept, e.g. info.log.1 to info.log.3 for 3.
+	Backups int
+}
+
+// Open returns a Logger writing to the output of the config. Close must be called to close
+// the file it opens.
+func Open(config Config) (*Logger, error) {
+	var w io.Writer
+
+	switch config.Output {
+	case Stdout, "":
+		w = os.Stdout
+	case Stderr:
+		w = os.Stderr
+	default:
+		file, err := OpenRotatingFile(config.Output, config.MaxSize, config.Backups)
+		if err != nil {
+			return nil, err
+		}
+
+		l := New(file, config.Level, config.Format)
+		l.sink.closer = file
+
+		return l, nil
+	}
+
+	return New(w, config.Level, config.Format), nil
+}
+
+// RotatingFile is a log file that is renamed once it reaches its maximum size, e.g. info.log to
+// info.log.1, shifting the previous backups and removing the oldest one, and then created again.
+type RotatingFile struct {
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+	// stuck is set once the file could not be rotated, after which it is only appended to.
+	stuck    bool
+	warnings io.Writer
+	sync.Mutex
+}
+
+// OpenRotatingFile opens a log file to append to, creating it if it does not exist. It is rotated when
+// writing to it would exceed maxSize bytes, unless maxSize is 0, keeping the given number of backups.
+func OpenRotatingFile(path string, maxSize int64, backups int) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		backups:  backups,
+		warnings: os.Stderr,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("could not open the log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("could not stat the log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	return nil
+}
+
+func (f *RotatingFile) backup(n int) string {
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
+
+// rotate shifts the backups, removing the oldest one, and renames the file to the first backup.
+// The file is only closed once the backups are shifted, so on an error it is still the one written to.
+func (f *RotatingFile) rotate() error {
+	if f.backups == 0 {
+		f.file.Close()
+		f.file = nil
+
+		return ignoreNotExist(os.Remove(f.path))
+	}
+
+	for n := f.backups - 1; n > 0; n-- {
+		if err := ignoreNotExist(os.Rename(f.backup(n), f.backup(n+1))); err != nil {
+			return err
+		}
+	}
+
+	f.file.Close()
+	f.file = nil
+
+	return ignoreNotExist(os.Rename(f.path, f.backup(1)))
+}
+
+// ignoreNotExist ignores the errors about missing files, e.g. the backups not created yet.
+func ignoreNotExist(err error) error {
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Write appends p to the file, rotating it first when p would make it exceed its maximum size.
+// An entry larger than the maximum size is written to an empty file. When the file cannot be rotated,
+// a warning is written once and the file grows past its maximum size rather than losing entries.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file != nil && !f.stuck && f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			f.stuck = true
+			New(f.warnings, LevelWarn, Logfmt).Warn("could not rotate the log file, appending to it", "path", f.path, "error", err)
+		}
+	}
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
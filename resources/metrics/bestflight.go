@@ -0,0 +1,24 @@
+package metrics
+
+// DurationBuckets are the buckets of the durations, in seconds.
+var DurationBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// NodeBuckets are the buckets of the number of nodes expanded by a search.
+var NodeBuckets = []float64{1, 10, 100, 1000, 10000, 100000, 1000000}
+
+// The metrics of the application, in the Registry shared by it. The gauges of the graph and the
+// cache are registered by the application, which knows the resources to read them from.
+var (
+	HTTPRequests = Default().Counter("bestflight_http_requests_total",
+		"Number of HTTP requests by route, method and status.", "route", "method", "status")
+	HTTPRequestDuration = Default().Histogram("bestflight_http_request_duration_seconds",
+		"Duration of the HTTP requests by route, method and status.", DurationBuckets, "route", "method", "status")
+	SearchDuration = Default().Histogram("bestflight_search_duration_seconds",
+		"Duration of the route searches by algorithm.", DurationBuckets, "algorithm")
+	SearchNodesExpanded = Default().Histogram("bestflight_search_nodes_expanded",
+		"Number of nodes expanded by the route searches by algorithm.", NodeBuckets, "algorithm")
+	FileWriteFailures = Default().Counter("bestflight_file_write_failures_total",
+		"Number of writes to the routes file or its operations log that failed.")
+	LoadRejectedLines = Default().Counter("bestflight_load_rejected_lines_total",
+		"Number of lines of the routes file rejected when the routes are loaded.")
+)
@@ -0,0 +1,326 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds metrics and writes them in the text exposition format of Prometheus.
+// Registering a name twice returns the metric registered first, so metrics can be registered
+// wherever they are used.
+type Registry struct {
+	metrics map[string]metric
+	sync.Mutex
+}
+
+// metric is a counter, histogram or gauge that writes its samples.
+type metric interface {
+	write(w io.Writer, name string) error
+}
+
+var instance = New()
+
+// New is a constructor for an empty Registry, independent from the one shared by Default.
+func New() *Registry {
+	return &Registry{
+		metrics: make(map[string]metric),
+	}
+}
+
+// Default returns the Registry shared by the application.
+func Default() *Registry {
+	return instance
+}
+
+func (r *Registry) register(name string, m metric) metric {
+	r.Lock()
+	defer r.Unlock()
+
+	if existing, ok := r.metrics[name]; ok {
+		return existing
+	}
+
+	r.metrics[name] = m
+
+	return m
+}
+
+// Counter registers a counter partitioned by the given labels.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return r.register(name, &Counter{
+		family: newFamily(help, labels),
+		values: make(map[string]float64),
+	}).(*Counter)
+}
+
+// Histogram registers a histogram with the given upper bounds of its buckets, partitioned by the given labels.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return r.register(name, &Histogram{
+		family:  newFamily(help, labels),
+		buckets: append([]float64{}, buckets...),
+		series:  make(map[string]*histogramSeries),
+	}).(*Histogram)
+}
+
+// GaugeFunc registers a gauge whose value is read from value every time the metrics are written.
+func (r *Registry) GaugeFunc(name, help string, value func() float64) {
+	r.register(name, &gaugeFunc{help: help, value: value})
+}
+
+// Write writes every metric, sorted by name.
+func (r *Registry) Write(w io.Writer) error {
+	r.Lock()
+	names := make([]string, 0, len(r.metrics))
+
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+
+	metrics := make([]metric, len(names))
+	sort.Strings(names)
+
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.Unlock()
+
+	for i, m := range metrics {
+		if err := m.write(w, names[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// family holds what the series of a metric have in common.
+type family struct {
+	help   string
+	labels []string
+	sync.Mutex
+}
+
+func newFamily(help string, labels []string) family {
+	return family{help: help, labels: labels}
+}
+
+// key identifies a series by its label values, which must be as many as the labels.
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %d label values given for %d labels", len(values), len(f.labels)))
+	}
+
+	return strings.Join(values, "\xff")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelPairs formats the labels of a series, e.g. {route="/routes",status="200"}, adding extra pairs at the end.
+func (f *family) labelPairs(key string, extra ...string) string {
+	pairs := []string{}
+
+	if len(f.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, f.labels[i], labelEscaper.Replace(value)))
+		}
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], extra[i+1]))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func writeHeader(w io.Writer, name, help, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	return err
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// Counter is a value that only goes up, e.g. the number of requests.
+type Counter struct {
+	family
+	values map[string]float64
+}
+
+// Add adds a non negative value to the series of the given label values.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	key := c.key(labelValues)
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.values[key] += value
+}
+
+// Inc adds one to the series of the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Value returns the value of the series of the given label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+
+	c.Lock()
+	defer c.Unlock()
+
+	return c.values[key]
+}
+
+func (c *Counter) write(w io.Writer, name string) error {
+	if err := writeHeader(w, name, c.help, "counter"); err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	keys := make([]string, 0, len(c.values))
+
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		_, err := fmt.Fprintf(w, "%s%s %s\n", name, c.labelPairs(key), formatValue(c.values[key]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Histogram counts observations, e.g. durations, in buckets.
+type Histogram struct {
+	family
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Observe adds a value to the series of the given label values.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.Lock()
+	defer h.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+
+	s.count++
+	s.sum += value
+}
+
+// Count returns the number of observations of the series of the given label values.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+
+	h.Lock()
+	defer h.Unlock()
+
+	if s, ok := h.series[key]; ok {
+		return s.count
+	}
+
+	return 0
+}
+
+func (h *Histogram) write(w io.Writer, name string) error {
+	if err := writeHeader(w, name, h.help, "histogram"); err != nil {
+		return err
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		cumulative := uint64(0)
+
+		for i, bound := range append(append([]float64{}, h.buckets...), math.Inf(1)) {
+			if i < len(s.counts) {
+				cumulative += s.counts[i]
+			} else {
+				cumulative = s.count
+			}
+
+			_, err := fmt.Fprintf(w, "%s_bucket%s %d\n", name, h.labelPairs(key, "le", formatValue(bound)), cumulative)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n",
+			name, h.labelPairs(key), formatValue(s.sum), name, h.labelPairs(key), s.count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type gaugeFunc struct {
+	help  string
+	value func() float64
+}
+
+func (g *gaugeFunc) write(w io.Writer, name string) error {
+	if err := writeHeader(w, name, g.help, "gauge"); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%s %s\n", name, formatValue(g.value()))
+
+	return err
+}
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Write writes the metrics of the Registry shared by the application.
+func Write(w io.Writer) error {
+	return Default().Write(w)
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestMetrics(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for Registry", func() {
+		g.It("should write the counters by label values, sorted and escaped", func() {
+			registry := New()
+			requests := registry.Counter("requests_total", "Number of requests.", "route", "status")
+
+			requests.Inc("/routes", "200")
+			requests.Inc("/routes", "200")
+			requests.Add(3, `/a"b`, "404")
+
+			var output bytes.Buffer
+			registry.Write(&output)
+
+			g.Assert(requests.Value("/routes", "200")).Equal(float64(2))
+			g.Assert(output.String()).Equal("# HELP requests_total Number of requests.\n" +
+				"# TYPE requests_total counter\n" +
+				`requests_total{route="/a\"b",status="404"} 3` + "\n" +
+				`requests_total{route="/routes",status="200"} 2` + "\n")
+		})
+
+		g.It("should write cumulative buckets, the sum and the count of the histograms", func() {
+			registry := New()
+			durations := registry.Histogram("duration_seconds", "Duration.", []float64{0.1, 1})
+
+			durations.Observe(0.05)
+			durations.Observe(0.1)
+			durations.Observe(0.5)
+			durations.Observe(2)
+
+			var output bytes.Buffer
+			registry.Write(&output)
+
+			g.Assert(durations.Count()).Equal(uint64(4))
+			g.Assert(output.String()).Equal("# HELP duration_seconds Duration.\n" +
+				"# TYPE duration_seconds histogram\n" +
+				`duration_seconds_bucket{le="0.1"} 2` + "\n" +
+				`duration_seconds_bucket{le="1"} 3` + "\n" +
+				`duration_seconds_bucket{le="+Inf"} 4` + "\n" +
+				"duration_seconds_sum 2.65\n" +
+				"duration_seconds_count 4\n")
+		})
+
+		g.It("should read the gauges when writing and sort the metrics by name", func() {
+			registry := New()
+			size := 1
+
+			registry.GaugeFunc("size", "Size.", func() float64 { return float64(size) })
+			registry.Counter("errors_total", "Errors.").Inc()
+			size = 7
+
+			var output bytes.Buffer
+			registry.Write(&output)
+
+			g.Assert(output.String()).Equal("# HELP errors_total Errors.\n# TYPE errors_total counter\nerrors_total 1\n" +
+				"# HELP size Size.\n# TYPE size gauge\nsize 7\n")
+		})
+
+		g.It("should return the metric registered first for the same name", func() {
+			registry := New()
+
+			first := registry.Counter("errors_total", "Errors.")
+			first.Inc()
+
+			g.Assert(registry.Counter("errors_total", "Errors.").Value()).Equal(float64(1))
+		})
+	})
+}
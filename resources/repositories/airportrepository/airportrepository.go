@@ -1,13 +1,84 @@
 package airportrepository
 
-import "go-bestflight/resources/database"
+import (
+	"go-bestflight/domain/entities/airports"
+	"go-bestflight/domain/errors"
+	"go-bestflight/domain/ports"
+	"go-bestflight/resources/database"
+	"sort"
+)
+
+// AirportRepository gives access to the registered airports.
+type AirportRepository struct {
+	airports ports.AirportStore
+}
+
+// New is a constructor for an AirportRepository over the given store.
+func New(airports ports.AirportStore) *AirportRepository {
+	return &AirportRepository{
+		airports: airports,
+	}
+}
+
+// Default returns an AirportRepository over the database shared by the application.
+func Default() *AirportRepository {
+	return New(database.Default())
+}
 
 // IsRegistered returns true if the specified airport exists.
-func IsRegistered(airport string) bool {
-	return database.GetAirport(airport)
+func (ar *AirportRepository) IsRegistered(airport string) bool {
+	return ar.airports.GetAirport(airport)
 }
 
 // GetAllAirports returns all stored airports.
+func (ar *AirportRepository) GetAllAirports() []string {
+	return ar.airports.GetAllAirports()
+}
+
+// StoreAirportsDetails stores the reference data of the airports.
+func (ar *AirportRepository) StoreAirportsDetails(details []airports.Airport) {
+	ar.airports.StoreAirportsDetails(details)
+}
+
+// GetAirport returns an airport with its reference data, if there is any. Airports that are
+// registered by routes but have no reference data are returned with their code only.
+func (ar *AirportRepository) GetAirport(code string) (airports.Airport, error) {
+	if airport, ok := ar.airports.GetAirportDetails(code); ok {
+		return airport, nil
+	}
+
+	if ar.airports.GetAirport(code) {
+		return airports.Airport{Code: code}, nil
+	}
+
+	return airports.Airport{}, errors.NewAirportNotFoundErr()
+}
+
+// GetRegisteredAirports returns the airports registered by routes with their reference data, sorted by code.
+func (ar *AirportRepository) GetRegisteredAirports() []airports.Airport {
+	codes := ar.airports.GetAllAirports()
+	sort.Strings(codes)
+
+	registered := make([]airports.Airport, 0, len(codes))
+
+	for _, code := range codes {
+		airport, ok := ar.airports.GetAirportDetails(code)
+		if !ok {
+			airport = airports.Airport{Code: code}
+		}
+
+		registered = append(registered, airport)
+	}
+
+	return registered
+}
+
+// IsRegistered returns true if the specified airport exists in the shared database.
+func IsRegistered(airport string) bool {
+	return Default().IsRegistered(airport)
+}
+
+// GetAllAirports returns all airports stored in the shared database.
 func GetAllAirports() []string {
-	return database.GetAllAirports()
+	return Default().GetAllAirports()
 }
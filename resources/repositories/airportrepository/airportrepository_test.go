@@ -1,6 +1,8 @@
 package airportrepository
 
 import (
+	"go-bestflight/domain/entities/airports"
+	"go-bestflight/domain/errors"
 	"go-bestflight/resources/database"
 	"testing"
 
@@ -23,4 +25,43 @@ func TestAirportRepository(t *testing.T) {
 			g.Assert(IsRegistered(airport)).IsTrue()
 		})
 	})
+
+	g.Describe("Tests for GetAirport and GetRegisteredAirports", func() {
+		db := database.New()
+		repository := New(db)
+		gru := airports.Airport{Code: "GRU", Name: "Guarulhos", City: "Sao Paulo"}
+		lhr := airports.Airport{Code: "LHR", Name: "Heathrow", City: "London"}
+
+		db.StoreAirport("GRU")
+		db.StoreAirport("CDG")
+		repository.StoreAirportsDetails([]airports.Airport{gru, lhr})
+
+		g.It("should return the reference data of registered and not registered airports", func() {
+			airport, err := repository.GetAirport("GRU")
+			g.Assert(err).Equal(nil)
+			g.Assert(airport).Equal(gru)
+
+			airport, err = repository.GetAirport("LHR")
+			g.Assert(err).Equal(nil)
+			g.Assert(airport).Equal(lhr)
+		})
+
+		g.It("should return only the code of a registered airport without reference data", func() {
+			airport, err := repository.GetAirport("CDG")
+
+			g.Assert(err).Equal(nil)
+			g.Assert(airport).Equal(airports.Airport{Code: "CDG"})
+			g.Assert(repository.IsRegistered("CDG")).IsTrue()
+		})
+
+		g.It("should return AirportNotFoundErr for an unknown airport", func() {
+			_, err := repository.GetAirport("SCL")
+
+			g.Assert(err).Equal(errors.NewAirportNotFoundErr())
+		})
+
+		g.It("should list only the registered airports sorted by code", func() {
+			g.Assert(repository.GetRegisteredAirports()).Equal([]airports.Airport{{Code: "CDG"}, gru})
+		})
+	})
 }
@@ -1,43 +1,159 @@
 package routerepository
 
 import (
+	"context"
 	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/ports"
 	"go-bestflight/resources/cache"
 	"go-bestflight/resources/database"
 	"go-bestflight/resources/file"
-	"log"
+	"go-bestflight/resources/repositories/unitofwork"
+	"go-bestflight/resources/tracing"
+	"sync"
 )
 
+// RouteRepository keeps the routes consistent across the database, cache and file.
+type RouteRepository struct {
+	routes     ports.RouteStore
+	airports   ports.AirportStore
+	cache      ports.RouteGraphCache
+	file       ports.RouteFile
+	commitLock *sync.Mutex // serializes the commits of the units of work over the resources
+}
+
+// New is a constructor for a RouteRepository over the given resources, which it owns: no other
+// RouteRepository may change them.
+func New(routes ports.RouteStore, airports ports.AirportStore, cache ports.RouteGraphCache, file ports.RouteFile) *RouteRepository {
+	return &RouteRepository{
+		routes:     routes,
+		airports:   airports,
+		cache:      cache,
+		file:       file,
+		commitLock: &sync.Mutex{},
+	}
+}
+
+// sharedCommitLock is the commit lock of the repositories returned by Default, which all own the shared resources.
+var sharedCommitLock sync.Mutex
+
+// Default returns a RouteRepository over the resources shared by the application.
+func Default() *RouteRepository {
+	rr := New(database.Default(), database.Default(), cache.Default(), file.Default())
+	rr.commitLock = &sharedCommitLock
+
+	return rr
+}
+
+func (rr *RouteRepository) unitOfWork() *unitofwork.UnitOfWork {
+	return unitofwork.New(rr.commitLock, rr.routes, rr.airports, rr.cache, rr.file)
+}
+
 // StoreRoute encapsulates the adding of new routes and airports to the database, cache and file.
-func StoreRoute(route r.Route) error {
-	database.StoreAirport(route.Boarding)
-	database.StoreAirport(route.Destination)
-	database.StoreRoute(route)
+// Either every resource is changed or none of them.
+func (rr *RouteRepository) StoreRoute(ctx context.Context, route r.Route) error {
+	work := rr.unitOfWork()
+	work.StoreRoute(route)
+
+	return work.Commit(ctx)
+}
+
+// StoreRoutes stores multiple routes at once: either all of them are stored or none.
+func (rr *RouteRepository) StoreRoutes(ctx context.Context, routes []r.Route) error {
+	work := rr.unitOfWork()
+	work.StoreRoutes(routes)
+
+	return work.Commit(ctx)
+}
+
+// StoreNewRoutes stores at once the routes that are not stored yet and returns the ones that are,
+// which are skipped: either every other route is stored or none.
+func (rr *RouteRepository) StoreNewRoutes(ctx context.Context, routes []r.Route) ([]r.Route, error) {
+	skipped := []r.Route{}
 
-	err := file.Write(route)
+	work := rr.unitOfWork()
+	work.StoreNewRoutes(routes, func(route r.Route) {
+		skipped = append(skipped, route)
+	})
+
+	err := work.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return skipped, nil
+}
+
+// ApplyChanges applies changes read from the file to the database and cache at once: either all of
+// them are applied or none.
+func (rr *RouteRepository) ApplyChanges(ctx context.Context, changes r.RouteChanges) error {
+	work := rr.unitOfWork()
+	work.ApplyChanges(changes)
+
+	return work.Commit(ctx)
+}
+
+// DeleteRoute encapsulates the removal of a route from the database, file and cache.
+// Airports that are no longer part of any route are removed as well.
+func (rr *RouteRepository) DeleteRoute(ctx context.Context, boarding, destination string) (r.Route, error) {
+	cost, err := rr.routes.GetRouteCost(boarding, destination)
 	if err != nil {
-		log.Printf("error when writing to file: %v", err)
-		log.Println("removing route from database")
-		database.DeleteRoute(route)
-		return err
+		return r.Route{}, err
 	}
 
-	cache.AddRoute(route)
+	route := r.Route{
+		Boarding:    boarding,
+		Destination: destination,
+		Cost:        cost,
+	}
+
+	work := rr.unitOfWork()
+	work.DeleteRoute(route)
+
+	err = work.Commit(ctx)
+	if err != nil {
+		return r.Route{}, err
+	}
+
+	return route, nil
+}
+
+// UpdateRoute encapsulates the replacement of a route cost in the database, file and cache.
+func (rr *RouteRepository) UpdateRoute(ctx context.Context, route r.Route) error {
+	work := rr.unitOfWork()
+	work.UpdateRoute(route)
 
-	return nil
+	return work.Commit(ctx)
 }
 
 // StoreRouteFromFile stores routes and airports from file into database and cache.
-func StoreRouteFromFile(route r.Route) {
-	database.StoreAirport(route.Boarding)
-	database.StoreAirport(route.Destination)
-	database.StoreRoute(route)
-	cache.AddRoute(route)
+func (rr *RouteRepository) StoreRouteFromFile(route r.Route) {
+	rr.airports.StoreAirport(route.Boarding)
+	rr.airports.StoreAirport(route.Destination)
+	rr.routes.StoreRoute(route)
+	rr.cache.AddRoute(route)
+}
+
+// StoreRoutesFromFile stores multiple routes and airports from file into database and cache at once.
+func (rr *RouteRepository) StoreRoutesFromFile(ctx context.Context, routes []r.Route) {
+	_, span := tracing.Start(ctx, "database.write", "changes", len(routes))
+
+	for _, route := range routes {
+		rr.airports.StoreAirport(route.Boarding)
+		rr.airports.StoreAirport(route.Destination)
+		rr.routes.StoreRoute(route)
+	}
+
+	span.End()
+
+	_, span = tracing.Start(ctx, "graph.build", "changes", len(routes))
+	defer span.End()
+
+	rr.cache.AddRoutes(routes)
 }
 
 // RouteExists defines if a route is already stored or not based on a cost search.
-func RouteExists(boarding, destination string) bool {
-	cost, _ := database.GetRouteCost(boarding, destination)
+func (rr *RouteRepository) RouteExists(boarding, destination string) bool {
+	cost, _ := rr.routes.GetRouteCost(boarding, destination)
 
 	if cost == -1 {
 		return false
@@ -46,6 +162,52 @@ func RouteExists(boarding, destination string) bool {
 	return true
 }
 
+// GetAllRoutes returns every stored route, in no particular order.
+func (rr *RouteRepository) GetAllRoutes() []r.Route {
+	return rr.routes.GetAllRoutes()
+}
+
+func (rr *RouteRepository) HasConnection(boarding string) bool {
+	return rr.routes.HasConnection(boarding)
+}
+
+// GetGraph returns the current snapshot of the routes graph.
+func (rr *RouteRepository) GetGraph() *r.Graph {
+	return rr.cache.GetGraph()
+}
+
+// The functions below use the resources shared by the application.
+
+// StoreRoute encapsulates the adding of new routes and airports to the database, cache and file.
+func StoreRoute(ctx context.Context, route r.Route) error {
+	return Default().StoreRoute(ctx, route)
+}
+
+// DeleteRoute encapsulates the removal of a route from the database, file and cache.
+func DeleteRoute(ctx context.Context, boarding, destination string) (r.Route, error) {
+	return Default().DeleteRoute(ctx, boarding, destination)
+}
+
+// UpdateRoute encapsulates the replacement of a route cost in the database, file and cache.
+func UpdateRoute(ctx context.Context, route r.Route) error {
+	return Default().UpdateRoute(ctx, route)
+}
+
+// StoreRouteFromFile stores routes and airports from file into database and cache.
+func StoreRouteFromFile(route r.Route) {
+	Default().StoreRouteFromFile(route)
+}
+
+// StoreRoutesFromFile stores multiple routes and airports from file into database and cache at once.
+func StoreRoutesFromFile(ctx context.Context, routes []r.Route) {
+	Default().StoreRoutesFromFile(ctx, routes)
+}
+
+// RouteExists defines if a route is already stored or not based on a cost search.
+func RouteExists(boarding, destination string) bool {
+	return Default().RouteExists(boarding, destination)
+}
+
 func HasConnection(boarding string) bool {
-	return database.HasConnection(boarding)
+	return Default().HasConnection(boarding)
 }
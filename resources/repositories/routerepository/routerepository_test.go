@@ -1,27 +1,50 @@
 package routerepository
 
 import (
+	"context"
 	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/errors"
 	"go-bestflight/resources/cache"
 	"go-bestflight/resources/database"
 	"go-bestflight/resources/file"
 	"testing"
+	"time"
 
 	"github.com/franela/goblin"
 )
 
 func TestRoutesRepository(t *testing.T) {
+	t.Parallel()
+
 	g := goblin.Goblin(t)
 
+	var (
+		db         *database.Database
+		mc         *cache.Memcache
+		routesFile *file.RoutesFile
+		repo       *RouteRepository
+	)
+
+	// isolate gives the test a repository over its own resources.
+	isolate := func(filePath string) {
+		db = database.New()
+		mc = cache.New()
+		routesFile, _ = file.Open(filePath)
+		repo = New(db, db, mc, routesFile)
+	}
+
+	// breakFile keeps the database and cache of the repository but replaces its file
+	// by one whose empty path will generate errors when reading or writing it.
+	breakFile := func() {
+		routesFile.Remove()
+		routesFile, _ = file.Open("")
+		repo = New(db, db, mc, routesFile)
+	}
+
 	g.Describe("Tests for StoreRoute", func() {
 		g.It("should successfully store a route on database, cache and file", func() {
-			filePath := "test.csv"
-
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
-			file.Reset(filePath)
+			isolate("test.csv")
+			defer routesFile.Remove()
 
 			route := r.Route{
 				Boarding:    "XYZ",
@@ -29,15 +52,15 @@ func TestRoutesRepository(t *testing.T) {
 				Cost:        1000,
 			}
 
-			err := StoreRoute(route)
+			err := repo.StoreRoute(context.Background(), route)
 
 			g.Assert(err).Equal(nil)
 
-			cost, _ := database.GetRouteCost(route.Boarding, route.Destination)
-			bAirport := database.GetAirport(route.Boarding)
-			dAirport := database.GetAirport(route.Destination)
-			routesFromCache := cache.GetAllRoutes()
-			routesFromFile, _ := file.ReadFile()
+			cost, _ := db.GetRouteCost(route.Boarding, route.Destination)
+			bAirport := db.GetAirport(route.Boarding)
+			dAirport := db.GetAirport(route.Destination)
+			routesFromCache := mc.GetAllRoutes()
+			routesFromFile, _ := routesFile.ReadFile()
 
 			g.Assert(cost).Equal(1000)
 			g.Assert(bAirport).IsTrue()
@@ -45,18 +68,10 @@ func TestRoutesRepository(t *testing.T) {
 			g.Assert(len(routesFromCache[route.Boarding])).Equal(1)
 			g.Assert(len(routesFromFile)).Equal(1)
 			g.Assert(routesFromFile[0]).Equal(route)
-
-			file.Remove()
 		})
 
-		g.It("should remove route from database if file writing fails", func() {
-			filePath := "" // empty path will generate errors when opening file
-
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
-			file.Reset(filePath)
+		g.It("should remove route and airports from database if file writing fails", func() {
+			isolate("")
 
 			route := r.Route{
 				Boarding:    "XYZ",
@@ -64,33 +79,57 @@ func TestRoutesRepository(t *testing.T) {
 				Cost:        1000,
 			}
 
-			err := StoreRoute(route)
+			err := repo.StoreRoute(context.Background(), route)
 
 			g.Assert(err != nil).IsTrue()
 
-			cost, _ := database.GetRouteCost(route.Boarding, route.Destination)
-			bAirport := database.GetAirport(route.Boarding)
-			dAirport := database.GetAirport(route.Destination)
-			routesFromCache := cache.GetAllRoutes()
+			cost, _ := db.GetRouteCost(route.Boarding, route.Destination)
+			bAirport := db.GetAirport(route.Boarding)
+			dAirport := db.GetAirport(route.Destination)
+			routesFromCache := mc.GetAllRoutes()
 
 			g.Assert(cost).Equal(-1)
-			g.Assert(bAirport).IsTrue()
-			g.Assert(dAirport).IsTrue()
+			g.Assert(bAirport).IsFalse()
+			g.Assert(dAirport).IsFalse()
 			g.Assert(len(routesFromCache[route.Boarding])).Equal(0)
+		})
+	})
+
+	g.Describe("Tests for the commit lock", func() {
+		g.It("should not make a repository wait for the commits of a repository over other resources", func() {
+			db := database.New()
+			owner := New(db, db, cache.New(), file.NewReadOnly(nil))
+			otherFile, _ := file.Open("other.csv")
+			defer otherFile.Remove()
+
+			other := database.New()
+			independent := New(other, other, cache.New(), otherFile)
+
+			owner.commitLock.Lock()
+			defer owner.commitLock.Unlock()
+
+			committed := make(chan error, 1)
+			go func() {
+				committed <- independent.StoreRoute(context.Background(), r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75})
+			}()
+
+			select {
+			case err := <-committed:
+				g.Assert(err).Equal(nil)
+			case <-time.After(time.Second):
+				g.Fail("the commit waited for the lock of another repository")
+			}
+		})
 
-			file.Remove()
+		g.It("should share the lock of the repositories over the shared resources", func() {
+			g.Assert(Default().commitLock == Default().commitLock).IsTrue()
 		})
 	})
 
 	g.Describe("Tests for StoreRouteFromFile", func() {
 		g.It("should successfully load routes into the database and cache", func() {
-			filePath := "test.csv"
-
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
-			file.Reset(filePath)
+			isolate("test.csv")
+			defer routesFile.Remove()
 
 			routes := []r.Route{
 				{
@@ -121,21 +160,21 @@ func TestRoutesRepository(t *testing.T) {
 			}
 
 			for _, r := range routes {
-				StoreRouteFromFile(r)
+				repo.StoreRouteFromFile(r)
 			}
 
-			cost, _ := database.GetRouteCost(routes[0].Boarding, routes[0].Destination)
-			cost2, _ := database.GetRouteCost(routes[1].Boarding, routes[1].Destination)
-			cost3, _ := database.GetRouteCost(routes[2].Boarding, routes[2].Destination)
-			cost4, _ := database.GetRouteCost(routes[3].Boarding, routes[3].Destination)
-			cost5, _ := database.GetRouteCost(routes[4].Boarding, routes[4].Destination)
-			airportA := database.GetAirport(routes[0].Boarding)
-			airportB := database.GetAirport(routes[0].Destination)
-			airportC := database.GetAirport(routes[1].Destination)
-			airportD := database.GetAirport(routes[2].Destination)
-			airportE := database.GetAirport(routes[3].Destination)
-			routesFromCache := cache.GetAllRoutes()
-			routesFromFile, _ := file.ReadFile()
+			cost, _ := db.GetRouteCost(routes[0].Boarding, routes[0].Destination)
+			cost2, _ := db.GetRouteCost(routes[1].Boarding, routes[1].Destination)
+			cost3, _ := db.GetRouteCost(routes[2].Boarding, routes[2].Destination)
+			cost4, _ := db.GetRouteCost(routes[3].Boarding, routes[3].Destination)
+			cost5, _ := db.GetRouteCost(routes[4].Boarding, routes[4].Destination)
+			airportA := db.GetAirport(routes[0].Boarding)
+			airportB := db.GetAirport(routes[0].Destination)
+			airportC := db.GetAirport(routes[1].Destination)
+			airportD := db.GetAirport(routes[2].Destination)
+			airportE := db.GetAirport(routes[3].Destination)
+			routesFromCache := mc.GetAllRoutes()
+			routesFromFile, _ := routesFile.ReadFile()
 
 			g.Assert(cost).Equal(10)
 			g.Assert(cost2).Equal(20)
@@ -153,20 +192,13 @@ func TestRoutesRepository(t *testing.T) {
 			g.Assert(len(routesFromCache[routes[4].Boarding])).Equal(1)
 
 			g.Assert(len(routesFromFile)).Equal(0)
-
-			file.Remove()
 		})
 	})
 
 	g.Describe("Tests for RouteExists", func() {
 		g.It("should return true if a route has a cost stored for ir", func() {
-			filePath := "test.csv"
-
-			database.Connect()
-			database.Truncate()
-			cache.Connect()
-			cache.Truncate()
-			file.Reset(filePath)
+			isolate("test.csv")
+			defer routesFile.Remove()
 
 			routes := []r.Route{
 				{
@@ -197,18 +229,167 @@ func TestRoutesRepository(t *testing.T) {
 			}
 
 			for _, r := range routes {
-				StoreRoute(r)
+				repo.StoreRoute(context.Background(), r)
 			}
 
-			g.Assert(RouteExists(routes[0].Boarding, routes[0].Destination)).IsTrue()
-			g.Assert(RouteExists(routes[1].Boarding, routes[1].Destination)).IsTrue()
-			g.Assert(RouteExists(routes[2].Boarding, routes[2].Destination)).IsTrue()
-			g.Assert(RouteExists(routes[3].Boarding, routes[3].Destination)).IsTrue()
-			g.Assert(RouteExists(routes[4].Boarding, routes[4].Destination)).IsTrue()
+			g.Assert(repo.RouteExists(routes[0].Boarding, routes[0].Destination)).IsTrue()
+			g.Assert(repo.RouteExists(routes[1].Boarding, routes[1].Destination)).IsTrue()
+			g.Assert(repo.RouteExists(routes[2].Boarding, routes[2].Destination)).IsTrue()
+			g.Assert(repo.RouteExists(routes[3].Boarding, routes[3].Destination)).IsTrue()
+			g.Assert(repo.RouteExists(routes[4].Boarding, routes[4].Destination)).IsTrue()
+
+			g.Assert(repo.RouteExists("UKN", "NOW")).IsFalse()
+		})
+	})
+
+	g.Describe("Tests for DeleteRoute", func() {
+		g.It("should delete a route from database, cache and file and clean up airports", func() {
+			isolate("test.csv")
+			defer routesFile.Remove()
+
+			route := r.Route{Boarding: "AAA", Destination: "BBB", Cost: 10}
+			route2 := r.Route{Boarding: "BBB", Destination: "CCC", Cost: 5}
+
+			repo.StoreRoute(context.Background(), route)
+			repo.StoreRoute(context.Background(), route2)
+
+			deleted, err := repo.DeleteRoute(context.Background(), "AAA", "BBB")
+
+			g.Assert(err).Equal(nil)
+			g.Assert(deleted).Equal(route)
+
+			routesFromFile, _ := routesFile.ReadFile()
+			_, okCache := mc.GetAllRoutes()["AAA"]
+
+			g.Assert(repo.RouteExists("AAA", "BBB")).IsFalse()
+			g.Assert(okCache).IsFalse()
+			g.Assert(routesFromFile).Equal([]r.Route{route2})
+			g.Assert(db.GetAirport("AAA")).IsFalse()
+			g.Assert(db.GetAirport("BBB")).IsTrue()
+			g.Assert(db.GetAirport("CCC")).IsTrue()
+		})
+
+		g.It("should return RouteNotFoundErr for a not stored route", func() {
+			isolate("")
+
+			_, err := repo.DeleteRoute(context.Background(), "AAA", "BBB")
+
+			g.Assert(err).Equal(errors.NewRouteNotFoundErr())
+		})
+
+		g.It("should restore the route into database if file rewriting fails", func() {
+			isolate("test.csv")
+			defer routesFile.Remove()
+
+			route := r.Route{Boarding: "AAA", Destination: "BBB", Cost: 10}
+
+			repo.StoreRoute(context.Background(), route)
+			breakFile()
+
+			_, err := repo.DeleteRoute(context.Background(), "AAA", "BBB")
+
+			g.Assert(err != nil).IsTrue()
+			g.Assert(repo.RouteExists("AAA", "BBB")).IsTrue()
+			g.Assert(len(mc.GetAllRoutes()["AAA"])).Equal(1)
+			g.Assert(db.GetAirport("AAA")).IsTrue()
+		})
+	})
 
-			g.Assert(RouteExists("UKN", "NOW")).IsFalse()
+	g.Describe("Tests for UpdateRoute", func() {
+		g.It("should update the route cost on database, cache and file", func() {
+			isolate("test.csv")
+			defer routesFile.Remove()
 
-			file.Remove()
+			repo.StoreRoute(context.Background(), r.Route{Boarding: "AAA", Destination: "BBB", Cost: 10})
+
+			route := r.Route{Boarding: "AAA", Destination: "BBB", Cost: 3}
+			err := repo.UpdateRoute(context.Background(), route)
+
+			g.Assert(err).Equal(nil)
+
+			cost, _ := db.GetRouteCost("AAA", "BBB")
+			routesFromFile, _ := routesFile.ReadFile()
+
+			g.Assert(cost).Equal(3)
+			g.Assert(mc.GetAllRoutes()["AAA"]).Equal([]r.Connection{{Airport: "BBB", Cost: 3}})
+			g.Assert(routesFromFile).Equal([]r.Route{route})
+		})
+
+		g.It("should return RouteNotFoundErr for a not stored route", func() {
+			isolate("")
+
+			err := repo.UpdateRoute(context.Background(), r.Route{Boarding: "AAA", Destination: "BBB", Cost: 3})
+
+			g.Assert(err).Equal(errors.NewRouteNotFoundErr())
+		})
+
+		g.It("should restore the previous cost into database if file rewriting fails", func() {
+			isolate("test.csv")
+			defer routesFile.Remove()
+
+			repo.StoreRoute(context.Background(), r.Route{Boarding: "AAA", Destination: "BBB", Cost: 10})
+			breakFile()
+
+			err := repo.UpdateRoute(context.Background(), r.Route{Boarding: "AAA", Destination: "BBB", Cost: 3})
+
+			cost, _ := db.GetRouteCost("AAA", "BBB")
+
+			g.Assert(err != nil).IsTrue()
+			g.Assert(cost).Equal(10)
+			g.Assert(mc.GetAllRoutes()["AAA"]).Equal([]r.Connection{{Airport: "BBB", Cost: 10}})
+		})
+	})
+
+	g.Describe("Tests for StoreRoutes", func() {
+		g.It("should store every route or none of them", func() {
+			isolate("test.csv")
+			defer routesFile.Remove()
+
+			routes := []r.Route{
+				{Boarding: "AAA", Destination: "BBB", Cost: 3},
+				{Boarding: "BBB", Destination: "CCC", Cost: 4},
+			}
+
+			g.Assert(repo.StoreRoutes(context.Background(), routes)).Equal(nil)
+
+			routesFromFile, _ := routesFile.ReadFile()
+
+			g.Assert(routesFromFile).Equal(routes)
+			g.Assert(len(mc.GetAllRoutes())).Equal(2)
+
+			breakFile()
+
+			err := repo.StoreRoutes(context.Background(), []r.Route{
+				{Boarding: "CCC", Destination: "DDD", Cost: 5},
+				{Boarding: "DDD", Destination: "EEE", Cost: 6},
+			})
+
+			g.Assert(err != nil).IsTrue()
+			g.Assert(db.GetAirport("DDD")).IsFalse()
+			g.Assert(len(db.GetAllRoutes())).Equal(2)
+			g.Assert(len(mc.GetAllRoutes()["CCC"])).Equal(0)
+		})
+	})
+
+	g.Describe("Tests for StoreNewRoutes", func() {
+		g.It("should store the routes that are not stored and return the others", func() {
+			isolate("test.csv")
+			defer routesFile.Remove()
+
+			stored := r.Route{Boarding: "AAA", Destination: "BBB", Cost: 3}
+			repo.StoreRoute(context.Background(), stored)
+
+			skipped, err := repo.StoreNewRoutes(context.Background(), []r.Route{
+				{Boarding: "AAA", Destination: "BBB", Cost: 8},
+				{Boarding: "BBB", Destination: "CCC", Cost: 4},
+			})
+
+			routesFromFile, _ := routesFile.ReadFile()
+
+			g.Assert(err).Equal(nil)
+			g.Assert(skipped).Equal([]r.Route{{Boarding: "AAA", Destination: "BBB", Cost: 8}})
+			g.Assert(routesFromFile).Equal([]r.Route{stored, {Boarding: "BBB", Destination: "CCC", Cost: 4}})
+			g.Assert(mc.GetAllRoutes()["AAA"]).Equal([]r.Connection{{Airport: "BBB", Cost: 3}})
 		})
 	})
 }
@@ -0,0 +1,418 @@
+package unitofwork
+
+import (
+	"context"
+	r "go-bestflight/domain/entities/routes"
+	"go-bestflight/domain/errors"
+	"go-bestflight/domain/ports"
+	"go-bestflight/resources/logger"
+	"go-bestflight/resources/tracing"
+	"sync"
+)
+
+type phase int
+
+const (
+	databasePhase phase = iota
+	filePhase
+	cachePhase
+	phases
+)
+
+// step is a staged change to one resource and the way to undo it once applied.
+type step struct {
+	apply func() error
+	undo  func() error
+}
+
+// UnitOfWork stages changes to the database, the file and the cache and applies them all or none.
+// On commit, the database changes are applied first, then the file and at last the cache, which is
+// what searches read. If any step fails, the steps already applied are undone in reverse order.
+type UnitOfWork struct {
+	lock     sync.Locker
+	routes   ports.RouteStore
+	airports ports.AirportStore
+	cache    ports.RouteGraphCache
+	file     ports.RouteFile
+	steps    [phases][]step
+}
+
+// New is a constructor for an empty UnitOfWork over the given resources. It commits holding lock,
+// which must be shared by every UnitOfWork over the same resources: it keeps their commits from
+// interleaving, so a rollback never undoes someone else's change.
+func New(lock sync.Locker, routes ports.RouteStore, airports ports.AirportStore, cache ports.RouteGraphCache, file ports.RouteFile) *UnitOfWork {
+	return &UnitOfWork{
+		lock:     lock,
+		routes:   routes,
+		airports: airports,
+		cache:    cache,
+		file:     file,
+	}
+}
+
+func (u *UnitOfWork) stage(p phase, apply func() error, undo func() error) {
+	u.steps[p] = append(u.steps[p], step{apply: apply, undo: undo})
+}
+
+// StoreRoute stages a new route, registering its airports when they are not registered yet.
+// The commit fails with RouteAlreadyExistErr if the route is stored by then.
+func (u *UnitOfWork) StoreRoute(route r.Route) {
+	u.registerAirport(route.Boarding)
+	u.registerAirport(route.Destination)
+
+	inserted := false
+
+	u.stage(databasePhase, func() error {
+		if _, err := u.routes.GetRouteCost(route.Boarding, route.Destination); err == nil {
+			return errors.NewRouteAlreadyExistErr()
+		}
+
+		u.routes.StoreRoute(route)
+		inserted = true
+
+		return nil
+	}, func() error {
+		if inserted {
+			u.routes.DeleteRoute(route)
+		}
+
+		return nil
+	})
+
+	u.stage(filePhase, func() error {
+		return u.file.Write(route)
+	}, func() error {
+		return u.file.Delete(route)
+	})
+
+	u.stage(cachePhase, func() error {
+		u.cache.AddRoute(route)
+		return nil
+	}, func() error {
+		u.cache.DeleteRoute(route)
+		return nil
+	})
+}
+
+// StoreRoutes stages new routes like StoreRoute, all at once: the file logs them with a single
+// write and the cache publishes the routes graph once for all of them, so searches see either none
+// or all of them. The commit fails with RouteAlreadyExistErr if any of them is stored by then.
+func (u *UnitOfWork) StoreRoutes(routes []r.Route) {
+	u.storeRoutes(routes, nil)
+}
+
+// StoreNewRoutes stages new routes like StoreRoutes, but a route that is stored by the commit is
+// passed to skip instead of failing it.
+func (u *UnitOfWork) StoreNewRoutes(routes []r.Route, skip func(route r.Route)) {
+	u.storeRoutes(routes, skip)
+}
+
+func (u *UnitOfWork) storeRoutes(routes []r.Route, skip func(route r.Route)) {
+	stored := []r.Route{}
+	staged := make(map[string]bool)
+
+	for _, route := range routes {
+		route := route
+		inserted := false
+
+		for _, airport := range []string{route.Boarding, route.Destination} {
+			if !staged[airport] {
+				staged[airport] = true
+				u.registerAirport(airport)
+			}
+		}
+
+		u.stage(databasePhase, func() error {
+			if _, err := u.routes.GetRouteCost(route.Boarding, route.Destination); err == nil {
+				if skip == nil {
+					return errors.NewRouteAlreadyExistErr()
+				}
+
+				skip(route)
+
+				return nil
+			}
+
+			u.routes.StoreRoute(route)
+			inserted = true
+			stored = append(stored, route)
+
+			return nil
+		}, func() error {
+			if inserted {
+				u.routes.DeleteRoute(route)
+			}
+
+			return nil
+		})
+	}
+
+	u.stage(filePhase, func() error {
+		return u.file.WriteAll(stored)
+	}, func() error {
+		return u.file.DeleteAll(stored)
+	})
+
+	u.stage(cachePhase, func() error {
+		u.cache.ApplyChanges(stored, nil)
+		return nil
+	}, func() error {
+		u.cache.ApplyChanges(nil, stored)
+		return nil
+	})
+}
+
+// UpdateRoute stages the replacement of the cost of a stored route.
+// The commit fails with RouteNotFoundErr if the route is not stored by then.
+func (u *UnitOfWork) UpdateRoute(route r.Route) {
+	previous := route
+
+	u.stage(databasePhase, func() error {
+		cost, err := u.routes.GetRouteCost(route.Boarding, route.Destination)
+		if err != nil {
+			return err
+		}
+
+		previous.Cost = cost
+		u.routes.StoreRoute(route)
+
+		return nil
+	}, func() error {
+		u.routes.StoreRoute(previous)
+		return nil
+	})
+
+	u.stage(filePhase, func() error {
+		return u.file.Update(route)
+	}, func() error {
+		return u.file.Update(previous)
+	})
+
+	u.stage(cachePhase, func() error {
+		u.cache.UpdateRoute(route)
+		return nil
+	}, func() error {
+		u.cache.UpdateRoute(previous)
+		return nil
+	})
+}
+
+// DeleteRoute stages the removal of a stored route, unregistering the airports left without routes.
+// The commit fails with RouteNotFoundErr if the route is not stored by then.
+func (u *UnitOfWork) DeleteRoute(route r.Route) {
+	previous := route
+
+	u.stage(databasePhase, func() error {
+		cost, err := u.routes.GetRouteCost(route.Boarding, route.Destination)
+		if err != nil {
+			return err
+		}
+
+		previous.Cost = cost
+		u.routes.DeleteRoute(route)
+
+		return nil
+	}, func() error {
+		u.routes.StoreRoute(previous)
+		return nil
+	})
+
+	u.unregisterAirport(route.Boarding)
+	u.unregisterAirport(route.Destination)
+
+	u.stage(filePhase, func() error {
+		return u.file.Delete(route)
+	}, func() error {
+		return u.file.Write(previous)
+	})
+
+	u.stage(cachePhase, func() error {
+		u.cache.DeleteRoute(route)
+		return nil
+	}, func() error {
+		u.cache.AddRoute(previous)
+		return nil
+	})
+}
+
+// ApplyChanges stages changes that were read from the file, e.g. when it is rewritten by someone else.
+// The file already has them, so only the database and the cache are changed. The cache publishes the
+// routes graph once for all the changes, so searches see either none or all of them.
+// Updated routes that are no longer stored are stored again, and removed ones that are gone are skipped.
+func (u *UnitOfWork) ApplyChanges(changes r.RouteChanges) {
+	upserted := append(append([]r.Route{}, changes.Added...), changes.Updated...)
+	inserted := []r.Route{}
+	restored := []r.Route{}
+
+	for _, route := range upserted {
+		route := route
+
+		u.registerAirport(route.Boarding)
+		u.registerAirport(route.Destination)
+
+		var before *r.Route
+
+		u.stage(databasePhase, func() error {
+			if cost, err := u.routes.GetRouteCost(route.Boarding, route.Destination); err == nil {
+				before = &r.Route{Boarding: route.Boarding, Destination: route.Destination, Cost: cost}
+				restored = append(restored, *before)
+			} else {
+				inserted = append(inserted, route)
+			}
+
+			u.routes.StoreRoute(route)
+
+			return nil
+		}, func() error {
+			if before != nil {
+				u.routes.StoreRoute(*before)
+			} else {
+				u.routes.DeleteRoute(route)
+			}
+
+			return nil
+		})
+	}
+
+	for _, route := range changes.Removed {
+		route := route
+		removed := false
+
+		u.stage(databasePhase, func() error {
+			cost, err := u.routes.GetRouteCost(route.Boarding, route.Destination)
+			if err != nil {
+				return nil
+			}
+
+			route.Cost = cost
+			removed = true
+			restored = append(restored, route)
+			u.routes.DeleteRoute(route)
+
+			return nil
+		}, func() error {
+			if removed {
+				u.routes.StoreRoute(route)
+			}
+
+			return nil
+		})
+
+		u.unregisterAirport(route.Boarding)
+		u.unregisterAirport(route.Destination)
+	}
+
+	u.stage(cachePhase, func() error {
+		u.cache.ApplyChanges(upserted, changes.Removed)
+		return nil
+	}, func() error {
+		u.cache.ApplyChanges(restored, inserted)
+		return nil
+	})
+}
+
+func (u *UnitOfWork) registerAirport(airport string) {
+	registered := false
+
+	u.stage(databasePhase, func() error {
+		if !u.airports.GetAirport(airport) {
+			u.airports.StoreAirport(airport)
+			registered = true
+		}
+
+		return nil
+	}, func() error {
+		if registered {
+			u.airports.DeleteAirport(airport)
+		}
+
+		return nil
+	})
+}
+
+func (u *UnitOfWork) unregisterAirport(airport string) {
+	unregistered := false
+
+	u.stage(databasePhase, func() error {
+		if u.airports.GetAirport(airport) && !u.routes.IsAirportInUse(airport) {
+			u.airports.DeleteAirport(airport)
+			unregistered = true
+		}
+
+		return nil
+	}, func() error {
+		if unregistered {
+			u.airports.StoreAirport(airport)
+		}
+
+		return nil
+	})
+}
+
+// spans are the names of the spans of the phases: applying the cache changes publishes a new routes graph.
+var spans = [phases]string{"database.write", "file.write", "graph.build"}
+
+// applyPhase applies the steps of a phase, appending them to applied, within a span.
+func applyPhase(ctx context.Context, p phase, steps []step, applied []step) ([]step, error) {
+	_, span := tracing.Start(ctx, spans[p], "changes", len(steps))
+	defer span.End()
+
+	for _, s := range steps {
+		err := s.apply()
+		if err != nil {
+			span.RecordError(err)
+			return applied, err
+		}
+
+		applied = append(applied, s)
+	}
+
+	return applied, nil
+}
+
+// Commit applies every staged change. When a step fails, the applied ones are undone and its error is returned.
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "commit")
+	defer span.End()
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	defer u.Rollback()
+
+	applied := []step{}
+
+	for p, steps := range u.steps {
+		if len(steps) == 0 {
+			continue
+		}
+
+		var err error
+
+		applied, err = applyPhase(ctx, phase(p), steps, applied)
+		if err != nil {
+			logger.FromContext(ctx).Error("could not commit the changes", "error", err)
+			span.RecordError(err)
+			undo(ctx, applied)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rollback discards the staged changes that were not committed.
+func (u *UnitOfWork) Rollback() {
+	u.steps = [phases][]step{}
+}
+
+func undo(ctx context.Context, applied []step) {
+	logger.FromContext(ctx).Warn("rolling back the applied changes", "changes", len(applied))
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		err := applied[i].undo()
+		if err != nil {
+			logger.FromContext(ctx).Error("could not roll back a change", "error", err)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package unitofwork
+
+import (
+	"context"
+	"errors"
+	r "go-bestflight/domain/entities/routes"
+	e "go-bestflight/domain/errors"
+	"go-bestflight/resources/cache"
+	"go-bestflight/resources/database"
+	"go-bestflight/resources/file"
+	"sync"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func failing() error {
+	return errors.New("injected failure")
+}
+
+func nothing() error {
+	return nil
+}
+
+func TestUnitOfWork(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	stored := r.Route{Boarding: "GRU", Destination: "CDG", Cost: 75}
+
+	var (
+		lock       sync.Mutex
+		db         *database.Database
+		mc         *cache.Memcache
+		routesFile *file.RoutesFile
+	)
+
+	reset := func() {
+		db = database.New()
+		mc = cache.New()
+		routesFile, _ = file.Open("test.csv")
+	}
+
+	assertUntouched := func() {
+		cost, _ := db.GetRouteCost("GRU", "CDG")
+		routesFromFile, _ := routesFile.ReadFile()
+
+		g.Assert(cost).Equal(75)
+		g.Assert(db.GetAirport("GRU")).IsTrue()
+		g.Assert(db.GetAirport("CDG")).IsTrue()
+		g.Assert(routesFromFile).Equal([]r.Route{stored})
+		g.Assert(mc.GetAllRoutes()).Equal(r.Routes{"GRU": {{Airport: "CDG", Cost: 75}}})
+
+		_, ok := mc.GetGraph().Index("GRU")
+		g.Assert(ok).IsTrue()
+	}
+
+	g.Describe("Tests for StoreRoute", func() {
+		g.BeforeEach(func() {
+			reset()
+
+			work := New(&lock, db, db, mc, routesFile)
+			work.StoreRoute(stored)
+			work.Commit(context.Background())
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should store the route and its airports in every resource", func() {
+			assertUntouched()
+		})
+
+		for p, name := range []string{"database", "file", "cache"} {
+			p := phase(p)
+
+			g.It("should roll back every resource when the "+name+" step fails", func() {
+				work := New(&lock, db, db, mc, routesFile)
+				work.StoreRoute(r.Route{Boarding: "CDG", Destination: "SCL", Cost: 20})
+				work.stage(p, failing, nothing)
+
+				err := work.Commit(context.Background())
+
+				g.Assert(err).Equal(errors.New("injected failure"))
+				g.Assert(db.GetAirport("SCL")).IsFalse()
+
+				_, ok := mc.GetGraph().Index("SCL")
+				g.Assert(ok).IsFalse()
+
+				assertUntouched()
+			})
+		}
+
+		g.It("should store only one of the routes added concurrently and keep the stored one on failure", func() {
+			route := r.Route{Boarding: "CDG", Destination: "SCL", Cost: 20}
+			errs := make(chan error, 10)
+
+			for i := 0; i < 10; i++ {
+				go func(cost int) {
+					work := New(&lock, db, db, mc, routesFile)
+					work.StoreRoute(r.Route{Boarding: route.Boarding, Destination: route.Destination, Cost: cost})
+					errs <- work.Commit(context.Background())
+				}(route.Cost + i)
+			}
+
+			committed := 0
+
+			for i := 0; i < 10; i++ {
+				err := <-errs
+				if err == nil {
+					committed++
+					continue
+				}
+
+				g.Assert(err).Equal(e.NewRouteAlreadyExistErr())
+			}
+
+			cost, _ := db.GetRouteCost("CDG", "SCL")
+			routesFromFile, _ := routesFile.ReadFile()
+
+			g.Assert(committed).Equal(1)
+			g.Assert(len(routesFromFile)).Equal(2)
+			g.Assert(routesFromFile[1].Cost).Equal(cost)
+			g.Assert(mc.GetAllRoutes()["CDG"]).Equal([]r.Connection{{Airport: "SCL", Cost: cost}})
+		})
+
+		g.It("should roll back when the file can not be written", func() {
+			notSynced, _ := file.Open("")
+
+			work := New(&lock, db, db, mc, notSynced)
+			work.StoreRoute(r.Route{Boarding: "CDG", Destination: "SCL", Cost: 20})
+
+			g.Assert(work.Commit(context.Background()) != nil).IsTrue()
+			g.Assert(db.GetAirport("SCL")).IsFalse()
+			g.Assert(db.GetAirport("CDG")).IsTrue()
+			g.Assert(len(mc.GetAllRoutes()["CDG"])).Equal(0)
+		})
+	})
+
+	g.Describe("Tests for StoreNewRoutes", func() {
+		routes := []r.Route{
+			{Boarding: "CDG", Destination: "SCL", Cost: 20},
+			{Boarding: "GRU", Destination: "CDG", Cost: 70},
+			{Boarding: "SCL", Destination: "ORL", Cost: 5},
+		}
+
+		g.BeforeEach(func() {
+			reset()
+
+			work := New(&lock, db, db, mc, routesFile)
+			work.StoreRoute(stored)
+			work.Commit(context.Background())
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should store the new routes and skip the stored ones", func() {
+			skipped := []r.Route{}
+
+			work := New(&lock, db, db, mc, routesFile)
+			work.StoreNewRoutes(routes, func(route r.Route) { skipped = append(skipped, route) })
+
+			g.Assert(work.Commit(context.Background())).Equal(nil)
+			g.Assert(skipped).Equal([]r.Route{routes[1]})
+
+			cost, _ := db.GetRouteCost("GRU", "CDG")
+			routesFromFile, _ := routesFile.ReadFile()
+
+			g.Assert(cost).Equal(75)
+			g.Assert(routesFromFile).Equal([]r.Route{stored, routes[0], routes[2]})
+			g.Assert(mc.GetAllRoutes()).Equal(r.Routes{
+				"GRU": {{Airport: "CDG", Cost: 75}},
+				"CDG": {{Airport: "SCL", Cost: 20}},
+				"SCL": {{Airport: "ORL", Cost: 5}},
+			})
+			g.Assert(mc.GetGraph().Airports()).Equal(4)
+		})
+
+		for p, name := range []string{"database", "file", "cache"} {
+			p := phase(p)
+
+			g.It("should roll back every new route when the "+name+" step fails", func() {
+				work := New(&lock, db, db, mc, routesFile)
+				work.StoreNewRoutes(routes, func(r.Route) {})
+				work.stage(p, failing, nothing)
+
+				err := work.Commit(context.Background())
+
+				g.Assert(err).Equal(errors.New("injected failure"))
+				g.Assert(db.GetAirport("SCL")).IsFalse()
+				g.Assert(db.GetAirport("ORL")).IsFalse()
+
+				_, ok := mc.GetGraph().Index("SCL")
+				g.Assert(ok).IsFalse()
+
+				assertUntouched()
+			})
+		}
+	})
+
+	g.Describe("Tests for UpdateRoute", func() {
+		g.BeforeEach(func() {
+			reset()
+
+			work := New(&lock, db, db, mc, routesFile)
+			work.StoreRoute(stored)
+			work.Commit(context.Background())
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should replace the route cost in every resource", func() {
+			updated := r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30}
+
+			work := New(&lock, db, db, mc, routesFile)
+			work.UpdateRoute(updated)
+
+			g.Assert(work.Commit(context.Background())).Equal(nil)
+
+			cost, _ := db.GetRouteCost("GRU", "CDG")
+			routesFromFile, _ := routesFile.ReadFile()
+
+			g.Assert(cost).Equal(30)
+			g.Assert(routesFromFile).Equal([]r.Route{updated})
+			g.Assert(mc.GetAllRoutes()["GRU"]).Equal([]r.Connection{{Airport: "CDG", Cost: 30}})
+		})
+
+		g.It("should fail with RouteNotFoundErr for a not stored route", func() {
+			work := New(&lock, db, db, mc, routesFile)
+			work.UpdateRoute(r.Route{Boarding: "CDG", Destination: "GRU", Cost: 30})
+
+			g.Assert(work.Commit(context.Background())).Equal(e.NewRouteNotFoundErr())
+			assertUntouched()
+		})
+
+		for p, name := range []string{"database", "file", "cache"} {
+			p := phase(p)
+
+			g.It("should restore the previous cost when the "+name+" step fails", func() {
+				work := New(&lock, db, db, mc, routesFile)
+				work.UpdateRoute(r.Route{Boarding: "GRU", Destination: "CDG", Cost: 30})
+				work.stage(p, failing, nothing)
+
+				g.Assert(work.Commit(context.Background())).Equal(errors.New("injected failure"))
+				assertUntouched()
+			})
+		}
+	})
+
+	g.Describe("Tests for DeleteRoute", func() {
+		g.BeforeEach(func() {
+			reset()
+
+			work := New(&lock, db, db, mc, routesFile)
+			work.StoreRoute(stored)
+			work.Commit(context.Background())
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should remove the route and its unused airports from every resource", func() {
+			work := New(&lock, db, db, mc, routesFile)
+			work.DeleteRoute(stored)
+
+			g.Assert(work.Commit(context.Background())).Equal(nil)
+
+			routesFromFile, _ := routesFile.ReadFile()
+
+			g.Assert(db.GetAirport("GRU")).IsFalse()
+			g.Assert(db.GetAirport("CDG")).IsFalse()
+			g.Assert(len(routesFromFile)).Equal(0)
+			g.Assert(mc.GetAllRoutes()).Equal(r.Routes{})
+		})
+
+		for p, name := range []string{"database", "file", "cache"} {
+			p := phase(p)
+
+			g.It("should restore the route and its airports when the "+name+" step fails", func() {
+				work := New(&lock, db, db, mc, routesFile)
+				work.DeleteRoute(stored)
+				work.stage(p, failing, nothing)
+
+				g.Assert(work.Commit(context.Background())).Equal(errors.New("injected failure"))
+				assertUntouched()
+			})
+		}
+	})
+
+	g.Describe("Tests for Rollback", func() {
+		g.BeforeEach(func() {
+			reset()
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		g.It("should discard the staged changes", func() {
+			work := New(&lock, db, db, mc, routesFile)
+			work.StoreRoute(stored)
+			work.Rollback()
+
+			g.Assert(work.Commit(context.Background())).Equal(nil)
+			g.Assert(db.GetAirport("GRU")).IsFalse()
+			g.Assert(mc.GetAllRoutes()).Equal(r.Routes{})
+		})
+	})
+
+	g.Describe("Tests for ApplyChanges", func() {
+		g.BeforeEach(func() {
+			reset()
+
+			work := New(&lock, db, db, mc, routesFile)
+			work.StoreRoute(stored)
+			work.StoreRoute(r.Route{Boarding: "CDG", Destination: "SCL", Cost: 20})
+			work.Commit(context.Background())
+		})
+
+		g.AfterEach(func() {
+			routesFile.Remove()
+		})
+
+		changes := r.RouteChanges{
+			Added:   []r.Route{{Boarding: "SCL", Destination: "ORL", Cost: 5}},
+			Updated: []r.Route{{Boarding: "GRU", Destination: "CDG", Cost: 30}},
+			Removed: []r.Route{{Boarding: "CDG", Destination: "SCL", Cost: 20}},
+		}
+
+		g.It("should apply the changes to the database and cache but not to the file", func() {
+			work := New(&lock, db, db, mc, routesFile)
+			work.ApplyChanges(changes)
+
+			g.Assert(work.Commit(context.Background())).Equal(nil)
+
+			cost, _ := db.GetRouteCost("GRU", "CDG")
+			_, err := db.GetRouteCost("CDG", "SCL")
+			routesFromFile, _ := routesFile.ReadFile()
+
+			g.Assert(cost).Equal(30)
+			g.Assert(err != nil).IsTrue()
+			g.Assert(db.GetAirport("ORL")).IsTrue()
+			g.Assert(db.GetAirport("SCL")).IsTrue()
+			g.Assert(mc.GetAllRoutes()).Equal(r.Routes{
+				"GRU": {{Airport: "CDG", Cost: 30}},
+				"SCL": {{Airport: "ORL", Cost: 5}},
+			})
+			g.Assert(len(routesFromFile)).Equal(2)
+		})
+
+		g.It("should skip removed routes that are gone and store updated ones again", func() {
+			work := New(&lock, db, db, mc, routesFile)
+			work.ApplyChanges(r.RouteChanges{
+				Updated: []r.Route{{Boarding: "ORL", Destination: "GRU", Cost: 8}},
+				Removed: []r.Route{{Boarding: "BRC", Destination: "SCL", Cost: 1}},
+			})
+
+			g.Assert(work.Commit(context.Background())).Equal(nil)
+
+			cost, _ := db.GetRouteCost("ORL", "GRU")
+			g.Assert(cost).Equal(8)
+		})
+
+		failures := []struct {
+			phase phase
+			name  string
+		}{
+			{databasePhase, "database"},
+			{cachePhase, "cache"},
+		}
+
+		for _, failure := range failures {
+			p := failure.phase
+
+			g.It("should leave the database and cache untouched when the "+failure.name+" step fails", func() {
+				work := New(&lock, db, db, mc, routesFile)
+				work.ApplyChanges(changes)
+				work.stage(p, failing, nothing)
+
+				g.Assert(work.Commit(context.Background())).Equal(errors.New("injected failure"))
+
+				cost, _ := db.GetRouteCost("CDG", "SCL")
+
+				g.Assert(cost).Equal(20)
+				g.Assert(db.GetAirport("ORL")).IsFalse()
+				g.Assert(mc.GetAllRoutes()).Equal(r.Routes{
+					"GRU": {{Airport: "CDG", Cost: 75}},
+					"CDG": {{Airport: "SCL", Cost: 20}},
+				})
+			})
+		}
+	})
+}
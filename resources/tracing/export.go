@@ -0,0 +1,206 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-bestflight/resources/logger"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// The statuses of the spans.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// SpanData is an ended span, as exported.
+type SpanData struct {
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Name         string                 `json:"name"`
+	Start        time.Time              `json:"start_time"`
+	End          time.Time              `json:"end_time"`
+	Duration     float64                `json:"duration_seconds"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Status       string                 `json:"status"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// Exporter sends the ended spans somewhere they can be looked at.
+type Exporter interface {
+	Export(span SpanData)
+	// Close exports the spans still held and releases the exporter.
+	Close() error
+}
+
+// WriterExporter writes every span as a line of JSON.
+type WriterExporter struct {
+	w      io.Writer
+	closer io.Closer // the file opened by OpenFileExporter, if any
+	sync.Mutex
+}
+
+// NewWriterExporter is a constructor for a WriterExporter writing to w.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// OpenFileExporter returns a WriterExporter appending to a file, created if it does not exist.
+func OpenFileExporter(path string) (*WriterExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the traces file: %w", err)
+	}
+
+	return &WriterExporter{w: file, closer: file}, nil
+}
+
+// Export writes the span.
+func (e *WriterExporter) Export(span SpanData) {
+	line, err := json.Marshal(span)
+	if err != nil {
+		logger.Warn("could not export a span", "span", span.Name, "error", err)
+		return
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	e.w.Write(append(line, '\n'))
+}
+
+// Close closes the file opened by OpenFileExporter. The writers given to NewWriterExporter are left open.
+func (e *WriterExporter) Close() error {
+	if e.closer == nil {
+		return nil
+	}
+
+	return e.closer.Close()
+}
+
+// The batches of a CollectorExporter.
+const (
+	CollectorBatchSize = 100
+	CollectorInterval  = 2 * time.Second
+	collectorQueueSize = 10 * CollectorBatchSize
+)
+
+// CollectorExporter posts the spans as JSON, e.g. {"spans":[...]}, to the URL of a collector, in
+// batches of up to CollectorBatchSize spans or every CollectorInterval. The spans are queued, so
+// exporting them never waits for the collector: when the queue is full they are dropped.
+type CollectorExporter struct {
+	url    string
+	client *http.Client
+	spans  chan SpanData
+	done   chan struct{}
+	closed bool
+	sync.Mutex
+}
+
+// NewCollectorExporter is a constructor for a CollectorExporter posting to url.
+func NewCollectorExporter(url string) *CollectorExporter {
+	e := &CollectorExporter{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		spans:  make(chan SpanData, collectorQueueSize),
+		done:   make(chan struct{}),
+	}
+
+	go e.run()
+
+	return e
+}
+
+// Export queues the span.
+func (e *CollectorExporter) Export(span SpanData) {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.closed {
+		return
+	}
+
+	select {
+	case e.spans <- span:
+	default:
+		logger.Warn("span dropped, the collector queue is full", "span", span.Name)
+	}
+}
+
+func (e *CollectorExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(CollectorInterval)
+	defer ticker.Stop()
+
+	batch := []SpanData{}
+
+	for {
+		select {
+		case span, ok := <-e.spans:
+			if !ok {
+				e.post(batch)
+				return
+			}
+
+			batch = append(batch, span)
+
+			if len(batch) == CollectorBatchSize {
+				e.post(batch)
+				batch = []SpanData{}
+			}
+		case <-ticker.C:
+			e.post(batch)
+			batch = []SpanData{}
+		}
+	}
+}
+
+func (e *CollectorExporter) post(batch []SpanData) {
+	if len(batch) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(struct {
+		Spans []SpanData `json:"spans"`
+	}{batch})
+	if err != nil {
+		logger.Warn("could not export the spans", "url", e.url, "spans", len(batch), "error", err)
+		return
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Warn("could not export the spans", "url", e.url, "spans", len(batch), "error", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		logger.Warn("the collector refused the spans", "url", e.url, "spans", len(batch), "status", resp.StatusCode)
+	}
+}
+
+// Close posts the queued spans and stops the exporter.
+func (e *CollectorExporter) Close() error {
+	e.Lock()
+
+	if e.closed {
+		e.Unlock()
+		return nil
+	}
+
+	e.closed = true
+	close(e.spans)
+	e.Unlock()
+
+	<-e.done
+
+	return nil
+}
@@ -0,0 +1,298 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Header is the W3C Trace Context header carrying the trace and the parent span of a request.
+const Header = "traceparent"
+
+// TraceID identifies a trace, made of the spans of a request across every service it goes through.
+type TraceID [16]byte
+
+// SpanID identifies a span of a trace.
+type SpanID [8]byte
+
+// String returns the id in lowercase hex, as in the traceparent header.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid tells whether the id is not made of zeros only.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String returns the id in lowercase hex, as in the traceparent header.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid tells whether the id is not made of zeros only.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext identifies a span across processes.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid tells whether both ids are valid.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent returns the value of the traceparent header of the span, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent reads the span context of a traceparent header of the version 00. Headers of
+// later versions are read as far as they are compatible, as the specification asks.
+func ParseTraceparent(header string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, errInvalidTraceparent
+	}
+
+	var sc SpanContext
+
+	if !decodeHex(parts[0], make([]byte, 1)) || !decodeHex(parts[1], sc.TraceID[:]) || !decodeHex(parts[2], sc.SpanID[:]) {
+		return SpanContext{}, errInvalidTraceparent
+	}
+
+	flags := make([]byte, 1)
+	if !decodeHex(parts[3], flags) || !sc.IsValid() {
+		return SpanContext{}, errInvalidTraceparent
+	}
+
+	sc.Sampled = flags[0]&1 == 1
+
+	return sc, nil
+}
+
+// decodeHex decodes a lowercase hex string of exactly the length of dst.
+func decodeHex(s string, dst []byte) bool {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(s))
+
+	return err == nil
+}
+
+// Span is a timed operation of a trace, e.g. a request or a search. Its attributes are given as
+// alternating keys and values, like the fields of the log. It is exported once it ends.
+type Span struct {
+	tracer     *Tracer
+	context    SpanContext
+	parent     SpanID
+	name       string
+	start      time.Time
+	attributes map[string]interface{}
+	err        error
+	ended      int32
+	sync.Mutex
+}
+
+// Context returns the span context, to be sent to other services.
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+// SetName replaces the name of the span, e.g. once the route of a request is known.
+func (s *Span) SetName(name string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.name = name
+}
+
+// SetAttributes adds attributes to the span, replacing the ones with the same keys.
+func (s *Span) SetAttributes(keyvals ...interface{}) {
+	s.Lock()
+	defer s.Unlock()
+
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		s.attributes[fmt.Sprint(keyvals[i])] = value(keyvals[i+1])
+	}
+}
+
+// RecordError marks the span as failed by err, unless err is nil.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.err = err
+}
+
+// End ends the span and exports it. Only the first call has any effect.
+func (s *Span) End() {
+	if !atomic.CompareAndSwapInt32(&s.ended, 0, 1) {
+		return
+	}
+
+	end := s.tracer.now()
+
+	s.Lock()
+	data := SpanData{
+		TraceID:    s.context.TraceID.String(),
+		SpanID:     s.context.SpanID.String(),
+		Name:       s.name,
+		Start:      s.start,
+		End:        end,
+		Duration:   end.Sub(s.start).Seconds(),
+		Attributes: s.attributes,
+		Status:     StatusOK,
+	}
+
+	if s.parent.IsValid() {
+		data.ParentSpanID = s.parent.String()
+	}
+
+	if s.err != nil {
+		data.Status = StatusError
+		data.Error = s.err.Error()
+	}
+	s.Unlock()
+
+	s.tracer.export(data)
+}
+
+// value returns what is exported for an attribute value: the text of errors and fmt.Stringer values,
+// and the value itself otherwise.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithRemote returns a copy of ctx where the spans started without a parent span continue
+// the trace of a remote span, e.g. the one of a traceparent header.
+func ContextWithRemote(ctx context.Context, remote SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, remote)
+}
+
+// FromContext returns the span of the context, or nil when there is none.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+func newID(id []byte) {
+	rand.Read(id)
+}
+
+// Tracer starts spans and exports them once they end.
+type Tracer struct {
+	exporter Exporter
+	now      func() time.Time
+}
+
+// New is a constructor for a Tracer exporting its spans to exporter. With a nil exporter the spans are
+// still started, so their ids are propagated, but they are not exported.
+func New(exporter Exporter) *Tracer {
+	return &Tracer{
+		exporter: exporter,
+		now:      time.Now,
+	}
+}
+
+var instance atomic.Value
+
+func init() {
+	instance.Store(New(nil))
+}
+
+// Default returns the Tracer shared by the application. It exports nothing until SetDefault replaces it.
+func Default() *Tracer {
+	return instance.Load().(*Tracer)
+}
+
+// SetDefault replaces the Tracer shared by the application.
+func SetDefault(t *Tracer) {
+	instance.Store(t)
+}
+
+// Start starts a span child of the span of ctx or, when there is none, of the remote span of ctx.
+// Without either, the span starts a new trace. The returned context holds the new span.
+func (t *Tracer) Start(ctx context.Context, name string, keyvals ...interface{}) (context.Context, *Span) {
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		start:      t.now(),
+		attributes: make(map[string]interface{}),
+	}
+
+	if parent := FromContext(ctx); parent != nil {
+		span.context = parent.context
+		span.parent = parent.context.SpanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok && remote.IsValid() {
+		span.context = remote
+		span.parent = remote.SpanID
+	} else {
+		newID(span.context.TraceID[:])
+		span.context.Sampled = true
+	}
+
+	newID(span.context.SpanID[:])
+	span.SetAttributes(keyvals...)
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (t *Tracer) export(data SpanData) {
+	if t.exporter != nil {
+		t.exporter.Export(data)
+	}
+}
+
+// Close closes the exporter, exporting the spans it still holds.
+func (t *Tracer) Close() error {
+	if t.exporter == nil {
+		return nil
+	}
+
+	return t.exporter.Close()
+}
+
+// The functions below use the Tracer shared by the application.
+
+// Start starts a span child of the span of ctx or, when there is none, of the remote span of ctx.
+// Without either, the span starts a new trace. The returned context holds the new span.
+func Start(ctx context.Context, name string, keyvals ...interface{}) (context.Context, *Span) {
+	return Default().Start(ctx, name, keyvals...)
+}
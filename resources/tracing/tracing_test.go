@@ -0,0 +1,147 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+)
+
+func newTestTracer() (*Tracer, *bytes.Buffer) {
+	var output bytes.Buffer
+
+	t := New(NewWriterExporter(&output))
+	t.now = func() time.Time { return time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	return t, &output
+}
+
+func exported(output *bytes.Buffer) []SpanData {
+	spans := []SpanData{}
+
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var span SpanData
+		json.Unmarshal([]byte(line), &span)
+		spans = append(spans, span)
+	}
+
+	return spans
+}
+
+func TestTracing(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Tests for ParseTraceparent", func() {
+		g.It("should read the ids and the sampled flag", func() {
+			sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+			g.Assert(err).Equal(nil)
+			g.Assert(sc.TraceID.String()).Equal("4bf92f3577b34da6a3ce929d0e0e4736")
+			g.Assert(sc.SpanID.String()).Equal("00f067aa0ba902b7")
+			g.Assert(sc.Sampled).IsTrue()
+			g.Assert(sc.Traceparent()).Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		})
+
+		g.It("should read the compatible part of later versions", func() {
+			sc, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+
+			g.Assert(err).Equal(nil)
+			g.Assert(sc.Sampled).IsFalse()
+		})
+
+		g.It("should refuse malformed headers and zero ids", func() {
+			for _, header := range []string{
+				"",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+				"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+				"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+				"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+			} {
+				_, err := ParseTraceparent(header)
+				g.Assert(err == nil).IsFalse()
+			}
+		})
+	})
+
+	g.Describe("Tests for Tracer", func() {
+		g.It("should export the children spans in the trace of their parent", func() {
+			tracer, output := newTestTracer()
+
+			ctx, parent := tracer.Start(context.Background(), "request", "request_id", "abc")
+			_, child := tracer.Start(ctx, "search", "board", "GRU")
+			child.SetAttributes("expanded", 4)
+			child.RecordError(errors.New("best route not found"))
+			child.End()
+			child.End()
+			parent.End()
+
+			spans := exported(output)
+
+			g.Assert(len(spans)).Equal(2)
+			g.Assert(spans[0].Name).Equal("search")
+			g.Assert(spans[0].TraceID).Equal(spans[1].TraceID)
+			g.Assert(spans[0].ParentSpanID).Equal(spans[1].SpanID)
+			g.Assert(spans[0].Attributes).Equal(map[string]interface{}{"board": "GRU", "expanded": float64(4)})
+			g.Assert(spans[0].Status).Equal(StatusError)
+			g.Assert(spans[0].Error).Equal("best route not found")
+			g.Assert(spans[1].ParentSpanID).Equal("")
+			g.Assert(spans[1].Status).Equal(StatusOK)
+			g.Assert(FromContext(ctx)).Equal(parent)
+		})
+
+		g.It("should continue the trace of a remote span", func() {
+			tracer, output := newTestTracer()
+			remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+			_, span := tracer.Start(ContextWithRemote(context.Background(), remote), "request")
+			span.End()
+
+			spans := exported(output)
+
+			g.Assert(spans[0].TraceID).Equal("4bf92f3577b34da6a3ce929d0e0e4736")
+			g.Assert(spans[0].ParentSpanID).Equal("00f067aa0ba902b7")
+			g.Assert(span.Context().SpanID == remote.SpanID).IsFalse()
+		})
+	})
+
+	g.Describe("Tests for CollectorExporter", func() {
+		g.It("should post the queued spans when closed", func() {
+			received := make(chan []SpanData, 1)
+
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				var body struct {
+					Spans []SpanData `json:"spans"`
+				}
+
+				json.NewDecoder(req.Body).Decode(&body)
+				received <- body.Spans
+			}))
+			defer collector.Close()
+
+			tracer := New(NewCollectorExporter(collector.URL))
+
+			for _, name := range []string{"search", "commit"} {
+				_, span := tracer.Start(context.Background(), name)
+				span.End()
+			}
+
+			g.Assert(tracer.Close()).Equal(nil)
+
+			spans := <-received
+
+			g.Assert(len(spans)).Equal(2)
+			g.Assert(spans[0].Name).Equal("search")
+			g.Assert(spans[1].Name).Equal("commit")
+		})
+	})
+}